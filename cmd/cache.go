@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/belingud/gptcomet/internal/cache"
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// newResponseCache returns the response cache in the config directory with the
+// TTL and size limit of the config, or nil if caching is disabled or the config
+// directory can't be found, in which case every request is sent.
+func newResponseCache(cfgManager config.ManagerInterface) *cache.Cache {
+	if !cfgManager.GetCacheEnabled() {
+		return nil
+	}
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		logger.Debug("Response cache disabled: %v", err)
+		return nil
+	}
+	return cache.New(dir, cfgManager.GetCacheTTL(), cfgManager.GetCacheMaxSize())
+}
+
+// NewCacheCmd creates and returns a new cobra.Command for the 'cache' subcommand,
+// which manages the cache of generated commit messages, review comments and
+// translations.
+//
+// Subcommands:
+//   - clear: Remove all cached responses
+func NewCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of generated responses",
+	}
+
+	cmd.AddCommand(newClearCacheCmd())
+
+	return cmd
+}
+
+// newClearCacheCmd creates and returns a new cobra.Command that removes all
+// cached responses.
+func newClearCacheCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached responses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := config.GetCacheDir()
+			if err != nil {
+				return fmt.Errorf("failed to get cache directory: %w", err)
+			}
+
+			return runClearCache(os.Stdout, cache.New(dir, 0, 0))
+		},
+	}
+}
+
+// runClearCache removes all entries of c and reports how many were removed to w
+func runClearCache(w io.Writer, c *cache.Cache) error {
+	removed, err := c.Clear()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Removed %d cached responses from %s\n", removed, c.Dir())
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/belingud/gptcomet/internal/cache"
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResponseCache(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		cfgManager := &testutils.MockConfigManager{}
+		cfgManager.On("GetCacheEnabled").Return(false)
+
+		assert.Nil(t, newResponseCache(cfgManager))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		cfgManager := &testutils.MockConfigManager{}
+		cfgManager.On("GetCacheEnabled").Return(true)
+		cfgManager.On("GetCacheTTL").Return(time.Hour)
+		cfgManager.On("GetCacheMaxSize").Return(int64(1024))
+
+		c := newResponseCache(cfgManager)
+		require.NotNil(t, c)
+		assert.Contains(t, c.Dir(), "gptcomet")
+	})
+}
+
+func TestRunClearCache(t *testing.T) {
+	c := cache.New(t.TempDir(), 0, 0)
+	require.NoError(t, c.Put(cache.Key("a"), "first"))
+	require.NoError(t, c.Put(cache.Key("b"), "second"))
+
+	var buf bytes.Buffer
+	require.NoError(t, runClearCache(&buf, c))
+	assert.Contains(t, buf.String(), "Removed 2 cached responses")
+
+	_, ok := c.Get(cache.Key("a"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/chunk"
+	"github.com/belingud/gptcomet/internal/client"
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/logger"
+	"github.com/belingud/gptcomet/internal/ui"
+)
+
+// summarizeDiff returns the diff unchanged, unless chunking is enabled (in the
+// config or with force) and the diff exceeds the chunk token budget. In that case
+// the diff is split into chunks, every chunk is summarized with the chunk summary
+// prompt, and the joined summaries are returned to be used in place of the diff.
+//
+// Parameters:
+//   - ctx: The context of the requests
+//   - c: The client used to summarize the chunks
+//   - cfgManager: The config manager holding the chunk settings and prompt
+//   - diff: The diff to summarize
+//   - force: Summarize in chunks even if chunk.enabled is false
+//   - verbose: Report the progress of every chunk
+//
+// Returns:
+//   - string: The diff itself or the summaries of its chunks
+//   - error: An error if summarizing any of the chunks fails
+func summarizeDiff(ctx context.Context, c client.ClientInterface, cfgManager config.ManagerInterface, diff string, force bool, verbose bool) (string, error) {
+	if !force && !cfgManager.GetChunkEnabled() {
+		return diff, nil
+	}
+
+	budget := cfgManager.GetChunkTokenBudget()
+	tokens := chunk.EstimateTokens(diff)
+	if tokens <= budget {
+		return diff, nil
+	}
+
+	fmt.Println(formatRemindMessage(fmt.Sprintf("Diff has about %d tokens, more than the budget of %d, summarizing it in chunks...", tokens, budget)))
+
+	var progress *ui.Progress
+	if verbose {
+		progress = ui.NewProgress(true)
+	}
+
+	prompt := cfgManager.GetChunkSummaryPrompt()
+	return chunk.Summarize(diff, budget, func(part string) (string, error) {
+		logger.Debug("Summarizing chunk of length: %d", len(part))
+		resp, err := c.Chat(ctx, strings.Replace(prompt, "{{ placeholder }}", part, 1), nil)
+		if err != nil {
+			return "", err
+		}
+		return removeThinkTags(resp.Content)
+	}, progress)
+}
+
+// summarizeDiff summarizes the diff in chunks when needed, see summarizeDiff.
+// The result is kept for the last diff, so retries and feedback on the same
+// staged changes don't summarize them again.
+func (s *CommitService) summarizeDiff(ctx context.Context, diff string) (string, error) {
+	if s.summarizedDiff == diff && s.diffSummary != "" {
+		return s.diffSummary, nil
+	}
+
+	summary, err := summarizeDiff(ctx, s.client, s.cfgManager, diff, s.options.Chunk, s.getVerboseSetting())
+	if err != nil {
+		return "", err
+	}
+	s.summarizedDiff, s.diffSummary = diff, summary
+	return summary, nil
+}
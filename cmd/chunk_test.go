@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSummarizeDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n"
+
+	tests := []struct {
+		name        string
+		force       bool
+		setupMocks  func(cfg *testutils.MockConfigManager, client *MockClient)
+		want        string
+		wantSummary bool
+		wantErr     bool
+	}{
+		{
+			name: "disabled",
+			setupMocks: func(cfg *testutils.MockConfigManager, client *MockClient) {
+				cfg.On("GetChunkEnabled").Return(false)
+			},
+			want: diff,
+		},
+		{
+			name: "within_budget",
+			setupMocks: func(cfg *testutils.MockConfigManager, client *MockClient) {
+				cfg.On("GetChunkEnabled").Return(true)
+				cfg.On("GetChunkTokenBudget").Return(1000)
+			},
+			want: diff,
+		},
+		{
+			name: "enabled_over_budget",
+			setupMocks: func(cfg *testutils.MockConfigManager, client *MockClient) {
+				cfg.On("GetChunkEnabled").Return(true)
+				cfg.On("GetChunkTokenBudget").Return(5)
+				cfg.On("GetChunkSummaryPrompt").Return("summarize {{ placeholder }}")
+				client.On("Chat", mock.Anything, mock.MatchedBy(func(msg string) bool {
+					return strings.HasPrefix(msg, "summarize diff --git a/a.go b/a.go")
+				}), []types.Message(nil)).Return(&types.CompletionResponse{Content: "<thinking>hmm</thinking>changed a.go"}, nil)
+			},
+			wantSummary: true,
+		},
+		{
+			name:  "forced_over_budget",
+			force: true,
+			setupMocks: func(cfg *testutils.MockConfigManager, client *MockClient) {
+				cfg.On("GetChunkTokenBudget").Return(5)
+				cfg.On("GetChunkSummaryPrompt").Return("summarize {{ placeholder }}")
+				client.On("Chat", mock.Anything, mock.Anything, []types.Message(nil)).
+					Return(&types.CompletionResponse{Content: "changed a.go"}, nil)
+			},
+			wantSummary: true,
+		},
+		{
+			name: "summary_error",
+			setupMocks: func(cfg *testutils.MockConfigManager, client *MockClient) {
+				cfg.On("GetChunkEnabled").Return(true)
+				cfg.On("GetChunkTokenBudget").Return(5)
+				cfg.On("GetChunkSummaryPrompt").Return("summarize {{ placeholder }}")
+				client.On("Chat", mock.Anything, mock.Anything, []types.Message(nil)).
+					Return(nil, errors.New("api error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCfg := new(testutils.MockConfigManager)
+			mockClient := new(MockClient)
+			tt.setupMocks(mockCfg, mockClient)
+
+			got, err := summarizeDiff(context.Background(), mockClient, mockCfg, diff, tt.force, false)
+
+			mockCfg.AssertExpectations(t)
+			mockClient.AssertExpectations(t)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantSummary {
+				assert.Contains(t, got, "changed a.go")
+				assert.NotContains(t, got, "<thinking>")
+				assert.NotContains(t, got, "+new")
+			} else {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCommitService_summarizeDiffIsMemoized(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n"
+
+	mockCfg := new(testutils.MockConfigManager)
+	mockCfg.On("GetChunkTokenBudget").Return(5)
+	mockCfg.On("GetChunkSummaryPrompt").Return("summarize {{ placeholder }}")
+	mockCfg.On("GetNestedValue", []string{"console", "verbose"}).Return(false, true)
+	mockClient := new(MockClient)
+	mockClient.On("Chat", mock.Anything, mock.Anything, []types.Message(nil)).
+		Return(&types.CompletionResponse{Content: "changed a.go"}, nil)
+
+	service := &CommitService{
+		client:     mockClient,
+		cfgManager: mockCfg,
+		options:    CommitOptions{Chunk: true},
+	}
+
+	first, err := service.summarizeDiff(context.Background(), diff)
+	assert.NoError(t, err)
+	calls := len(mockClient.Calls)
+	assert.Greater(t, calls, 0)
+
+	second, err := service.summarizeDiff(context.Background(), diff)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Len(t, mockClient.Calls, calls, "summary of the same diff should be reused")
+}
@@ -23,6 +23,11 @@ type CommitOptions struct {
 	AutoYes    bool
 	ConfigPath string
 	NoVerify   bool
+	Stream     bool
+	Candidates int
+	Chunk      bool
+	NoCache    bool
+	Amend      bool
 }
 
 // CommitService handles the logic for committing changes to version control
@@ -35,7 +40,17 @@ type CommitService struct {
 	cfgManager   config.ManagerInterface
 	options      CommitOptions
 	editor       TextEditor
+	picker       CandidatePicker
 	clientConfig *types.ClientConfig
+
+	// conversation holds the prompt and answers of the current commit message,
+	// so it can be refined with feedback
+	conversation []types.Message
+	// lastMessage is the last commit message shown to the user
+	lastMessage string
+	// summarizedDiff and diffSummary remember the last diff summarized in chunks
+	summarizedDiff string
+	diffSummary    string
 }
 
 // NewCommitService creates a new CommitService instance with the provided options.
@@ -66,10 +81,14 @@ func NewCommitService(options CommitOptions) (*CommitService, error) {
 	// Overwrite client config with command line flags
 	ApplyCommonOptions(&options.CommonOptions, clientConfig)
 
-	apiClient, err := client.New(clientConfig)
+	apiClient, err := factory.NewClientWithFallbacks(cfgManager, clientConfig)
 	if err != nil {
 		return nil, err
 	}
+	apiClient.SetLedger(newUsageLedger(), "commit")
+	if !options.NoCache {
+		apiClient.SetCache(newResponseCache(cfgManager))
+	}
 
 	return &CommitService{
 		vcs:          vcs,
@@ -77,6 +96,7 @@ func NewCommitService(options CommitOptions) (*CommitService, error) {
 		cfgManager:   cfgManager,
 		options:      options,
 		editor:       &TerminalEditor{},
+		picker:       &TerminalCandidatePicker{},
 		clientConfig: clientConfig,
 	}, nil
 }
@@ -91,6 +111,11 @@ func NewCommitService(options CommitOptions) (*CommitService, error) {
 //   - --dry-run: Preview the generated commit message without actually committing (bool)
 //   - --svn: Use SVN instead of Git for version control operations (bool)
 //   - --no-verify: Skip git hooks verification, akin to using 'git commit --no-verify' (bool)
+//   - --stream, -s: Stream the commit message as it is generated (bool)
+//   - --candidates: Generate several commit messages and pick one of them (int)
+//   - --chunk: Summarize large diffs in chunks even if chunk.enabled is false (bool)
+//   - --no-cache: Always send a new request instead of using a cached response (bool)
+//   - --amend: Regenerate the message of the last commit, with the staged changes, and amend it (bool)
 //   - --api-base: Override API base URL (string)
 //   - --api-key: Override API key (string)
 //   - --max-tokens: Override maximum tokens (int)
@@ -136,7 +161,7 @@ func NewCommitCmd() *cobra.Command {
 				return err
 			}
 
-			return service.Execute()
+			return service.Execute(cmd.Context())
 		},
 	}
 
@@ -150,6 +175,11 @@ func NewCommitCmd() *cobra.Command {
 	generalFlags.BoolVar(&options.NoVerify, "no-verify", false, "Skip git hooks verification, akin to using 'git commit --no-verify'")
 	generalFlags.BoolVar(&options.DryRun, "dry-run", false, "Print the generated commit message and exit without committing")
 	generalFlags.BoolVar(&options.UseSVN, "svn", false, "Use SVN instead of Git")
+	generalFlags.BoolVarP(&options.Stream, "stream", "s", false, "Stream the commit message as it is generated")
+	generalFlags.IntVar(&options.Candidates, "candidates", 0, "Generate N commit messages and pick one of them")
+	generalFlags.BoolVar(&options.Chunk, "chunk", false, "Summarize diffs larger than chunk.token_budget in chunks first")
+	generalFlags.BoolVar(&options.NoCache, "no-cache", false, "Always send a new request instead of using a cached response")
+	generalFlags.BoolVar(&options.Amend, "amend", false, "Regenerate the message of the last commit and amend it, with the staged changes")
 
 	// Advanced API Flags (shared with other commands)
 	AddAdvancedAPIFlags(advancedFlags, &options.CommonOptions)
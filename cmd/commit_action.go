@@ -2,34 +2,131 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 
+	"github.com/belingud/gptcomet/internal/client"
 	"github.com/belingud/gptcomet/internal/debug"
 	gptcometerrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/internal/ui"
+	"github.com/belingud/gptcomet/pkg/types"
 )
 
 // generateCommitMessage generates a commit message based on the provided git diff.
-// It uses the configured prompt template (either rich or standard) to generate the message.
-// If the configured output language is not English, it also translates the message
-// to the target language using a translation prompt.
+// It uses the configured prompt template (either rich or standard) to generate the message,
+// streaming it to the terminal when the stream option is enabled.
+// Diffs that exceed the chunk token budget are summarized in chunks first when
+// chunking is enabled. Thinking tags are removed from the generated message, and if
+// the configured output language is not English, the message is translated to the target language using a
+// translation prompt.
 //
 // Parameters:
+//   - ctx: The context of the command, cancelled on Ctrl-C
 //   - diff: The git diff string to generate the commit message from
 //
 // Returns:
 //   - string: The generated (and optionally translated) commit message
 //   - error: An error if message generation or translation fails, or if config is invalid
-func (s *CommitService) generateCommitMessage(diff string) (string, error) {
+func (s *CommitService) generateCommitMessage(ctx context.Context, diff string) (string, error) {
+	diff, err := s.summarizeDiff(ctx, diff)
+	if err != nil {
+		return "", err
+	}
+
 	prompt := s.cfgManager.GetPrompt(s.options.Rich)
-	msg, err := s.client.GenerateCommitMessage(diff, prompt)
+	var msg string
+	if s.options.Stream {
+		msg, err = s.streamCommitMessage(ctx, diff, prompt)
+	} else {
+		msg, err = s.client.GenerateCommitMessage(ctx, diff, prompt)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	msg, err = removeThinkTags(msg)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the conversation so the message can be refined with feedback later
+	s.conversation = []types.Message{
+		{Role: types.RoleUser, Content: strings.Replace(prompt, "{{ placeholder }}", diff, 1)},
+		{Role: types.RoleAssistant, Content: msg},
+	}
+
+	translated, err := s.translateCommitMessage(ctx, msg)
 	if err != nil {
 		return "", err
 	}
+	s.lastMessage = translated
+	return translated, nil
+}
 
+// refineCommitMessage asks the LLM to revise the current commit message according
+// to the user's feedback. The feedback is sent as a follow-up turn after the original
+// prompt with the diff and the previous answers, so it can be repeated to refine the
+// message step by step.
+//
+// Parameters:
+//   - ctx: The context of the command, cancelled on Ctrl-C
+//   - currentMsg: The commit message currently shown to the user, possibly edited
+//   - feedback: The user's note on what to change
+//
+// Returns:
+//   - string: The revised (and optionally translated) commit message
+//   - error: An error if fetching the diff, the request or the translation fails
+func (s *CommitService) refineCommitMessage(ctx context.Context, currentMsg string, feedback string) (string, error) {
+	if len(s.conversation) == 0 {
+		diff, err := s.getDiff()
+		if err != nil {
+			return "", err
+		}
+		diff, err = s.summarizeDiff(ctx, diff)
+		if err != nil {
+			return "", err
+		}
+		prompt := s.cfgManager.GetPrompt(s.options.Rich)
+		s.conversation = []types.Message{
+			{Role: types.RoleUser, Content: strings.Replace(prompt, "{{ placeholder }}", diff, 1)},
+			{Role: types.RoleAssistant, Content: currentMsg},
+		}
+	} else if currentMsg != s.lastMessage {
+		// The message was edited, refine the edited version instead
+		s.conversation[len(s.conversation)-1].Content = currentMsg
+	}
+
+	feedbackPrompt := strings.Replace(s.cfgManager.GetFeedbackPrompt(), "{{ placeholder }}", feedback, 1)
+	resp, err := s.client.Chat(ctx, feedbackPrompt, s.conversation)
+	if err != nil {
+		return "", err
+	}
+
+	msg, err := removeThinkTags(strings.TrimSpace(resp.Content))
+	if err != nil {
+		return "", err
+	}
+
+	s.conversation = append(s.conversation,
+		types.Message{Role: types.RoleUser, Content: feedbackPrompt},
+		types.Message{Role: types.RoleAssistant, Content: msg},
+	)
+
+	translated, err := s.translateCommitMessage(ctx, msg)
+	if err != nil {
+		return "", err
+	}
+	s.lastMessage = translated
+	return translated, nil
+}
+
+// translateCommitMessage translates the commit message if the configured output
+// language is not English. If translate_title is disabled, only the content after
+// the conventional commit prefix is translated.
+func (s *CommitService) translateCommitMessage(ctx context.Context, msg string) (string, error) {
 	// Translate commit message if output.lang is not en
 	langValue, ok := s.cfgManager.Get(LANGUAGE_KEY)
 	if !ok {
@@ -58,7 +155,7 @@ func (s *CommitService) generateCommitMessage(diff string) (string, error) {
 		debug.Printf("Split commit message: prefix=%s, content=%s\n", prefix, content)
 		if prefix != "" {
 			// Translate only the content part
-			translatedContent, err := s.client.TranslateMessage(translatePrompt, content, lang)
+			translatedContent, err := s.client.TranslateMessage(ctx, translatePrompt, content, lang)
 			if err != nil {
 				return "", err
 			}
@@ -67,7 +164,25 @@ func (s *CommitService) generateCommitMessage(diff string) (string, error) {
 	}
 
 	// Translate the entire message
-	return s.client.TranslateMessage(translatePrompt, msg, lang)
+	return s.client.TranslateMessage(ctx, translatePrompt, msg, lang)
+}
+
+// streamCommitMessage streams the commit message to the terminal as it is generated
+// and returns the complete message once the stream has finished.
+func (s *CommitService) streamCommitMessage(ctx context.Context, diff string, prompt string) (string, error) {
+	fmt.Println(formatRemindMessage("Generating, streaming results as they arrive..."))
+
+	var msg strings.Builder
+	err := s.client.GenerateCommitMessageStream(ctx, diff, prompt, func(chunk string) error {
+		fmt.Print(chunk)
+		msg.WriteString(chunk)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(msg.String()), nil
 }
 
 // splitCommitMessage splits a commit message into prefix and content parts based on the first colon separator.
@@ -127,7 +242,8 @@ func removeThinkTags(input string) (string, error) {
 // - No changes found after filtering
 // - Failed to generate commit message
 // - Failed to handle commit interaction
-func (s *CommitService) Execute() error {
+// - ctx was cancelled, e.g. by Ctrl-C, which stops the requests and prompts at once
+func (s *CommitService) Execute(ctx context.Context) error {
 	// Get verbose setting
 	verbose := s.getVerboseSetting()
 
@@ -142,22 +258,25 @@ func (s *CommitService) Execute() error {
 	if progress != nil {
 		progress.Start("Fetching git diff")
 	}
-	hasStagedChanges, err := s.vcs.HasStagedChanges(s.options.RepoPath)
-	if err != nil {
-		if progress != nil {
-			progress.Error("Fetching git diff", err)
+	// Amending needs no staged changes, the last commit has the changes
+	if !s.options.Amend {
+		hasStagedChanges, err := s.vcs.HasStagedChanges(s.options.RepoPath)
+		if err != nil {
+			if progress != nil {
+				progress.Error("Fetching git diff", err)
+			}
+			return err
 		}
-		return err
-	}
-	if !hasStagedChanges {
-		if progress != nil {
-			progress.Error("Fetching git diff", gptcometerrors.NoStagedChangesError())
+		if !hasStagedChanges {
+			if progress != nil {
+				progress.Error("Fetching git diff", gptcometerrors.NoStagedChangesError())
+			}
+			return gptcometerrors.NoStagedChangesError()
 		}
-		return gptcometerrors.NoStagedChangesError()
 	}
 
 	// get diff of staged changes after filtering with file_ignore patterns
-	diff, err := s.vcs.GetStagedDiffFiltered(s.options.RepoPath, s.cfgManager)
+	diff, err := s.getDiff()
 	debug.Printf("Got diff length: %d\n", len(diff))
 	if err != nil {
 		if progress != nil {
@@ -177,21 +296,21 @@ func (s *CommitService) Execute() error {
 	}
 
 	fmt.Printf("Discovered provider: %s, model: %s\n", s.clientConfig.Provider, s.clientConfig.Model)
+	if verbose {
+		// Report the tokens and estimated cost of all requests when done
+		defer printUsageSummary(s.client, s.cfgManager)
+	}
 
 	if progress != nil {
 		progress.StartWithNewLine("Generating message")
 	}
 
-	// generate commit message
-	commitMsg, err := s.generateCommitMessage(diff)
-	if err != nil {
-		if progress != nil {
-			progress.Error("Generating message", err)
-		}
-		return err
+	if s.options.Candidates > 1 {
+		return s.executeWithCandidates(ctx, diff, progress)
 	}
 
-	commitMsg, err = removeThinkTags(commitMsg)
+	// generate commit message
+	commitMsg, err := s.generateCommitMessage(ctx, diff)
 	if err != nil {
 		if progress != nil {
 			progress.Error("Generating message", err)
@@ -210,7 +329,7 @@ func (s *CommitService) Execute() error {
 		return nil
 	}
 
-	return s.handleCommitInteraction(commitMsg)
+	return s.handleCommitInteraction(ctx, commitMsg)
 }
 
 // getVerboseSetting retrieves the console.verbose configuration
@@ -223,22 +342,137 @@ func (s *CommitService) getVerboseSetting() bool {
 	return false
 }
 
+// executeWithCandidates generates several commit messages for the diff, lets the user
+// pick one of them and continues with the usual commit interaction.
+func (s *CommitService) executeWithCandidates(ctx context.Context, diff string, progress *ui.Progress) error {
+	candidates, err := s.generateCandidates(ctx, diff)
+	if err != nil {
+		if progress != nil {
+			progress.Error("Generating message", err)
+		} else {
+			fmt.Printf("Error in generating: %v\n", err)
+		}
+		return err
+	}
+
+	if progress != nil {
+		progress.CompleteInNewLine("Generating message")
+	}
+
+	if s.options.DryRun {
+		for i, candidate := range candidates {
+			fmt.Printf("\nCandidate %d:\n%s\n", i+1, formatBoxedMessage(candidate))
+		}
+		return nil
+	}
+
+	// Nobody to ask, take the first candidate
+	if s.options.AutoYes {
+		return s.handleCommitInteraction(ctx, candidates[0])
+	}
+
+	commitMsg, err := s.selectCandidate(ctx, diff, candidates)
+	if err != nil {
+		return err
+	}
+	if commitMsg == "" {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
+
+	return s.handleCommitInteraction(ctx, commitMsg)
+}
+
+// generateCandidates generates the configured number of alternative commit messages.
+// Every candidate goes through think tag removal and translation like a single message.
+func (s *CommitService) generateCandidates(ctx context.Context, diff string) ([]string, error) {
+	diff, err := s.summarizeDiff(ctx, diff)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := s.cfgManager.GetPrompt(s.options.Rich)
+	messages, err := s.client.GenerateCommitMessages(ctx, diff, prompt, s.options.Candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		msg, err = removeThinkTags(msg)
+		if err != nil {
+			return nil, err
+		}
+		msg, err = s.translateCommitMessage(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, msg)
+	}
+
+	return candidates, nil
+}
+
+// selectCandidate shows the candidates until the user picks one of them.
+// Editing or regenerating a candidate replaces it in the list and shows the list again.
+//
+// Returns:
+//   - string: The picked commit message, empty if the user cancelled
+//   - error: An error if the picker, the editor or the regeneration fails
+func (s *CommitService) selectCandidate(ctx context.Context, diff string, candidates []string) (string, error) {
+	for {
+		action, index, err := s.picker.Pick(candidates)
+		if err != nil {
+			return "", err
+		}
+
+		switch action {
+		case ui.CandidateActionSelect:
+			// Refining starts a new conversation from the picked message
+			s.conversation = nil
+			s.lastMessage = candidates[index]
+			return candidates[index], nil
+		case ui.CandidateActionEdit:
+			edited, err := s.editor.Edit(candidates[index])
+			if err != nil {
+				fmt.Printf("Error editing message: %v\n", err)
+				continue
+			}
+			candidates[index] = edited
+		case ui.CandidateActionRegenerate:
+			msg, err := s.generateCommitMessage(client.WithRefreshCache(ctx), diff)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			if err != nil {
+				fmt.Printf("Error in generating: %v\n", err)
+				continue
+			}
+			candidates[index] = msg
+		default:
+			return "", nil
+		}
+	}
+}
+
 // handleCommitInteraction manages the interactive commit message workflow.
 // It displays the current commit message and prompts the user for actions:
 // - Yes: Creates the commit with the current message
 // - No: Cancels the operation
 // - Retry: Regenerates the commit message based on staged changes
+// - Feedback: Refines the commit message with a note from the user, keeping the conversation
 // - Edit: Opens an editor to manually modify the commit message
 //
 // The function loops until the user either confirms the commit or cancels the operation.
 // If AutoYes option is enabled, it skips the interaction and creates the commit directly.
 //
 // Parameters:
+//   - ctx: The context of the command, cancelled on Ctrl-C
 //   - initialMsg: The initial commit message to start with
 //
 // Returns:
 //   - error: An error if any operation fails, nil otherwise
-func (s *CommitService) handleCommitInteraction(initialMsg string) error {
+func (s *CommitService) handleCommitInteraction(ctx context.Context, initialMsg string) error {
 	commitMsg := initialMsg
 	reader := bufio.NewReader(os.Stdin)
 
@@ -251,8 +485,8 @@ func (s *CommitService) handleCommitInteraction(initialMsg string) error {
 			return s.createCommit(commitMsg)
 		}
 
-		fmt.Print("\nWould you like to create this commit? ([Y]es/[n]o/[r]etry/[e]dit): ")
-		answer, err := reader.ReadString('\n')
+		fmt.Print("\nWould you like to create this commit? ([Y]es/[n]o/[r]etry/[f]eedback/[e]dit): ")
+		answer, err := readLine(ctx, reader)
 		if err != nil {
 			return fmt.Errorf("failed to read answer: %w", err)
 		}
@@ -269,19 +503,35 @@ func (s *CommitService) handleCommitInteraction(initialMsg string) error {
 			fmt.Println("Operation cancelled")
 			return nil
 		case "r", "retry":
-			diff, err := s.vcs.GetStagedDiffFiltered(s.options.RepoPath, s.cfgManager)
+			diff, err := s.getDiff()
 			if err != nil {
 				return err
 			}
-			commitMsg, err = s.generateCommitMessage(diff)
+			commitMsg, err = s.generateCommitMessage(client.WithRefreshCache(ctx), diff)
 			if err != nil {
+				fmt.Printf("Error in generating: %v\n", err)
 				return err
 			}
-			commitMsg, err = removeThinkTags(commitMsg)
+		case "f", "feedback":
+			fmt.Print("What should be changed? ")
+			feedback, err := readLine(ctx, reader)
 			if err != nil {
-				fmt.Printf("Error in generating: %v\n", err)
-				return err
+				return fmt.Errorf("failed to read feedback: %w", err)
+			}
+			feedback = strings.TrimSpace(feedback)
+			if feedback == "" {
+				fmt.Println("No feedback given, keeping the current message")
+				continue
+			}
+			refined, err := s.refineCommitMessage(ctx, commitMsg, feedback)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				fmt.Printf("Error in refining: %v\n", err)
+				continue
 			}
+			commitMsg = refined
 		case "e", "edit":
 			edited, err := s.editor.Edit(commitMsg)
 			if err != nil {
@@ -297,7 +547,7 @@ func (s *CommitService) handleCommitInteraction(initialMsg string) error {
 
 // createCommit creates a new git commit with the given message and prints commit information.
 // It performs the following steps:
-// 1. Creates a commit with the provided message
+// 1. Creates a commit with the provided message, or amends the last commit with --amend
 // 2. Retrieves the hash of the newly created commit
 // 3. Gets detailed commit information
 // 4. Prints the commit details to stdout
@@ -308,8 +558,11 @@ func (s *CommitService) handleCommitInteraction(initialMsg string) error {
 // Returns:
 //   - error: nil if successful, otherwise error details with context
 func (s *CommitService) createCommit(msg string) error {
-	err := s.vcs.CreateCommit(s.options.RepoPath, msg, s.options.NoVerify)
-	if err != nil {
+	if s.options.Amend {
+		if err := s.vcs.AmendCommit(s.options.RepoPath, msg, s.options.NoVerify); err != nil {
+			return fmt.Errorf("failed to amend commit: %w", err)
+		}
+	} else if err := s.vcs.CreateCommit(s.options.RepoPath, msg, s.options.NoVerify); err != nil {
 		return fmt.Errorf("failed to create commit: %w", err)
 	}
 
@@ -324,6 +577,28 @@ func (s *CommitService) createCommit(msg string) error {
 		return fmt.Errorf("failed to get commit info: %w", err)
 	}
 
-	fmt.Printf("\nSuccessfully created commit:\n%s\n", commitInfo)
+	action := "created"
+	if s.options.Amend {
+		action = "amended"
+	}
+	fmt.Printf("\nSuccessfully %s commit:\n%s\n", action, commitInfo)
 	return nil
 }
+
+// getDiff returns the diff to generate the commit message from, filtered with the
+// file_ignore patterns: the staged changes, or when amending, the changes of the
+// last commit together with the staged ones.
+func (s *CommitService) getDiff() (string, error) {
+	if !s.options.Amend {
+		return s.vcs.GetStagedDiffFiltered(s.options.RepoPath, s.cfgManager)
+	}
+
+	diff, err := s.vcs.GetCommitDiff(s.options.RepoPath, "HEAD", true, s.cfgManager)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", gptcometerrors.NoCommitChangesError("HEAD")
+	}
+	return diff, nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -213,7 +214,7 @@ func TestHandleCommitInteraction(t *testing.T) {
 	t.Run("Function signature", func(t *testing.T) {
 		// Verify the method exists on CommitService
 		var service interface {
-			handleCommitInteraction(string) error
+			handleCommitInteraction(context.Context, string) error
 		}
 		_ = service
 	})
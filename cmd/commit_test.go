@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/ui"
 	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -43,6 +44,16 @@ func (m *MockTextEditor) Edit(initialText string) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+// MockCandidatePicker implements CandidatePicker interface for testing
+type MockCandidatePicker struct {
+	mock.Mock
+}
+
+func (m *MockCandidatePicker) Pick(candidates []string) (ui.CandidateAction, int, error) {
+	args := m.Called(candidates)
+	return args.Get(0).(ui.CandidateAction), args.Int(1), args.Error(2)
+}
+
 // MockVCS implements VCS interface for testing
 type MockVCS struct {
 	mock.Mock
@@ -94,6 +105,16 @@ func (m *MockVCS) GetStagedDiffFiltered(repoPath string, cfgManager config.Manag
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockVCS) GetCommitDiff(repoPath, commit string, staged bool, cfgManager config.ManagerInterface) (string, error) {
+	args := m.Called(repoPath, commit, staged, cfgManager)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVCS) AmendCommit(repoPath, message string, noverify bool) error {
+	args := m.Called(repoPath, message, noverify)
+	return args.Error(0)
+}
+
 func (m *MockVCS) GetStagedFiles(repoPath string) ([]string, error) {
 	args := m.Called(repoPath)
 	return args.Get(0).([]string), args.Error(1)
@@ -102,6 +123,18 @@ func (m *MockVCS) GetStagedFiles(repoPath string) ([]string, error) {
 // MockClient mocks the client.Client interface
 type MockClient struct {
 	mock.Mock
+	// usage is returned by Usage
+	usage types.Usage
+	// usageByModel is returned by UsageByModel
+	usageByModel map[string]types.Usage
+}
+
+func (m *MockClient) Usage() types.Usage {
+	return m.usage
+}
+
+func (m *MockClient) UsageByModel() map[string]types.Usage {
+	return m.usageByModel
 }
 
 func (m *MockClient) Chat(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, error) {
@@ -112,23 +145,43 @@ func (m *MockClient) Chat(ctx context.Context, message string, history []types.M
 	return nil, args.Error(1)
 }
 
-func (m *MockClient) TranslateMessage(prompt string, message string, lang string) (string, error) {
-	args := m.Called(prompt, message, lang)
+func (m *MockClient) TranslateMessage(ctx context.Context, prompt string, message string, lang string) (string, error) {
+	args := m.Called(ctx, prompt, message, lang)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockClient) GenerateCommitMessage(diff string, prompt string) (string, error) {
-	args := m.Called(diff, prompt)
+func (m *MockClient) GenerateCommitMessage(ctx context.Context, diff string, prompt string) (string, error) {
+	args := m.Called(ctx, diff, prompt)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockClient) GenerateReviewComment(diff string, prompt string) (string, error) {
-	args := m.Called(diff, prompt)
+func (m *MockClient) GenerateCommitMessageStream(ctx context.Context, diff string, prompt string, callback func(string) error) error {
+	args := m.Called(ctx, diff, prompt, callback)
+	if chunks, ok := args.Get(0).([]string); ok {
+		for _, chunk := range chunks {
+			if err := callback(chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockClient) GenerateCommitMessages(ctx context.Context, diff string, prompt string, n int) ([]string, error) {
+	args := m.Called(ctx, diff, prompt, n)
+	if messages, ok := args.Get(0).([]string); ok {
+		return messages, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockClient) GenerateReviewComment(ctx context.Context, diff string, prompt string) (string, error) {
+	args := m.Called(ctx, diff, prompt)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockClient) GenerateReviewCommentStream(diff string, prompt string, callback func(string) error) error {
-	args := m.Called(diff, prompt, callback)
+func (m *MockClient) GenerateReviewCommentStream(ctx context.Context, diff string, prompt string, callback func(string) error) error {
+	args := m.Called(ctx, diff, prompt, callback)
 	return args.Error(0)
 }
 
@@ -226,7 +279,7 @@ func TestCommitService_Execute(t *testing.T) {
 				vcs.On("CreateCommit", mock.Anything, commitMsg, false).Return(nil)
 				vcs.On("GetLastCommitHash", mock.Anything).Return("abc1234", nil)
 				vcs.On("GetCommitInfo", mock.Anything, "abc1234").Return("commit abc1234\nAuthor: Test User\nDate: Thu Jan 1 00:00:00 1970 +0000\n\nfeat: test commit no skip", nil)
-				client.On("GenerateCommitMessage", diff, mock.Anything).Return(commitMsg, nil)
+				client.On("GenerateCommitMessage", mock.Anything, diff, mock.Anything).Return(commitMsg, nil)
 
 				return diff, commitMsg
 			},
@@ -247,7 +300,7 @@ func TestCommitService_Execute(t *testing.T) {
 				vcs.On("CreateCommit", mock.Anything, commitMsg, true).Return(nil)
 				vcs.On("GetLastCommitHash", mock.Anything).Return("def4567", nil)
 				vcs.On("GetCommitInfo", mock.Anything, "def4567").Return("commit def4567\nAuthor: Test User\nDate: Fri Jan 2 00:00:00 1970 +0000\n\nfeat: test commit skip hook", nil)
-				client.On("GenerateCommitMessage", diff, mock.Anything).Return(commitMsg, nil)
+				client.On("GenerateCommitMessage", mock.Anything, diff, mock.Anything).Return(commitMsg, nil)
 
 				return diff, commitMsg
 			},
@@ -268,13 +321,43 @@ func TestCommitService_Execute(t *testing.T) {
 				vcs.On("CreateCommit", mock.Anything, commitMsg, false).Return(nil)
 				vcs.On("GetLastCommitHash", mock.Anything).Return("ghi7890", nil)
 				vcs.On("GetCommitInfo", mock.Anything, "ghi7890").Return("commit ghi7890\nAuthor: Test User\nDate: Sat Jan 3 00:00:00 1970 +0000\n\nfeat: interactive commit no skip", nil)
-				client.On("GenerateCommitMessage", diff, mock.Anything).Return(commitMsg, nil)
+				client.On("GenerateCommitMessage", mock.Anything, diff, mock.Anything).Return(commitMsg, nil)
 
 				return diff, commitMsg
 			},
 			wantErr: false,
 		},
 		*/
+		{
+			name: "amend_auto_yes",
+			options: CommitOptions{
+				AutoYes: true,
+				Amend:   true,
+			},
+			setupMocks: func(vcs *MockVCS, editor *MockTextEditor, client *MockClient) (string, string) {
+				diff := "test diff of last commit and staged changes"
+				commitMsg := "feat: amended commit"
+
+				vcs.On("GetCommitDiff", mock.Anything, "HEAD", true, mock.Anything).Return(diff, nil)
+				vcs.On("AmendCommit", mock.Anything, commitMsg, false).Return(nil)
+				vcs.On("GetLastCommitHash", mock.Anything).Return("abc1234", nil)
+				vcs.On("GetCommitInfo", mock.Anything, "abc1234").Return("commit abc1234\n\nfeat: amended commit", nil)
+				client.On("GenerateCommitMessage", mock.Anything, diff, mock.Anything).Return(commitMsg, nil)
+
+				return diff, commitMsg
+			},
+			wantErr: false,
+		},
+		{
+			name:    "amend_no_changes",
+			options: CommitOptions{Amend: true},
+			setupMocks: func(vcs *MockVCS, editor *MockTextEditor, client *MockClient) (string, string) {
+				vcs.On("GetCommitDiff", mock.Anything, "HEAD", true, mock.Anything).Return("", nil)
+				return "", ""
+			},
+			wantErr:     true,
+			errContains: "no changes",
+		},
 		{
 			name: "error_getting_diff",
 			setupMocks: func(vcs *MockVCS, editor *MockTextEditor, client *MockClient) (string, string) {
@@ -314,7 +397,7 @@ func TestCommitService_Execute(t *testing.T) {
 				clientConfig: &types.ClientConfig{Provider: "test-provider", Model: "test-model"},
 			}
 
-			err = service.Execute()
+			err = service.Execute(context.Background())
 
 			mockVCS.AssertExpectations(t)
 			mockEditor.AssertExpectations(t)
@@ -376,7 +459,7 @@ func TestCommitService_generateCommitMessage(t *testing.T) {
 			}
 
 			mockClient := new(MockClient)
-			mockClient.On("GenerateCommitMessage", tc.diff, mock.Anything).Return(tc.wantMessage, nil)
+			mockClient.On("GenerateCommitMessage", mock.Anything, tc.diff, mock.Anything).Return(tc.wantMessage, nil)
 
 			service := &CommitService{
 				vcs:        &MockVCS{},
@@ -385,7 +468,7 @@ func TestCommitService_generateCommitMessage(t *testing.T) {
 				options:    CommitOptions{},
 			}
 
-			message, err := service.generateCommitMessage(tc.diff)
+			message, err := service.generateCommitMessage(context.Background(), tc.diff)
 
 			mockClient.AssertExpectations(t)
 
@@ -401,3 +484,206 @@ func TestCommitService_generateCommitMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestCommitService_generateCommitMessageStream(t *testing.T) {
+	configPath, cleanupConfig := setupTempConfig(t)
+	defer cleanupConfig()
+
+	cfg, err := config.New(configPath)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Set("prompt.brief_commit_message", "test prompt"))
+
+	mockClient := new(MockClient)
+	mockClient.On("GenerateCommitMessageStream", mock.Anything, "test diff", mock.Anything, mock.Anything).
+		Return([]string{"<thinking>pondering</thinking>", "feat: ", "stream commit", "\n"}, nil)
+
+	service := &CommitService{
+		vcs:        &MockVCS{},
+		client:     mockClient,
+		cfgManager: cfg,
+		options:    CommitOptions{Stream: true},
+	}
+
+	message, err := service.generateCommitMessage(context.Background(), "test diff")
+
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "GenerateCommitMessage", mock.Anything, mock.Anything, mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, "feat: stream commit", message)
+}
+
+func TestCommitService_refineCommitMessage(t *testing.T) {
+	configPath, cleanupConfig := setupTempConfig(t)
+	defer cleanupConfig()
+
+	cfg, err := config.New(configPath)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Set("prompt.brief_commit_message", "prompt: {{ placeholder }}"))
+	assert.NoError(t, cfg.Set("prompt.feedback", "feedback: {{ placeholder }}"))
+
+	mockClient := new(MockClient)
+	mockClient.On("GenerateCommitMessage", mock.Anything, "test diff", mock.Anything).Return("feat: first", nil)
+	mockClient.On("Chat", mock.Anything, "feedback: mention the migration", mock.MatchedBy(func(history []types.Message) bool {
+		return len(history) == 2 &&
+			history[0] == types.Message{Role: types.RoleUser, Content: "prompt: test diff"} &&
+			history[1] == types.Message{Role: types.RoleAssistant, Content: "feat: first"}
+	})).Return(&types.CompletionResponse{Content: "feat: add migration\n"}, nil).Once()
+	mockClient.On("Chat", mock.Anything, "feedback: drop the test bullet", mock.MatchedBy(func(history []types.Message) bool {
+		return len(history) == 4 &&
+			history[2] == types.Message{Role: types.RoleUser, Content: "feedback: mention the migration"} &&
+			history[3] == types.Message{Role: types.RoleAssistant, Content: "feat: add user migration"}
+	})).Return(&types.CompletionResponse{Content: "feat: add user table migration"}, nil).Once()
+
+	service := &CommitService{
+		vcs:        &MockVCS{},
+		client:     mockClient,
+		cfgManager: cfg,
+		options:    CommitOptions{},
+	}
+
+	message, err := service.generateCommitMessage(context.Background(), "test diff")
+	assert.NoError(t, err)
+	assert.Equal(t, "feat: first", message)
+
+	message, err = service.refineCommitMessage(context.Background(), message, "mention the migration")
+	assert.NoError(t, err)
+	assert.Equal(t, "feat: add migration", message)
+
+	// An edited message replaces the previous answer in the conversation
+	message, err = service.refineCommitMessage(context.Background(), "feat: add user migration", "drop the test bullet")
+	assert.NoError(t, err)
+	assert.Equal(t, "feat: add user table migration", message)
+	assert.Len(t, service.conversation, 6)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestCommitService_refineCommitMessageWithoutConversation(t *testing.T) {
+	configPath, cleanupConfig := setupTempConfig(t)
+	defer cleanupConfig()
+
+	cfg, err := config.New(configPath)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Set("prompt.brief_commit_message", "prompt: {{ placeholder }}"))
+
+	mockVCS := &MockVCS{}
+	mockVCS.On("GetStagedDiffFiltered", "", cfg).Return("staged diff", nil)
+
+	mockClient := new(MockClient)
+	mockClient.On("Chat", mock.Anything, mock.Anything, mock.MatchedBy(func(history []types.Message) bool {
+		return len(history) == 2 &&
+			history[0].Content == "prompt: staged diff" &&
+			history[1].Content == "feat: existing"
+	})).Return(&types.CompletionResponse{Content: "feat: refined"}, nil)
+
+	service := &CommitService{
+		vcs:        mockVCS,
+		client:     mockClient,
+		cfgManager: cfg,
+		options:    CommitOptions{},
+	}
+
+	message, err := service.refineCommitMessage(context.Background(), "feat: existing", "be more specific")
+	assert.NoError(t, err)
+	assert.Equal(t, "feat: refined", message)
+
+	mockVCS.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestCommitService_generateCandidates(t *testing.T) {
+	configPath, cleanupConfig := setupTempConfig(t)
+	defer cleanupConfig()
+
+	cfg, err := config.New(configPath)
+	assert.NoError(t, err)
+
+	mockClient := new(MockClient)
+	mockClient.On("GenerateCommitMessages", mock.Anything, "test diff", mock.Anything, 3).
+		Return([]string{"<thinking>hmm</thinking>feat: first", "feat: second", "feat: third"}, nil)
+
+	service := &CommitService{
+		vcs:        &MockVCS{},
+		client:     mockClient,
+		cfgManager: cfg,
+		options:    CommitOptions{Candidates: 3},
+	}
+
+	candidates, err := service.generateCandidates(context.Background(), "test diff")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"feat: first", "feat: second", "feat: third"}, candidates)
+	mockClient.AssertExpectations(t)
+}
+
+func TestCommitService_selectCandidate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		setupMocks func(*MockCandidatePicker, *MockTextEditor, *MockClient)
+		want       string
+	}{
+		{
+			name: "pick",
+			setupMocks: func(picker *MockCandidatePicker, editor *MockTextEditor, client *MockClient) {
+				picker.On("Pick", mock.Anything).Return(ui.CandidateActionSelect, 1, nil).Once()
+			},
+			want: "feat: second",
+		},
+		{
+			name: "edit then pick",
+			setupMocks: func(picker *MockCandidatePicker, editor *MockTextEditor, client *MockClient) {
+				picker.On("Pick", mock.Anything).Return(ui.CandidateActionEdit, 0, nil).Once()
+				editor.On("Edit", "feat: first").Return("feat: edited", nil).Once()
+				picker.On("Pick", []string{"feat: edited", "feat: second"}).Return(ui.CandidateActionSelect, 0, nil).Once()
+			},
+			want: "feat: edited",
+		},
+		{
+			name: "regenerate then pick",
+			setupMocks: func(picker *MockCandidatePicker, editor *MockTextEditor, client *MockClient) {
+				picker.On("Pick", mock.Anything).Return(ui.CandidateActionRegenerate, 1, nil).Once()
+				client.On("GenerateCommitMessage", mock.Anything, "test diff", mock.Anything).Return("feat: regenerated", nil).Once()
+				picker.On("Pick", []string{"feat: first", "feat: regenerated"}).Return(ui.CandidateActionSelect, 1, nil).Once()
+			},
+			want: "feat: regenerated",
+		},
+		{
+			name: "cancel",
+			setupMocks: func(picker *MockCandidatePicker, editor *MockTextEditor, client *MockClient) {
+				picker.On("Pick", mock.Anything).Return(ui.CandidateActionNone, 0, nil).Once()
+			},
+			want: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath, cleanupConfig := setupTempConfig(t)
+			defer cleanupConfig()
+
+			cfg, err := config.New(configPath)
+			assert.NoError(t, err)
+
+			picker := new(MockCandidatePicker)
+			editor := new(MockTextEditor)
+			mockClient := new(MockClient)
+			tc.setupMocks(picker, editor, mockClient)
+
+			service := &CommitService{
+				vcs:        &MockVCS{},
+				client:     mockClient,
+				cfgManager: cfg,
+				editor:     editor,
+				picker:     picker,
+				options:    CommitOptions{Candidates: 2},
+			}
+
+			got, err := service.selectCandidate(context.Background(), "test diff", []string{"feat: first", "feat: second"})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			picker.AssertExpectations(t)
+			editor.AssertExpectations(t)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
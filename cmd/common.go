@@ -5,6 +5,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/belingud/gptcomet/internal/ui"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -172,3 +173,45 @@ type TerminalEditor struct{}
 func (e *TerminalEditor) Edit(initialText string) (string, error) {
 	return editText(initialText)
 }
+
+// CandidatePicker represents an interface for choosing one of several commit messages
+type CandidatePicker interface {
+	Pick(candidates []string) (ui.CandidateAction, int, error)
+}
+
+// TerminalCandidatePicker implements CandidatePicker with a terminal list
+type TerminalCandidatePicker struct{}
+
+func (p *TerminalCandidatePicker) Pick(candidates []string) (ui.CandidateAction, int, error) {
+	selector := ui.NewCandidateSelector(candidates)
+	m, err := tea.NewProgram(selector).Run()
+	if err != nil {
+		return ui.CandidateActionNone, 0, fmt.Errorf("failed to run candidate selector: %w", err)
+	}
+
+	selector = m.(*ui.CandidateSelector)
+	return selector.Action(), selector.Index(), nil
+}
+
+// RewordPicker represents an interface for reviewing the messages generated by reword
+type RewordPicker interface {
+	// Pick shows entries with the one at cursor highlighted, and stores the
+	// selection of the user in entries. It returns the action and the index
+	// of the highlighted entry.
+	Pick(entries []ui.RewordEntry, cursor int) (ui.RewordAction, int, error)
+}
+
+// TerminalRewordPicker implements RewordPicker with a terminal list
+type TerminalRewordPicker struct{}
+
+func (p *TerminalRewordPicker) Pick(entries []ui.RewordEntry, cursor int) (ui.RewordAction, int, error) {
+	selector := ui.NewRewordSelector(entries, cursor)
+	m, err := tea.NewProgram(selector).Run()
+	if err != nil {
+		return ui.RewordActionNone, 0, fmt.Errorf("failed to run reword selector: %w", err)
+	}
+
+	selector = m.(*ui.RewordSelector)
+	copy(entries, selector.Entries())
+	return selector.Action(), selector.Cursor(), nil
+}
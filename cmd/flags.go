@@ -24,11 +24,24 @@ type CommonOptions struct {
 	Temperature      float64
 	TopP             float64
 	Provider         string
+	// Timeouts in seconds
+	Timeout           int
+	ConnectTimeout    int
+	StreamIdleTimeout int
+
+	// flags are the parsed flags of the options, to tell an explicit 0 from an unset flag
+	flags *pflag.FlagSet
+}
+
+// changed reports whether the flag name of the options was given on the command line
+func (opts *CommonOptions) changed(name string) bool {
+	return opts.flags != nil && opts.flags.Changed(name)
 }
 
 // AddAdvancedAPIFlags adds all API override flags to a command
 // These flags allow users to override configuration file settings
 func AddAdvancedAPIFlags(flags *pflag.FlagSet, opts *CommonOptions) {
+	opts.flags = flags
 	flags.StringVar(&opts.APIBase, "api-base", "", "Override API base URL")
 	flags.StringVar(&opts.APIKey, "api-key", "", "Override API key")
 	flags.IntVar(&opts.MaxTokens, "max-tokens", 0, "Override maximum tokens")
@@ -41,6 +54,9 @@ func AddAdvancedAPIFlags(flags *pflag.FlagSet, opts *CommonOptions) {
 	flags.Float64Var(&opts.Temperature, "temperature", 0, "Override temperature")
 	flags.Float64Var(&opts.TopP, "top-p", 0, "Override top_p value")
 	flags.StringVar(&opts.Provider, "provider", "", "Override AI provider (openai/deepseek)")
+	flags.IntVar(&opts.Timeout, "timeout", 0, "Override request timeout in seconds")
+	flags.IntVar(&opts.ConnectTimeout, "connect-timeout", 0, "Override connect timeout in seconds")
+	flags.IntVar(&opts.StreamIdleTimeout, "stream-idle-timeout", 0, "Override seconds a stream may send no data before it is aborted")
 }
 
 // AddGeneralFlags adds general operational flags to a command
@@ -57,7 +73,8 @@ func AddConfigFlag(flags *pflag.FlagSet, configPath *string) {
 }
 
 // ApplyCommonOptions applies the common API options to a client config
-// This function applies non-zero values from opts to clientConfig
+// This function applies non-zero values from opts to clientConfig, and the
+// timeouts also when 0 is given explicitly, which means no limit
 func ApplyCommonOptions(opts *CommonOptions, clientConfig *types.ClientConfig) {
 	if opts.APIBase != "" {
 		clientConfig.APIBase = opts.APIBase
@@ -92,6 +109,15 @@ func ApplyCommonOptions(opts *CommonOptions, clientConfig *types.ClientConfig) {
 	if opts.TopP != 0 {
 		clientConfig.TopP = opts.TopP
 	}
+	if opts.Timeout > 0 || opts.changed("timeout") {
+		clientConfig.Timeout = int64(opts.Timeout)
+	}
+	if opts.ConnectTimeout > 0 || opts.changed("connect-timeout") {
+		clientConfig.ConnectTimeout = int64(opts.ConnectTimeout)
+	}
+	if opts.StreamIdleTimeout > 0 || opts.changed("stream-idle-timeout") {
+		clientConfig.StreamIdleTimeout = int64(opts.StreamIdleTimeout)
+	}
 }
 
 // SetAdvancedHelpFunc sets a custom help function that organizes flags into groups
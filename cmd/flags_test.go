@@ -35,6 +35,14 @@ func TestAddAdvancedAPIFlags(t *testing.T) {
 			wantSet:     true,
 			description: "Should override existing model value",
 		},
+		{
+			name:        "AddTimeoutFlag",
+			setupOpts:   &CommonOptions{},
+			flagName:    "stream-idle-timeout",
+			flagValue:   "45",
+			wantSet:     true,
+			description: "Should set StreamIdleTimeout flag successfully",
+		},
 	}
 
 	for _, tt := range tests {
@@ -114,17 +122,20 @@ func TestApplyCommonOptions(t *testing.T) {
 		{
 			name: "ApplyAllOptions",
 			opts: CommonOptions{
-				APIBase:          "https://custom.api.com",
-				APIKey:           "custom-key",
-				MaxTokens:        2048,
-				Retries:          5,
-				Model:            "custom-model",
-				AnswerPath:       "custom.answer",
-				CompletionPath:   "/custom/completion",
-				Proxy:            "http://proxy.com",
-				FrequencyPenalty: 0.5,
-				Temperature:      0.8,
-				TopP:             0.95,
+				APIBase:           "https://custom.api.com",
+				APIKey:            "custom-key",
+				MaxTokens:         2048,
+				Retries:           5,
+				Model:             "custom-model",
+				AnswerPath:        "custom.answer",
+				CompletionPath:    "/custom/completion",
+				Proxy:             "http://proxy.com",
+				FrequencyPenalty:  0.5,
+				Temperature:       0.8,
+				TopP:              0.95,
+				Timeout:           120,
+				ConnectTimeout:    5,
+				StreamIdleTimeout: 45,
 			},
 			initialCfg: types.ClientConfig{
 				APIBase:          "https://default.api.com",
@@ -152,6 +163,9 @@ func TestApplyCommonOptions(t *testing.T) {
 				assert.Equal(t, 0.5, cfg.FrequencyPenalty, "FrequencyPenalty should be updated")
 				assert.Equal(t, 0.8, cfg.Temperature, "Temperature should be updated")
 				assert.Equal(t, 0.95, cfg.TopP, "TopP should be updated")
+				assert.Equal(t, int64(120), cfg.Timeout, "Timeout should be updated")
+				assert.Equal(t, int64(5), cfg.ConnectTimeout, "ConnectTimeout should be updated")
+				assert.Equal(t, int64(45), cfg.StreamIdleTimeout, "StreamIdleTimeout should be updated")
 			},
 		},
 		{
@@ -232,6 +246,32 @@ func TestApplyCommonOptions(t *testing.T) {
 	}
 }
 
+func TestApplyCommonOptions_ExplicitZeroTimeouts(t *testing.T) {
+	initial := types.ClientConfig{Timeout: 60, ConnectTimeout: 10, StreamIdleTimeout: 30}
+
+	t.Run("unset flags keep the config", func(t *testing.T) {
+		var opts CommonOptions
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		AddAdvancedAPIFlags(flags, &opts)
+		require.NoError(t, flags.Parse(nil))
+
+		cfg := initial
+		ApplyCommonOptions(&opts, &cfg)
+		assert.Equal(t, initial, cfg)
+	})
+
+	t.Run("explicit 0 means no limit", func(t *testing.T) {
+		var opts CommonOptions
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		AddAdvancedAPIFlags(flags, &opts)
+		require.NoError(t, flags.Parse([]string{"--timeout", "0", "--connect-timeout", "0", "--stream-idle-timeout", "0"}))
+
+		cfg := initial
+		ApplyCommonOptions(&opts, &cfg)
+		assert.Equal(t, types.ClientConfig{}, cfg)
+	})
+}
+
 func TestSetAdvancedHelpFunc(t *testing.T) {
 	cmd := &cobra.Command{
 		Use:   "test",
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gptcometerrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// NewHookCmd creates and returns a new cobra.Command for the 'hook' subcommand,
+// which runs gptcomet as the prepare-commit-msg hook of git, so plain
+// 'git commit' opens the editor with a generated message.
+//
+// Subcommands:
+//   - install: Install the hook in the current repository
+//   - uninstall: Remove the hook from the current repository
+//   - run: Generate the message, called by the hook
+func NewHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Generate commit messages in a git prepare-commit-msg hook",
+	}
+
+	cmd.AddCommand(newHookInstallCmd())
+	cmd.AddCommand(newHookUninstallCmd())
+	cmd.AddCommand(newHookRunCmd())
+
+	return cmd
+}
+
+// newHookInstallCmd creates and returns a new cobra.Command that installs the
+// prepare-commit-msg hook. An existing hook is kept and runs first.
+func newHookInstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "install",
+		Short: "Install the prepare-commit-msg hook in the current repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Root().PersistentFlags().GetString("config")
+			if err != nil {
+				return fmt.Errorf("failed to get config path: %w", err)
+			}
+			hooksDir, err := getHooksDir()
+			if err != nil {
+				return err
+			}
+			executable, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to find the gmsg executable: %w", err)
+			}
+
+			return runHookInstall(os.Stdout, hooksDir, hookCommand(executable, configPath))
+		},
+	}
+}
+
+// newHookUninstallCmd creates and returns a new cobra.Command that removes the
+// prepare-commit-msg hook and restores the hook it chained.
+func newHookUninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the prepare-commit-msg hook from the current repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hooksDir, err := getHooksDir()
+			if err != nil {
+				return err
+			}
+			return runHookUninstall(os.Stdout, hooksDir)
+		},
+	}
+}
+
+// newHookRunCmd creates and returns a new cobra.Command that is called by the
+// hook with its arguments: the commit message file, and the source of the
+// message and the commit, if any. It never fails, so that a provider failure
+// doesn't block the commit.
+func newHookRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "run <message-file> [source] [commit]",
+		Short:  "Write a generated message to the commit message file (called by the hook)",
+		Args:   cobra.RangeArgs(1, 3),
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Root().PersistentFlags().GetString("config")
+			if err != nil {
+				return fmt.Errorf("failed to get config path: %w", err)
+			}
+			source := ""
+			if len(args) > 1 {
+				source = args[1]
+			}
+
+			generate := func(ctx context.Context) (string, error) {
+				return generateHookMessage(ctx, configPath)
+			}
+			commentChar := "#"
+			if repoPath, err := os.Getwd(); err == nil {
+				commentChar = (&git.GitVCS{}).GetCommentChar(repoPath)
+			}
+			runHook(cmd.Context(), os.Stderr, args[0], source, commentChar, generate)
+			return nil
+		},
+	}
+}
+
+// getHooksDir returns the hooks directory of the repository in the working directory
+func getHooksDir() (string, error) {
+	repoPath, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return (&git.GitVCS{}).GetHooksDir(repoPath)
+}
+
+// hookCommand returns the shell command the hook runs, which calls executable
+// with the config file in use when the hook was installed
+func hookCommand(executable, configPath string) string {
+	command := shellQuote(executable)
+	if configPath != "" {
+		if abs, err := filepath.Abs(configPath); err == nil {
+			configPath = abs
+		}
+		command += " --config " + shellQuote(configPath)
+	}
+	return command + " hook run"
+}
+
+// shellQuote quotes s for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runHookInstall installs the hook that runs command in hooksDir and reports it to w
+func runHookInstall(w io.Writer, hooksDir, command string) error {
+	chained, err := git.InstallHook(hooksDir, git.PrepareCommitMsgHook, command)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Installed the %s hook in %s\n", git.PrepareCommitMsgHook, hooksDir)
+	if chained != "" {
+		fmt.Fprintf(w, "The existing hook was moved to %s and runs first\n", chained)
+	}
+	return nil
+}
+
+// runHookUninstall removes the hook from hooksDir and reports it to w
+func runHookUninstall(w io.Writer, hooksDir string) error {
+	restored, err := git.UninstallHook(hooksDir, git.PrepareCommitMsgHook)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Removed the %s hook from %s\n", git.PrepareCommitMsgHook, hooksDir)
+	if restored != "" {
+		fmt.Fprintf(w, "Restored the previous hook %s\n", restored)
+	}
+	return nil
+}
+
+// runHook writes the message returned by generate to the top of the commit
+// message file msgFile, unless the commit already has a message. Failures are
+// reported to w as warnings, the commit then goes on with the message the
+// user writes. Lines of the file starting with commentChar are comments.
+func runHook(ctx context.Context, w io.Writer, msgFile, source, commentChar string, generate func(context.Context) (string, error)) {
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		fmt.Fprintf(w, "gptcomet: failed to read the commit message file: %v\n", err)
+		return
+	}
+	if skipHook(source, string(content), commentChar) {
+		return
+	}
+
+	fmt.Fprintln(w, "gptcomet: generating the commit message...")
+	msg, err := generate(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "gptcomet: failed to generate the commit message: %v\n", err)
+		return
+	}
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return
+	}
+
+	if err := os.WriteFile(msgFile, []byte(msg+"\n"+string(content)), 0o644); err != nil {
+		fmt.Fprintf(w, "gptcomet: failed to write the commit message file: %v\n", err)
+	}
+}
+
+// scissorsLine marks the start of the diff git appends to the message file of
+// commit --verbose, after the comment character and a space
+const scissorsLine = "------------------------ >8 ------------------------"
+
+// skipHook reports whether no message should be generated for a commit with
+// the message source and the current content of the message file. Messages
+// given with -m or -F, merges, squashes and amends (source "commit") are kept,
+// as is any message a template or another hook put in the file. Lines starting
+// with commentChar and the diff below the scissors line aren't the message.
+func skipHook(source, content, commentChar string) bool {
+	if source != "" && source != "template" {
+		return true
+	}
+	lines := strings.Split(content, "\n")
+	if commentChar == "auto" {
+		commentChar = autoCommentChar(lines)
+	}
+	for _, line := range lines {
+		if line == commentChar+" "+scissorsLine {
+			break
+		}
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, commentChar) {
+			return true
+		}
+	}
+	return false
+}
+
+// autoCommentChar guesses the comment character git picked for
+// core.commentChar=auto from the last non-empty line of the message file,
+// which is a comment of the commit status
+func autoCommentChar(lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line[:1]
+		}
+	}
+	return "#"
+}
+
+// generateHookMessage generates a commit message for the staged changes of the
+// repository in the working directory, without the interactive loop of commit
+func generateHookMessage(ctx context.Context, configPath string) (string, error) {
+	repoPath, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	service, err := NewCommitService(CommitOptions{RepoPath: repoPath, ConfigPath: configPath})
+	if err != nil {
+		return "", err
+	}
+	setLedgerCommand(service.client, "hook")
+
+	// Ignored files are left out, the message stays empty if nothing else is staged
+	diff, err := service.vcs.GetStagedDiffFiltered(repoPath, service.cfgManager)
+	if gptcometerrors.HasTitle(err, gptcometerrors.ErrTitleNoStagedChanges) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", nil
+	}
+	return service.generateCommitMessage(ctx, diff)
+}
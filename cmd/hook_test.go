@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookCommand(t *testing.T) {
+	assert.Equal(t, "'/usr/local/bin/gmsg' hook run", hookCommand("/usr/local/bin/gmsg", ""))
+	assert.Equal(t, `'/opt/it'\''s/gmsg' --config '/etc/gmsg.yaml' hook run`, hookCommand("/opt/it's/gmsg", "/etc/gmsg.yaml"))
+}
+
+func TestSkipHook(t *testing.T) {
+	verbose := "\n# Please enter the commit message\n" +
+		"# ------------------------ >8 ------------------------\n" +
+		"# Do not modify or remove the line above.\n" +
+		"diff --git a/main.go b/main.go\n" +
+		"+package main\n"
+
+	tests := []struct {
+		name        string
+		source      string
+		content     string
+		commentChar string
+		want        bool
+	}{
+		{name: "plain commit", source: "", content: "\n# Please enter the commit message\n", want: false},
+		{name: "comment only template", source: "template", content: "# Describe the change\n", want: false},
+		{name: "message given", source: "message", content: "fix: typo\n", want: true},
+		{name: "merge", source: "merge", content: "Merge branch 'dev'\n", want: true},
+		{name: "squash", source: "squash", content: "Squashed commit\n", want: true},
+		{name: "amend", source: "commit", content: "feat: old\n", want: true},
+		{name: "template with text", source: "template", content: "JIRA-: \n", want: true},
+		{name: "verbose diff", source: "", content: verbose, want: false},
+		{name: "message above verbose diff", source: "", content: "fix: typo\n" + verbose, want: true},
+		{name: "comment char", source: "", content: "\n; Please enter the commit message\n", commentChar: ";", want: false},
+		{name: "hash with comment char", source: "", content: "#123 fix\n; Please enter the commit message\n", commentChar: ";", want: true},
+		{name: "auto comment char", source: "", content: "\n; Please enter the commit message\n", commentChar: "auto", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commentChar := tt.commentChar
+			if commentChar == "" {
+				commentChar = "#"
+			}
+			assert.Equal(t, tt.want, skipHook(tt.source, tt.content, commentChar))
+		})
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	comments := "\n# Please enter the commit message for your changes.\n"
+	writeMsgFile := func(t *testing.T) string {
+		path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+		require.NoError(t, os.WriteFile(path, []byte(comments), 0o644))
+		return path
+	}
+
+	t.Run("writes the generated message", func(t *testing.T) {
+		path := writeMsgFile(t)
+		var out bytes.Buffer
+		runHook(context.Background(), &out, path, "", "#", func(ctx context.Context) (string, error) {
+			return "feat: add hook\n", nil
+		})
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "feat: add hook\n"+comments, string(data))
+	})
+
+	t.Run("provider failure keeps the file", func(t *testing.T) {
+		path := writeMsgFile(t)
+		var out bytes.Buffer
+		runHook(context.Background(), &out, path, "", "#", func(ctx context.Context) (string, error) {
+			return "", errors.New("provider unavailable")
+		})
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, comments, string(data))
+		assert.Contains(t, out.String(), "provider unavailable")
+	})
+
+	t.Run("skipped source doesn't generate", func(t *testing.T) {
+		path := writeMsgFile(t)
+		runHook(context.Background(), &bytes.Buffer{}, path, "merge", "#", func(ctx context.Context) (string, error) {
+			t.Error("generate called for a merge commit")
+			return "", nil
+		})
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var out bytes.Buffer
+		runHook(context.Background(), &out, filepath.Join(t.TempDir(), "missing"), "", "#", func(ctx context.Context) (string, error) {
+			t.Error("generate called without a message file")
+			return "", nil
+		})
+		assert.Contains(t, out.String(), "failed to read")
+	})
+}
+
+func TestRunHookInstallAndUninstall(t *testing.T) {
+	hooksDir := t.TempDir()
+
+	var out bytes.Buffer
+	require.NoError(t, runHookInstall(&out, hooksDir, "gmsg hook run"))
+	assert.Contains(t, out.String(), "Installed the prepare-commit-msg hook")
+
+	out.Reset()
+	require.NoError(t, runHookUninstall(&out, hooksDir))
+	assert.Contains(t, out.String(), "Removed the prepare-commit-msg hook")
+	assert.NoFileExists(t, filepath.Join(hooksDir, "prepare-commit-msg"))
+}
+
+func TestGenerateHookMessage_OnlyIgnoredFilesStaged(t *testing.T) {
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	defer cleanup()
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "go.sum"), []byte("example.com/mod v1.0.0 h1:abc=\n"), 0644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "go.sum"))
+
+	// The api base is unreachable, the test fails if the provider is called
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+provider: openai
+openai:
+  api_key: test-key
+  api_base: http://127.0.0.1:1
+  retries: 1
+file_ignore:
+  - go.sum
+`), 0600))
+
+	t.Chdir(repoPath)
+	message, err := generateHookMessage(context.Background(), configPath)
+	require.NoError(t, err)
+	assert.Empty(t, message)
+}
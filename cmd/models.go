@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/belingud/gptcomet/internal/client"
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// listModelsTimeout limits how long newprovider waits for the models of a provider
+const listModelsTimeout = 15 * time.Second
+
+// ModelsOptions contains the settings of the models command
+type ModelsOptions struct {
+	CommonOptions
+	JSON bool
+}
+
+// NewModelsCmd creates and returns a new cobra.Command for the 'models' subcommand.
+// It lists the models available from the model-listing endpoint of the configured
+// provider, marking the configured model.
+//
+// The command supports the following flags:
+//   - --provider: List the models of another configured provider (string)
+//   - --api-base, --api-key, --proxy, --timeout: Override the provider config
+//   - --json: Output the models as a JSON list (bool)
+func NewModelsCmd() *cobra.Command {
+	options := ModelsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "models",
+		Short: "List the models available from the provider",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Root().PersistentFlags().GetString("config")
+			if err != nil {
+				return fmt.Errorf("failed to get config path: %w", err)
+			}
+
+			cfgManager, err := config.New(configPath)
+			if err != nil {
+				return err
+			}
+
+			clientConfig, err := cfgManager.GetClientConfig(options.Provider)
+			if err != nil {
+				return err
+			}
+			ApplyCommonOptions(&options.CommonOptions, clientConfig)
+
+			apiClient, err := client.New(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			models, err := apiClient.ListModels(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return writeModels(os.Stdout, models, clientConfig.Model, options.JSON)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Provider, "provider", "", "List the models of this provider instead of the configured one")
+	cmd.Flags().StringVar(&options.APIBase, "api-base", "", "Override API base URL")
+	cmd.Flags().StringVar(&options.APIKey, "api-key", "", "Override API key")
+	cmd.Flags().StringVar(&options.Proxy, "proxy", "", "Override proxy URL")
+	cmd.Flags().IntVar(&options.Timeout, "timeout", 0, "Override request timeout in seconds")
+	options.flags = cmd.Flags()
+	cmd.Flags().BoolVar(&options.JSON, "json", false, "Output the models as JSON")
+
+	return cmd
+}
+
+// writeModels writes models to w, one per line with the current model marked,
+// or as a JSON list
+func writeModels(w io.Writer, models []string, current string, asJSON bool) error {
+	if asJSON {
+		if models == nil {
+			models = []string{}
+		}
+		data, err := json.MarshalIndent(models, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal models: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	if len(models) == 0 {
+		fmt.Fprintln(w, "The provider listed no models.")
+		return nil
+	}
+	for _, model := range models {
+		marker := " "
+		if model == current {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\n", marker, model)
+	}
+	return nil
+}
+
+// listProviderModels lists the models of provider with the values entered in
+// newprovider, for the model picker
+func listProviderModels(ctx context.Context, provider string, definition *types.ProviderDefinition, values map[string]string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, listModelsTimeout)
+	defer cancel()
+
+	apiClient, err := client.New(&types.ClientConfig{
+		Provider:   provider,
+		APIBase:    values["api_base"],
+		APIKey:     values["api_key"],
+		APIVersion: values["api_version"],
+		Definition: definition,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiClient.ListModels(ctx)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteModels(t *testing.T) {
+	t.Run("list marks the current model", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeModels(&buf, []string{"gpt-4o", "gpt-4o-mini"}, "gpt-4o-mini", false))
+		assert.Equal(t, "  gpt-4o\n* gpt-4o-mini\n", buf.String())
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeModels(&buf, nil, "gpt-4o", false))
+		assert.Contains(t, buf.String(), "no models")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeModels(&buf, nil, "", true))
+		assert.JSONEq(t, `[]`, buf.String())
+
+		buf.Reset()
+		require.NoError(t, writeModels(&buf, []string{"llama3.2"}, "", true))
+		assert.JSONEq(t, `["llama3.2"]`, buf.String())
+	})
+}
+
+func TestListProviderModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data": [{"id": "deepseek-chat"}]}`))
+	}))
+	defer server.Close()
+
+	models, err := listProviderModels(context.Background(), "deepseek", nil, map[string]string{
+		"api_base": server.URL,
+		"api_key":  "sk-test",
+		"model":    "",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deepseek-chat"}, models)
+}
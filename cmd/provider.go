@@ -1,13 +1,19 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/belingud/gptcomet/internal/config"
 	"github.com/belingud/gptcomet/internal/debug"
 	"github.com/belingud/gptcomet/internal/llm"
+	"github.com/belingud/gptcomet/internal/logger"
 	"github.com/belingud/gptcomet/internal/ui"
 	"github.com/belingud/gptcomet/pkg/types"
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,14 +26,19 @@ func NewProviderCmd() *cobra.Command {
 		Use:   "newprovider",
 		Short: "Configure a new provider interactively",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// get providers list
-			providers := llm.GetProviders()
+			// get providers list, with the providers declared in the config file
+			customProviders := customProviderDefinitions(cmd)
+			providers := providerNames(llm.GetProviders(), customProviders)
 
 			// In test environment, skip interactive selection
 			if os.Getenv("GPTCOMET_TEST") == "1" {
 				// Just list providers
 				fmt.Fprintln(cmd.OutOrStdout(), "Available providers:")
 				for _, p := range providers {
+					if _, ok := customProviders[p]; ok && !llm.HasProvider(p) {
+						fmt.Fprintln(cmd.OutOrStdout(), "-", p, "(custom)")
+						continue
+					}
 					fmt.Fprintln(cmd.OutOrStdout(), "-", p)
 				}
 				return nil
@@ -35,7 +46,7 @@ func NewProviderCmd() *cobra.Command {
 
 			// Create and run provider selector
 			selector := ui.NewProviderSelector(providers)
-			p := tea.NewProgram(selector)
+			p := tea.NewProgram(selector, tea.WithContext(cmd.Context()))
 			m, err := p.Run()
 			if err != nil {
 				return fmt.Errorf("failed to run provider selector: %w", err)
@@ -47,7 +58,11 @@ func NewProviderCmd() *cobra.Command {
 			}
 
 			// Create provider instance with config
-			provider, err := llm.NewProvider(providerName, &types.ClientConfig{})
+			providerConfig := &types.ClientConfig{}
+			if definition, ok := customProviders[providerName]; ok && !llm.HasProvider(providerName) {
+				providerConfig.Definition = &definition
+			}
+			provider, err := llm.NewProvider(providerName, providerConfig)
 			if err != nil {
 				return fmt.Errorf("failed to create provider: %w", err)
 			}
@@ -56,9 +71,14 @@ func NewProviderCmd() *cobra.Command {
 			requiredConfig := provider.GetRequiredConfig()
 			debug.Printf("Required config: %v", requiredConfig)
 
-			// Create and run config input
+			// Create and run config input, offering the models of the provider when it can list them
 			configInput := ui.NewConfigInput(requiredConfig)
-			p = tea.NewProgram(configInput)
+			if _, ok := provider.(llm.ModelLister); ok {
+				configInput.WithModels(func(values map[string]string) ([]string, error) {
+					return listProviderModels(cmd.Context(), providerName, providerConfig.Definition, values)
+				})
+			}
+			p = tea.NewProgram(configInput, tea.WithContext(cmd.Context()))
 			m, err = p.Run()
 			if err != nil {
 				return fmt.Errorf("failed to run config input: %w", err)
@@ -99,6 +119,10 @@ func NewProviderCmd() *cobra.Command {
 
 				reader := term.NewTerminal(os.Stdin, "")
 				response, err := reader.ReadLine()
+				if errors.Is(err, io.EOF) {
+					// Ctrl-C and Ctrl-D end the input in raw mode
+					return context.Canceled
+				}
 				if err != nil {
 					return fmt.Errorf("failed to read input: %w", err)
 				}
@@ -124,3 +148,38 @@ func NewProviderCmd() *cobra.Command {
 
 	return cmd
 }
+
+// customProviderDefinitions returns the providers declared in the config file,
+// or nil if the config can't be read, so the built-in providers are still listed.
+func customProviderDefinitions(cmd *cobra.Command) map[string]types.ProviderDefinition {
+	configPath, err := cmd.Root().PersistentFlags().GetString("config")
+	if err != nil {
+		return nil
+	}
+
+	cfgManager, err := config.New(configPath)
+	if err != nil {
+		logger.Debug("Failed to load custom providers: %v", err)
+		return nil
+	}
+
+	definitions, err := cfgManager.GetCustomProviders()
+	if err != nil {
+		logger.Warn("Skipping custom providers: %v", err)
+		return nil
+	}
+	return definitions
+}
+
+// providerNames returns the sorted names of the built-in and custom providers.
+// Custom providers with the name of a built-in provider are listed once.
+func providerNames(builtin []string, custom map[string]types.ProviderDefinition) []string {
+	names := append([]string{}, builtin...)
+	for name := range custom {
+		if !slices.Contains(builtin, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/belingud/gptcomet/internal/client"
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/llm"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// providerTestPrompt is the tiny prompt sent by 'provider test'
+	providerTestPrompt = "This is a connection test. Reply with the single word OK."
+	// maxRawResponseLength limits the raw response shown for an unresolved path
+	maxRawResponseLength = 4000
+)
+
+// ProviderTestOptions contains the settings of the provider test
+type ProviderTestOptions struct {
+	CommonOptions
+	NoStream bool
+}
+
+// providerTestClient is the part of the client used by the provider test
+type providerTestClient interface {
+	Chat(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, error)
+	Stream(ctx context.Context, message string, history []types.Message, callback func(*types.CompletionResponse) error) error
+	Usage() types.Usage
+}
+
+// providerCheck is the result of one test request
+type providerCheck struct {
+	Stream   bool
+	Latency  time.Duration
+	Answer   string
+	Usage    types.Usage
+	Response *llm.Response
+	Err      error
+}
+
+// NewProviderGroupCmd creates and returns a new cobra.Command for the 'provider'
+// subcommand, which groups the commands that work on the configured providers.
+//
+// Subcommands:
+//   - test: Send a tiny prompt through the provider and check the response
+func NewProviderGroupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "provider",
+		Short: "Manage the configured providers",
+	}
+
+	cmd.AddCommand(newProviderTestCmd())
+
+	return cmd
+}
+
+// newProviderTestCmd creates and returns a new cobra.Command that sends a tiny
+// prompt through the configured provider with the same client as commit and
+// review, and reports the latency, HTTP status, answer and usage, and whether
+// the answer paths of the config resolve in the responses.
+func newProviderTestCmd() *cobra.Command {
+	options := ProviderTestOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Check the connection, credentials and answer paths of a provider",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Root().PersistentFlags().GetString("config")
+			if err != nil {
+				return fmt.Errorf("failed to get config path: %w", err)
+			}
+
+			cfgManager, err := config.New(configPath)
+			if err != nil {
+				return err
+			}
+
+			clientConfig, err := cfgManager.GetClientConfig(options.Provider)
+			if err != nil {
+				return err
+			}
+			ApplyCommonOptions(&options.CommonOptions, clientConfig)
+			// Report the first failure instead of retrying it
+			clientConfig.Retries = 1
+
+			newClient := func() (providerTestClient, error) {
+				return client.New(clientConfig)
+			}
+			return runProviderTest(cmd.Context(), os.Stdout, newClient, clientConfig, !options.NoStream)
+		},
+	}
+
+	generalFlags := cmd.Flags()
+	generalFlags.BoolVar(&options.NoStream, "no-stream", false, "Skip the streaming request")
+	generalFlags.StringVar(&options.Provider, "provider", "", "Test this provider instead of the configured one")
+	generalFlags.StringVar(&options.APIBase, "api-base", "", "Override API base URL")
+	generalFlags.StringVar(&options.APIKey, "api-key", "", "Override API key")
+	generalFlags.StringVar(&options.Model, "model", "", "Override model name")
+	generalFlags.StringVar(&options.AnswerPath, "answer-path", "", "Override answer path")
+	generalFlags.StringVar(&options.CompletionPath, "completion-path", "", "Override completion path")
+	generalFlags.StringVar(&options.Proxy, "proxy", "", "Override proxy URL")
+	generalFlags.IntVar(&options.Timeout, "timeout", 0, "Override request timeout in seconds")
+	options.flags = generalFlags
+
+	return cmd
+}
+
+// runProviderTest sends the test prompt with a client from newClient, and with
+// another one as a stream if stream is set, and writes the report to w. It
+// returns an error if a request failed or an answer path doesn't resolve.
+func runProviderTest(ctx context.Context, w io.Writer, newClient func() (providerTestClient, error), clientConfig *types.ClientConfig, stream bool) error {
+	// The provider sets the default paths on the config when the client is created
+	apiClient, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Provider: %s\n", clientConfig.Provider)
+	fmt.Fprintf(w, "Model:    %s\n", clientConfig.Model)
+	if clientConfig.APIBase != "" {
+		fmt.Fprintf(w, "API base: %s\n", clientConfig.APIBase)
+	}
+
+	checks := []providerCheck{runProviderCheck(ctx, apiClient, false)}
+	if stream {
+		if apiClient, err = newClient(); err != nil {
+			return err
+		}
+		checks = append(checks, runProviderCheck(ctx, apiClient, true))
+	}
+
+	failed := false
+	for _, check := range checks {
+		if !writeProviderCheck(w, check, clientConfig) {
+			failed = true
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if failed {
+		return errors.New("provider test failed")
+	}
+	fmt.Fprintf(w, "\nProvider %s works.\n", clientConfig.Provider)
+	return nil
+}
+
+// runProviderCheck sends the test prompt with apiClient and records the raw
+// response
+func runProviderCheck(ctx context.Context, apiClient providerTestClient, stream bool) providerCheck {
+	check := providerCheck{Stream: stream}
+	ctx = llm.WithResponseRecorder(ctx, func(resp llm.Response) {
+		check.Response = &resp
+	})
+
+	start := time.Now()
+	if stream {
+		var answer strings.Builder
+		check.Err = apiClient.Stream(ctx, providerTestPrompt, nil, func(resp *types.CompletionResponse) error {
+			answer.WriteString(resp.Content)
+			return nil
+		})
+		check.Answer = strings.TrimSpace(answer.String())
+	} else {
+		resp, err := apiClient.Chat(ctx, providerTestPrompt, nil)
+		check.Err = err
+		if resp != nil {
+			check.Answer = resp.Content
+		}
+	}
+	check.Latency = time.Since(start)
+	check.Usage = apiClient.Usage()
+	return check
+}
+
+// writeProviderCheck writes the report of check to w and reports whether it passed
+func writeProviderCheck(w io.Writer, check providerCheck, clientConfig *types.ClientConfig) bool {
+	title, pathKey, path := "Request", "answer_path", clientConfig.AnswerPath
+	if check.Stream {
+		title, pathKey, path = "Streaming request", "stream_answer_path", clientConfig.StreamAnswerPath
+	}
+
+	fmt.Fprintf(w, "\n%s\n", title)
+	if check.Response != nil {
+		fmt.Fprintf(w, "  Status:  %s\n", formatStatus(check.Response.StatusCode))
+	}
+	fmt.Fprintf(w, "  Latency: %dms\n", check.Latency.Milliseconds())
+
+	passed := true
+	if check.Err != nil {
+		fmt.Fprintf(w, "  Error:   %s\n", strings.TrimSpace(check.Err.Error()))
+		passed = false
+	} else {
+		fmt.Fprintf(w, "  Answer:  %s\n", check.Answer)
+		if check.Usage.TotalTokens > 0 {
+			fmt.Fprintf(w, "  Usage:   %s\n", check.Usage)
+		} else {
+			fmt.Fprintln(w, "  Usage:   not reported")
+		}
+	}
+
+	// Paths can only be checked in successful responses
+	if check.Response == nil || (check.Response.StatusCode != 0 && check.Response.StatusCode != http.StatusOK) {
+		return passed
+	}
+	if llm.ResolvesPath(*check.Response, path) {
+		fmt.Fprintf(w, "  %s %q resolves\n", pathKey, path)
+		return passed
+	}
+
+	fmt.Fprintf(w, "  %s %q doesn't resolve in the response:\n", pathKey, path)
+	fmt.Fprintln(w, indent(formatRawResponse(*check.Response), "    "))
+	if suggestion := llm.SuggestAnswerPath(*check.Response); suggestion != "" {
+		fmt.Fprintf(w, "  The answer is most likely at %q, set it with:\n", suggestion)
+		fmt.Fprintf(w, "    gmsg config set %s.%s '%s'\n", clientConfig.Provider, pathKey, suggestion)
+	}
+	return false
+}
+
+// formatStatus formats an HTTP status code, or notes that the provider doesn't use HTTP
+func formatStatus(code int) string {
+	if code == 0 {
+		return "n/a (not an HTTP provider)"
+	}
+	return fmt.Sprintf("%d %s", code, http.StatusText(code))
+}
+
+// formatRawResponse returns the body of resp, indented if it is a JSON
+// object, and shortened to maxRawResponseLength
+func formatRawResponse(resp llm.Response) string {
+	body := bytes.TrimSpace(resp.Body)
+	var out bytes.Buffer
+	if !resp.Stream && json.Indent(&out, body, "", "  ") == nil {
+		body = out.Bytes()
+	}
+
+	text := string(body)
+	if len(text) > maxRawResponseLength {
+		text = text[:maxRawResponseLength] + "\n... (truncated)"
+	}
+	return text
+}
+
+// indent prefixes every line of text
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/client"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProviderTestServer answers like an OpenAI-compatible API, with body for
+// regular requests and stream for streaming requests
+func newProviderTestServer(t *testing.T, status int, body, stream string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "yes", r.Header.Get("X-Gateway"), "extra headers should be sent")
+		request, _ := io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		if strings.Contains(string(request), `"stream":true`) {
+			w.Write([]byte(stream))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func runProviderTestWith(t *testing.T, server *httptest.Server, stream bool) (string, error) {
+	clientConfig := &types.ClientConfig{
+		Provider:     "openai",
+		APIBase:      server.URL,
+		APIKey:       "sk-test",
+		Model:        "gpt-4o",
+		Retries:      1,
+		ExtraHeaders: map[string]string{"X-Gateway": "yes"},
+	}
+	newClient := func() (providerTestClient, error) {
+		return client.New(clientConfig)
+	}
+
+	var buf bytes.Buffer
+	err := runProviderTest(context.Background(), &buf, newClient, clientConfig, stream)
+	return buf.String(), err
+}
+
+func TestRunProviderTest(t *testing.T) {
+	t.Run("working provider", func(t *testing.T) {
+		server := newProviderTestServer(t, http.StatusOK,
+			`{"choices": [{"message": {"content": "OK"}}], "usage": {"prompt_tokens": 20, "completion_tokens": 1}}`,
+			"data: {\"choices\": [{\"delta\": {\"content\": \"OK\"}}]}\n\ndata: [DONE]\n\n")
+
+		output, err := runProviderTestWith(t, server, true)
+		require.NoError(t, err)
+		assert.Contains(t, output, "Status:  200 OK")
+		assert.Contains(t, output, "Answer:  OK")
+		assert.Contains(t, output, "prompt: 20, completion: 1, total: 21")
+		assert.Contains(t, output, `answer_path "choices.0.message.content" resolves`)
+		assert.Contains(t, output, `stream_answer_path "choices.0.delta.content" resolves`)
+		assert.Contains(t, output, "Provider openai works.")
+	})
+
+	t.Run("answer path doesn't resolve", func(t *testing.T) {
+		server := newProviderTestServer(t, http.StatusOK, `{"id": "req-1", "output": {"text": "OK"}}`, "")
+
+		output, err := runProviderTestWith(t, server, false)
+		require.Error(t, err)
+		assert.Contains(t, output, `answer_path "choices.0.message.content" doesn't resolve`)
+		assert.Contains(t, output, `"text": "OK"`)
+		assert.Contains(t, output, "gmsg config set openai.answer_path 'output.text'")
+		assert.NotContains(t, output, "Streaming request")
+	})
+
+	t.Run("rejected key", func(t *testing.T) {
+		server := newProviderTestServer(t, http.StatusUnauthorized, `{"error": {"message": "invalid key"}}`, `{"error": {"message": "invalid key"}}`)
+
+		output, err := runProviderTestWith(t, server, true)
+		require.Error(t, err)
+		assert.Contains(t, output, "Status:  401 Unauthorized")
+		assert.Contains(t, output, "API Authentication Failed")
+		assert.NotContains(t, output, "doesn't resolve")
+	})
+}
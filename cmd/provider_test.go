@@ -127,3 +127,13 @@ func TestProviderCmd_MultipleProviders(t *testing.T) {
 	assert.Contains(t, output, "test-provider1")
 	assert.Contains(t, output, "test-provider2")
 }
+
+func TestProviderNames(t *testing.T) {
+	custom := map[string]types.ProviderDefinition{
+		"myvendor": {Name: "myvendor"},
+		"openai":   {Name: "openai"},
+	}
+
+	assert.Equal(t, []string{"deepseek", "myvendor", "openai"}, providerNames([]string{"deepseek", "openai"}, custom))
+	assert.Equal(t, []string{"openai"}, providerNames([]string{"openai"}, nil))
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -28,6 +29,8 @@ type ReviewOptions struct {
 	UseSVN     bool
 	ConfigPath string
 	Stream     bool
+	Chunk      bool
+	NoCache    bool
 }
 
 // MarkdownRenderer interface for mocking in tests
@@ -75,10 +78,14 @@ func NewReviewService(options ReviewOptions) (*ReviewService, error) {
 	// Overwrite client config with command line flags
 	ApplyCommonOptions(&options.CommonOptions, clientConfig)
 
-	apiClient, err := client.New(clientConfig)
+	apiClient, err := factory.NewClientWithFallbacks(cfgManager, clientConfig)
 	if err != nil {
 		return nil, err
 	}
+	apiClient.SetLedger(newUsageLedger(), "review")
+	if !options.NoCache {
+		apiClient.SetCache(newResponseCache(cfgManager))
+	}
 
 	return &ReviewService{
 		vcs:              vcs,
@@ -91,8 +98,8 @@ func NewReviewService(options ReviewOptions) (*ReviewService, error) {
 	}, nil
 }
 
-// Execute performs the review operation
-func (s *ReviewService) Execute() error {
+// Execute performs the review operation, stopping early when ctx is cancelled
+func (s *ReviewService) Execute(ctx context.Context) error {
 	// Get verbose setting
 	verbose := s.getVerboseSetting()
 
@@ -122,6 +129,19 @@ func (s *ReviewService) Execute() error {
 
 	// Get provider and model from configuration
 	fmt.Printf("Discovered provider: %s, model: %s\n", s.clientConfig.Provider, s.clientConfig.Model)
+	if verbose {
+		// Report the tokens and estimated cost of all requests when done
+		defer printUsageSummary(s.client, s.cfgManager)
+	}
+
+	// Summarize large diffs in chunks and review the summaries instead
+	diff, err = summarizeDiff(ctx, s.client, s.cfgManager, diff, s.options.Chunk, verbose)
+	if err != nil {
+		if progress != nil {
+			progress.Error("Generating review", err)
+		}
+		return err
+	}
 
 	// Use streaming mode if the option is enabled
 	if s.options.Stream {
@@ -129,11 +149,11 @@ func (s *ReviewService) Execute() error {
 			// Complete current stage in new line, have a checkmark prefix
 			progress.CompleteInNewLine("Generating review")
 		}
-		return s.ExecuteStream(diff)
+		return s.ExecuteStream(ctx, diff)
 	}
 
 	// Otherwise use the standard non-streaming mode
-	reviewComment, err := s.generateReviewComment(diff)
+	reviewComment, err := s.generateReviewComment(ctx, diff)
 	if err != nil {
 		if progress != nil {
 			progress.Error("Generating review", err)
@@ -165,7 +185,7 @@ func (s *ReviewService) getVerboseSetting() bool {
 }
 
 // ExecuteStream performs the review operation with streaming output
-func (s *ReviewService) ExecuteStream(diff string) error {
+func (s *ReviewService) ExecuteStream(ctx context.Context, diff string) error {
 	if diff == "" {
 		return fmt.Errorf("empty diff provided")
 	}
@@ -189,7 +209,7 @@ func (s *ReviewService) ExecuteStream(diff string) error {
 	var responseBuffer strings.Builder
 
 	// Define the callback function that will be called with each chunk of the response
-	err = s.client.GenerateReviewCommentStream(diff, prompt, func(chunk string) error {
+	err = s.client.GenerateReviewCommentStream(ctx, diff, prompt, func(chunk string) error {
 		// Print the chunk directly to the console
 		fmt.Print(chunk)
 		// Also accumulate it for final formatting
@@ -236,7 +256,7 @@ func (s *ReviewService) getStagedDiff() (string, error) {
 
 // generateReviewComment creates a review comment using the provided diff
 // and configured prompt template.
-func (s *ReviewService) generateReviewComment(diff string) (string, error) {
+func (s *ReviewService) generateReviewComment(ctx context.Context, diff string) (string, error) {
 	if diff == "" {
 		return "", fmt.Errorf("empty diff provided")
 	}
@@ -255,7 +275,7 @@ func (s *ReviewService) generateReviewComment(diff string) (string, error) {
 	logger.Debug("Generating review comment for diff length: %d", len(diff))
 
 	fmt.Println(formatRemindMessage("Reviwing, may take a few seconds, you can set --stream/-s to stream the results..."))
-	comment, err := s.client.GenerateReviewComment(diff, prompt)
+	comment, err := s.client.GenerateReviewComment(ctx, diff, prompt)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate review comment: %w", err)
 	}
@@ -347,7 +367,7 @@ func NewReviewCmd() *cobra.Command {
 				return err
 			}
 
-			return service.Execute()
+			return service.Execute(cmd.Context())
 		},
 	}
 
@@ -357,6 +377,8 @@ func NewReviewCmd() *cobra.Command {
 	// General Flags
 	AddGeneralFlags(generalFlags, &options.RepoPath, &options.UseSVN)
 	generalFlags.BoolVarP(&options.Stream, "stream", "s", false, "Stream output as it arrives from the LLM")
+	generalFlags.BoolVar(&options.Chunk, "chunk", false, "Summarize diffs larger than chunk.token_budget in chunks first")
+	generalFlags.BoolVar(&options.NoCache, "no-cache", false, "Always send a new request instead of using a cached response")
 
 	// Advanced API Flags (shared with other commands)
 	AddAdvancedAPIFlags(advancedFlags, &options.CommonOptions)
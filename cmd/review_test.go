@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -23,7 +24,7 @@ func TestNewReviewService(t *testing.T) {
 		{
 			name: "success_git",
 			setupMocks: func(vcs *MockVCS, cfg *testutils.MockConfigManager) {
-				cfg.On("GetClientConfig").Return(&types.ClientConfig{}, nil)
+				cfg.On("GetClientConfig", mock.Anything).Return(&types.ClientConfig{}, nil)
 				cfg.On("Get", "openai.api_key").Return("dummy-key", true)
 				cfg.On("Get", "output.lang").Return("en", true)
 			},
@@ -93,12 +94,34 @@ func TestReviewService_Execute(t *testing.T) {
 				cfg.On("Get", REVIEW_LANG_KEY).Return("en", true)
 				cfg.On("GetWithDefault", "output.markdown_theme", mock.Anything).Return("auto")
 				cfg.On("GetNestedValue", []string{"console", "verbose"}).Return(false, true)
-				client.On("GenerateReviewComment", "test-diff", "test-prompt").Return("test-comment", nil)
+				cfg.On("GetChunkEnabled").Return(false)
+				client.On("GenerateReviewComment", mock.Anything, "test-diff", "test-prompt").Return("test-comment", nil)
 				vcs.On("GetStagedDiffFiltered", mock.Anything, mock.Anything).Return("staged-diff", nil)
 			},
 			wantErr:     false,
 			isPipeInput: false,
 		},
+		{
+			name: "success_with_chunked_diff",
+			setupMocks: func(vcs *MockVCS, cfg *testutils.MockConfigManager, client *MockClient) {
+				vcs.On("HasStagedChanges", mock.Anything).Return(true, nil)
+				vcs.On("GetStagedDiffFiltered", mock.Anything, mock.Anything).Return("test-diff", nil)
+				cfg.On("GetReviewPrompt").Return("test-prompt")
+				cfg.On("Get", REVIEW_LANG_KEY).Return("en", true)
+				cfg.On("GetWithDefault", "output.markdown_theme", mock.Anything).Return("auto")
+				cfg.On("GetNestedValue", []string{"console", "verbose"}).Return(false, true)
+				cfg.On("GetChunkEnabled").Return(true)
+				cfg.On("GetChunkTokenBudget").Return(1)
+				cfg.On("GetChunkSummaryPrompt").Return("summarize {{ placeholder }}")
+				client.On("Chat", mock.Anything, "summarize test-diff", []types.Message(nil)).
+					Return(&types.CompletionResponse{Content: "test-summary"}, nil)
+				client.On("GenerateReviewComment", mock.Anything, mock.MatchedBy(func(diff string) bool {
+					return strings.Contains(diff, "test-summary")
+				}), "test-prompt").Return("test-comment", nil)
+			},
+			wantErr:     false,
+			isPipeInput: false,
+		},
 		{
 			name: "no_staged_changes",
 			setupMocks: func(vcs *MockVCS, cfg *testutils.MockConfigManager, client *MockClient) {
@@ -129,7 +152,7 @@ func TestReviewService_Execute(t *testing.T) {
 				clientConfig:     &types.ClientConfig{Provider: "test-provider", Model: "test-model"},
 			}
 
-			err := service.Execute()
+			err := service.Execute(context.Background())
 
 			mockVCS.AssertExpectations(t)
 			mockCfg.AssertExpectations(t)
@@ -245,7 +268,7 @@ func TestReviewService_generateReviewComment(t *testing.T) {
 			setupMocks: func(cfg *testutils.MockConfigManager, client *MockClient) {
 				cfg.On("GetReviewPrompt").Return("test-prompt")
 				cfg.On("Get", REVIEW_LANG_KEY).Return("en", true)
-				client.On("GenerateReviewComment", "test-diff", "test-prompt").Return("test-comment", nil)
+				client.On("GenerateReviewComment", mock.Anything, "test-diff", "test-prompt").Return("test-comment", nil)
 			},
 			wantComment: "test-comment",
 			wantErr:     false,
@@ -270,7 +293,7 @@ func TestReviewService_generateReviewComment(t *testing.T) {
 				cfgManager: mockCfg,
 			}
 
-			comment, err := service.generateReviewComment(tt.diff)
+			comment, err := service.generateReviewComment(context.Background(), tt.diff)
 
 			mockCfg.AssertExpectations(t)
 			mockClient.AssertExpectations(t)
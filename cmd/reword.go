@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/client"
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/git"
+	"github.com/belingud/gptcomet/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// RewordOptions contains the settings of the reword command
+type RewordOptions struct {
+	CommonOptions
+	RepoPath   string
+	ConfigPath string
+	Rich       bool
+	AutoYes    bool
+	DryRun     bool
+	Force      bool
+	NoCache    bool
+}
+
+// rewordVCS is the part of git used by reword
+type rewordVCS interface {
+	PlanRewrite(repoPath, revRange string) (*git.RewritePlan, error)
+	RewriteMessages(repoPath string, plan *git.RewritePlan, messages map[string]string) (string, error)
+	GetCommitMessage(repoPath, commit string) (string, error)
+	GetCommitDiff(repoPath, commit string, staged bool, cfgManager config.ManagerInterface) (string, error)
+}
+
+// RewordService regenerates the messages of a range of existing commits and
+// rewrites them with the messages the user selects
+type RewordService struct {
+	vcs        rewordVCS
+	commit     *CommitService
+	cfgManager config.ManagerInterface
+	options    RewordOptions
+	editor     TextEditor
+	picker     RewordPicker
+}
+
+// NewRewordService creates a RewordService that generates the messages like commit does
+func NewRewordService(options RewordOptions) (*RewordService, error) {
+	commitService, err := NewCommitService(CommitOptions{
+		CommonOptions: options.CommonOptions,
+		RepoPath:      options.RepoPath,
+		ConfigPath:    options.ConfigPath,
+		Rich:          options.Rich,
+		NoCache:       options.NoCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	setLedgerCommand(commitService.client, "reword")
+
+	return &RewordService{
+		vcs:        &git.GitVCS{},
+		commit:     commitService,
+		cfgManager: commitService.cfgManager,
+		options:    options,
+		editor:     &TerminalEditor{},
+		picker:     &TerminalRewordPicker{},
+	}, nil
+}
+
+// NewRewordCmd creates and returns a new cobra.Command for the 'reword' subcommand.
+// It generates a new message for every commit of a range from the commit's own
+// diff, shows the current and new messages side by side to pick the commits to
+// reword, and rewrites them on the current branch.
+//
+// The command supports the following flags:
+//   - --force, -f: Rewrite pushed commits and protected branches (bool)
+//   - --yes, -y: Reword all commits without asking (bool)
+//   - --dry-run: Print the new messages without rewriting (bool)
+//   - --rich, -r: Generate detailed commit messages (bool)
+//   - --no-cache: Always send a new request instead of using a cached response (bool)
+//   - --repo: Repository path (string)
+//   - The advanced API flags shared with commit
+func NewRewordCmd() *cobra.Command {
+	options := RewordOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "reword <range>",
+		Short: "Regenerate the messages of existing commits",
+		Long: `Regenerate the messages of the commits in a range, like origin/main..HEAD,
+from the changes of each commit, and rewrite the commits you select.
+A single revision, like HEAD~3, means the commits after it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.RepoPath == "" {
+				var err error
+				options.RepoPath, err = os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+			}
+
+			configPath, err := cmd.Root().PersistentFlags().GetString("config")
+			if err != nil {
+				return fmt.Errorf("failed to get config path: %w", err)
+			}
+			options.ConfigPath = configPath
+
+			service, err := NewRewordService(options)
+			if err != nil {
+				return err
+			}
+
+			return service.Execute(cmd.Context(), args[0])
+		},
+	}
+
+	var generalFlags = pflag.NewFlagSet("General Flag", pflag.ExitOnError)
+	var advancedFlags = pflag.NewFlagSet("Overwrite Flag", pflag.ExitOnError)
+
+	generalFlags.StringVar(&options.RepoPath, "repo", "", "Repository path")
+	generalFlags.BoolVarP(&options.Force, "force", "f", false, "Rewrite commits that are pushed or on a protected branch")
+	generalFlags.BoolVarP(&options.AutoYes, "yes", "y", false, "Reword all commits without asking")
+	generalFlags.BoolVar(&options.DryRun, "dry-run", false, "Print the new messages and exit without rewriting")
+	generalFlags.BoolVarP(&options.Rich, "rich", "r", false, "Generate rich commit messages with details")
+	generalFlags.BoolVar(&options.NoCache, "no-cache", false, "Always send a new request instead of using a cached response")
+
+	AddAdvancedAPIFlags(advancedFlags, &options.CommonOptions)
+
+	cmd.Flags().AddFlagSet(generalFlags)
+	cmd.Flags().AddFlagSet(advancedFlags)
+	SetAdvancedHelpFunc(cmd, generalFlags, advancedFlags)
+
+	return cmd
+}
+
+// Execute rewords the commits of revRange, stopping early when ctx is cancelled
+func (s *RewordService) Execute(ctx context.Context, revRange string) error {
+	plan, err := s.vcs.PlanRewrite(s.options.RepoPath, revRange)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRewritable(plan); err != nil {
+		return err
+	}
+
+	entries, err := s.generateMessages(ctx, plan.Commits)
+	if err != nil {
+		return err
+	}
+
+	if s.options.DryRun {
+		for _, entry := range entries {
+			fmt.Printf("\n%s %s\n", git.ShortHash(entry.Hash), git.FirstLine(entry.OldMessage))
+			if entry.NewMessage == "" {
+				fmt.Println("No new message")
+				continue
+			}
+			fmt.Println(formatBoxedMessage(entry.NewMessage))
+		}
+		return nil
+	}
+
+	if !s.options.AutoYes {
+		applied, err := s.review(ctx, entries)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	messages := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Selected && entry.NewMessage != "" {
+			messages[entry.Hash] = entry.NewMessage
+		}
+	}
+	if len(messages) == 0 {
+		fmt.Println("No commits selected, nothing to reword")
+		return nil
+	}
+
+	if signed := signedRewrites(plan, messages); len(signed) > 0 {
+		fmt.Printf("Warning: %d of the commits to rewrite are signed, starting with %s, the rewritten commits are not signed\n",
+			len(signed), git.ShortHash(signed[0]))
+	}
+
+	newHead, err := s.vcs.RewriteMessages(s.options.RepoPath, plan, messages)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite commits: %w", err)
+	}
+
+	fmt.Printf("\nReworded %d commits on %s, HEAD is now %s\n", len(messages), plan.Branch, git.ShortHash(newHead))
+	fmt.Printf("To undo, run: git reset --soft %s\n", git.ShortHash(plan.Head))
+	return nil
+}
+
+// checkRewritable returns an error if the plan rewrites a protected branch or
+// pushed commits, unless --force is given
+func (s *RewordService) checkRewritable(plan *git.RewritePlan) error {
+	if s.options.Force {
+		return nil
+	}
+	if slices.Contains(s.cfgManager.GetProtectedBranches(), plan.Branch) {
+		return fmt.Errorf("branch %s is protected, rewording would rewrite its history (use --force to reword anyway)", plan.Branch)
+	}
+	if len(plan.Pushed) > 0 {
+		return fmt.Errorf("%d of the commits to rewrite are already pushed, starting with %s (use --force to reword anyway)",
+			len(plan.Pushed), git.ShortHash(plan.Pushed[0]))
+	}
+	return nil
+}
+
+// signedRewrites returns the signed commits of plan that rewriting with
+// messages recreates, the commits of the chain from the first reworded one
+func signedRewrites(plan *git.RewritePlan, messages map[string]string) []string {
+	first := slices.IndexFunc(plan.Chain, func(commit string) bool {
+		_, ok := messages[commit]
+		return ok
+	})
+	if first < 0 {
+		return nil
+	}
+	rewritten := plan.Chain[first:]
+
+	var signed []string
+	for _, commit := range plan.Signed {
+		if slices.Contains(rewritten, commit) {
+			signed = append(signed, commit)
+		}
+	}
+	return signed
+}
+
+// generateMessages generates a new message for every commit from its own diff.
+// Commits without changes after filtering with file_ignore, and commits whose
+// message can't be generated keep their message.
+func (s *RewordService) generateMessages(ctx context.Context, commits []string) ([]ui.RewordEntry, error) {
+	entries := make([]ui.RewordEntry, 0, len(commits))
+	for i, commit := range commits {
+		oldMessage, err := s.vcs.GetCommitMessage(s.options.RepoPath, commit)
+		if err != nil {
+			return nil, err
+		}
+		entry := ui.RewordEntry{Hash: commit, OldMessage: oldMessage}
+
+		fmt.Printf("Generating message for %s (%d/%d): %s\n", git.ShortHash(commit), i+1, len(commits), git.FirstLine(oldMessage))
+		entry.NewMessage, err = s.generateMessage(ctx, commit)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			fmt.Printf("Keeping the message of %s: %v\n", git.ShortHash(commit), err)
+		}
+		entry.Selected = entry.NewMessage != ""
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// generateMessage generates a new message for commit from its diff, or
+// returns "" if the commit has no changes after filtering with file_ignore
+func (s *RewordService) generateMessage(ctx context.Context, commit string) (string, error) {
+	diff, err := s.vcs.GetCommitDiff(s.options.RepoPath, commit, false, s.cfgManager)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", nil
+	}
+	return s.commit.generateCommitMessage(ctx, diff)
+}
+
+// review shows the commits until the user applies the selection, editing or
+// regenerating messages in between. It reports whether the selection was applied.
+func (s *RewordService) review(ctx context.Context, entries []ui.RewordEntry) (bool, error) {
+	cursor := 0
+	for {
+		action, index, err := s.picker.Pick(entries, cursor)
+		if err != nil {
+			return false, err
+		}
+		cursor = index
+
+		switch action {
+		case ui.RewordActionApply:
+			return true, nil
+		case ui.RewordActionEdit:
+			message := entries[index].NewMessage
+			if message == "" {
+				message = entries[index].OldMessage
+			}
+			edited, err := s.editor.Edit(message)
+			if err != nil {
+				fmt.Printf("Error editing message: %v\n", err)
+				continue
+			}
+			if edited = strings.TrimSpace(edited); edited != "" {
+				entries[index].NewMessage = edited
+				entries[index].Selected = true
+			}
+		case ui.RewordActionRegenerate:
+			message, err := s.generateMessage(client.WithRefreshCache(ctx), entries[index].Hash)
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			if err != nil {
+				fmt.Printf("Error in generating: %v\n", err)
+				continue
+			}
+			if message != "" {
+				entries[index].NewMessage = message
+				entries[index].Selected = true
+			}
+		default:
+			return false, nil
+		}
+	}
+}
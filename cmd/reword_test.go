@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/git"
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/belingud/gptcomet/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRewordPicker implements RewordPicker interface for testing
+type MockRewordPicker struct {
+	mock.Mock
+}
+
+func (m *MockRewordPicker) Pick(entries []ui.RewordEntry, cursor int) (ui.RewordAction, int, error) {
+	args := m.Called(entries, cursor)
+	return args.Get(0).(ui.RewordAction), args.Int(1), args.Error(2)
+}
+
+// setupRewordRepo creates a repository with "wip" commits on the feature branch
+func setupRewordRepo(t *testing.T) string {
+	t.Helper()
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	t.Cleanup(cleanup)
+
+	commit := func(name string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(name+"\n"), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", name))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "commit", "-m", "wip"))
+	}
+	commit("base.txt")
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "-b", "feature"))
+	commit("login.go")
+	commit("logout.go")
+	return repoPath
+}
+
+func commitSubjects(t *testing.T, repoPath string) string {
+	t.Helper()
+	cmd := exec.Command("git", "log", "--reverse", "--format=%s")
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	require.NoError(t, err)
+	return strings.TrimSpace(string(output))
+}
+
+func newTestRewordService(t *testing.T, repoPath string, options RewordOptions) (*RewordService, *MockClient, *MockRewordPicker, *MockTextEditor) {
+	t.Helper()
+	configPath, cleanupConfig := setupTempConfig(t)
+	t.Cleanup(cleanupConfig)
+	cfg, err := config.New(configPath)
+	require.NoError(t, err)
+
+	mockClient := new(MockClient)
+	mockPicker := new(MockRewordPicker)
+	mockEditor := new(MockTextEditor)
+	options.RepoPath = repoPath
+
+	return &RewordService{
+		vcs: &git.GitVCS{},
+		commit: &CommitService{
+			vcs:        &git.GitVCS{},
+			client:     mockClient,
+			cfgManager: cfg,
+		},
+		cfgManager: cfg,
+		options:    options,
+		editor:     mockEditor,
+		picker:     mockPicker,
+	}, mockClient, mockPicker, mockEditor
+}
+
+// diffWith matches a diff that adds file
+func diffWith(file string) interface{} {
+	return mock.MatchedBy(func(diff string) bool { return strings.Contains(diff, "+++ b/"+file) })
+}
+
+func TestRewordService_Execute(t *testing.T) {
+	t.Run("reword all with yes", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		service, mockClient, _, _ := newTestRewordService(t, repoPath, RewordOptions{AutoYes: true})
+		mockClient.On("GenerateCommitMessage", mock.Anything, diffWith("login.go"), mock.Anything).Return("feat: add login", nil)
+		mockClient.On("GenerateCommitMessage", mock.Anything, diffWith("logout.go"), mock.Anything).Return("feat: add logout", nil)
+
+		require.NoError(t, service.Execute(context.Background(), "master..HEAD"))
+		assert.Equal(t, "wip\nfeat: add login\nfeat: add logout", commitSubjects(t, repoPath))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		service, mockClient, _, _ := newTestRewordService(t, repoPath, RewordOptions{DryRun: true})
+		mockClient.On("GenerateCommitMessage", mock.Anything, mock.Anything, mock.Anything).Return("feat: generated", nil)
+
+		require.NoError(t, service.Execute(context.Background(), "HEAD~1"))
+		assert.Equal(t, "wip\nwip\nwip", commitSubjects(t, repoPath))
+	})
+
+	t.Run("review edits, deselects and applies", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		service, mockClient, mockPicker, mockEditor := newTestRewordService(t, repoPath, RewordOptions{})
+		mockClient.On("GenerateCommitMessage", mock.Anything, diffWith("login.go"), mock.Anything).Return("feat: add login", nil)
+		mockClient.On("GenerateCommitMessage", mock.Anything, diffWith("logout.go"), mock.Anything).Return("feat: add logout", nil)
+
+		mockPicker.On("Pick", mock.Anything, 0).Return(ui.RewordActionEdit, 1, nil).Once()
+		mockEditor.On("Edit", "feat: add logout").Return("feat: add logout button", nil).Once()
+		mockPicker.On("Pick", mock.Anything, 1).Run(func(args mock.Arguments) {
+			entries := args.Get(0).([]ui.RewordEntry)
+			assert.Equal(t, "feat: add logout button", entries[1].NewMessage)
+			// Deselect the first commit
+			entries[0].Selected = false
+		}).Return(ui.RewordActionApply, 1, nil).Once()
+
+		require.NoError(t, service.Execute(context.Background(), "master..HEAD"))
+		assert.Equal(t, "wip\nwip\nfeat: add logout button", commitSubjects(t, repoPath))
+		mockPicker.AssertExpectations(t)
+		mockEditor.AssertExpectations(t)
+	})
+
+	t.Run("review regenerates", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		service, mockClient, mockPicker, _ := newTestRewordService(t, repoPath, RewordOptions{})
+		mockClient.On("GenerateCommitMessage", mock.Anything, mock.Anything, mock.Anything).Return("feat: first try", nil).Once()
+		mockClient.On("GenerateCommitMessage", mock.Anything, mock.Anything, mock.Anything).Return("feat: second try", nil).Once()
+
+		mockPicker.On("Pick", mock.Anything, 0).Return(ui.RewordActionRegenerate, 0, nil).Once()
+		mockPicker.On("Pick", mock.Anything, 0).Return(ui.RewordActionApply, 0, nil).Once()
+
+		require.NoError(t, service.Execute(context.Background(), "HEAD~1"))
+		assert.Equal(t, "wip\nwip\nfeat: second try", commitSubjects(t, repoPath))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("review cancelled", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		service, mockClient, mockPicker, _ := newTestRewordService(t, repoPath, RewordOptions{})
+		mockClient.On("GenerateCommitMessage", mock.Anything, mock.Anything, mock.Anything).Return("feat: generated", nil)
+		mockPicker.On("Pick", mock.Anything, 0).Return(ui.RewordActionNone, 0, nil).Once()
+
+		require.NoError(t, service.Execute(context.Background(), "HEAD~1"))
+		assert.Equal(t, "wip\nwip\nwip", commitSubjects(t, repoPath))
+	})
+
+	t.Run("failed generation keeps the message", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		service, mockClient, _, _ := newTestRewordService(t, repoPath, RewordOptions{AutoYes: true})
+		mockClient.On("GenerateCommitMessage", mock.Anything, diffWith("login.go"), mock.Anything).Return("", fmt.Errorf("provider down"))
+		mockClient.On("GenerateCommitMessage", mock.Anything, diffWith("logout.go"), mock.Anything).Return("feat: add logout", nil)
+
+		require.NoError(t, service.Execute(context.Background(), "master..HEAD"))
+		assert.Equal(t, "wip\nwip\nfeat: add logout", commitSubjects(t, repoPath))
+	})
+}
+
+func TestSignedRewrites(t *testing.T) {
+	plan := &git.RewritePlan{
+		Chain:  []string{"a", "b", "c", "d"},
+		Signed: []string{"a", "c"},
+	}
+
+	assert.Equal(t, []string{"c"}, signedRewrites(plan, map[string]string{"b": "feat: b", "d": "feat: d"}))
+	assert.Equal(t, []string{"a", "c"}, signedRewrites(plan, map[string]string{"a": "feat: a"}))
+	assert.Empty(t, signedRewrites(plan, map[string]string{"d": "feat: d"}))
+	assert.Empty(t, signedRewrites(plan, nil))
+}
+
+func TestRewordService_checkRewritable(t *testing.T) {
+	t.Run("protected branch", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "master"))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "merge", "-q", "--ff-only", "feature"))
+		service, mockClient, _, _ := newTestRewordService(t, repoPath, RewordOptions{AutoYes: true})
+
+		err := service.Execute(context.Background(), "HEAD~1")
+		assert.ErrorContains(t, err, "branch master is protected")
+		mockClient.AssertNotCalled(t, "GenerateCommitMessage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("pushed commits", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "update-ref", "refs/remotes/origin/feature", "HEAD"))
+		service, _, _, _ := newTestRewordService(t, repoPath, RewordOptions{AutoYes: true})
+
+		err := service.Execute(context.Background(), "master..HEAD")
+		assert.ErrorContains(t, err, "already pushed")
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		repoPath := setupRewordRepo(t)
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "update-ref", "refs/remotes/origin/feature", "HEAD"))
+		service, mockClient, _, _ := newTestRewordService(t, repoPath, RewordOptions{AutoYes: true, Force: true})
+		mockClient.On("GenerateCommitMessage", mock.Anything, mock.Anything, mock.Anything).Return("feat: forced", nil)
+
+		require.NoError(t, service.Execute(context.Background(), "HEAD~1"))
+		assert.Equal(t, "wip\nwip\nfeat: forced", commitSubjects(t, repoPath))
+	})
+}
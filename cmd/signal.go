@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ExitCodeCancelled is the exit status after the user cancelled a command,
+// the same status shells use for a process stopped by Ctrl-C
+const ExitCodeCancelled = 130
+
+// NotifyContext returns a copy of parent that is cancelled on the first Ctrl-C
+// or SIGTERM, so running requests and prompts stop and the command returns.
+// After the first signal the default handling is restored, so a second Ctrl-C
+// terminates the program at once if it doesn't stop in time.
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// IsCancelled reports whether the command failed with err because ctx was
+// cancelled, rather than because of the error itself.
+func IsCancelled(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	return ctx.Err() != nil || errors.Is(err, context.Canceled)
+}
+
+// PrintCancelled tells the user the command was cancelled. It starts on a new
+// line, as the command may have been stopped in the middle of a streamed answer
+// or a prompt.
+func PrintCancelled(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, formatRemindMessage("Operation cancelled"))
+}
+
+// readLine reads a line from reader, returning the error of ctx as soon as it
+// is cancelled instead of waiting for the user to press enter.
+func readLine(ctx context.Context, reader *bufio.Reader) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	// The read can't be interrupted, it's left behind when ctx is cancelled
+	// and ends with the program
+	done := make(chan result, 1)
+	go func() {
+		line, err := reader.ReadString('\n')
+		done <- result{line, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-done:
+		return r.line, r.err
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCancelled(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "no error", ctx: cancelled, err: nil, want: false},
+		{name: "error after cancel", ctx: cancelled, err: errors.New("failed to read answer"), want: true},
+		{name: "wrapped context canceled", ctx: context.Background(), err: fmt.Errorf("request: %w", context.Canceled), want: true},
+		{name: "other error", ctx: context.Background(), err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsCancelled(tt.ctx, tt.err))
+		})
+	}
+}
+
+func TestPrintCancelled(t *testing.T) {
+	var buf bytes.Buffer
+	PrintCancelled(&buf)
+	assert.True(t, strings.HasPrefix(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), "Operation cancelled")
+}
+
+func TestReadLine(t *testing.T) {
+	t.Run("reads a line", func(t *testing.T) {
+		line, err := readLine(context.Background(), bufio.NewReader(strings.NewReader("yes\n")))
+		require.NoError(t, err)
+		assert.Equal(t, "yes\n", line)
+	})
+
+	t.Run("returns when cancelled", func(t *testing.T) {
+		// The pipe is never written to, so the read blocks until the test ends
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		_, err := readLine(ctx, bufio.NewReader(pr))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
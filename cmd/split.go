@@ -0,0 +1,466 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/config"
+	gptcometerrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/internal/git"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// SplitOptions contains the settings of the split command
+type SplitOptions struct {
+	CommonOptions
+	RepoPath   string
+	ConfigPath string
+	AutoYes    bool
+	DryRun     bool
+	NoVerify   bool
+}
+
+// splitVCS is the part of git used by split
+type splitVCS interface {
+	HasStagedChanges(repoPath string) (bool, error)
+	GetSplitChanges(repoPath string, cfgManager config.ManagerInterface) ([]git.SplitChange, error)
+	CommitSplit(repoPath string, changes []git.SplitChange, commits []git.SplitCommit, noVerify bool) ([]string, error)
+}
+
+// SplitService splits the staged changes into several commits, grouped and
+// described by the LLM
+type SplitService struct {
+	vcs        splitVCS
+	commit     *CommitService
+	cfgManager config.ManagerInterface
+	options    SplitOptions
+	editor     TextEditor
+}
+
+// NewSplitService creates a SplitService that uses the provider settings of commit
+func NewSplitService(options SplitOptions) (*SplitService, error) {
+	commitService, err := NewCommitService(CommitOptions{
+		CommonOptions: options.CommonOptions,
+		RepoPath:      options.RepoPath,
+		ConfigPath:    options.ConfigPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	setLedgerCommand(commitService.client, "split")
+
+	return &SplitService{
+		vcs:        &git.GitVCS{},
+		commit:     commitService,
+		cfgManager: commitService.cfgManager,
+		options:    options,
+		editor:     &TerminalEditor{},
+	}, nil
+}
+
+// NewSplitCmd creates and returns a new cobra.Command for the 'split' subcommand.
+// It asks the LLM to group the staged hunks and files into coherent commits with
+// their own messages, shows the plan for approval and editing, and creates the
+// commits in order.
+//
+// The command supports the following flags:
+//   - --yes, -y: Create the commits without asking (bool)
+//   - --dry-run: Print the plan without committing (bool)
+//   - --no-verify: Skip git hooks verification (bool)
+//   - --repo: Repository path (string)
+//   - The advanced API flags shared with commit
+func NewSplitCmd() *cobra.Command {
+	options := SplitOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split the staged changes into several commits",
+		Long: `Group the staged changes into coherent commits, each with its own generated
+message, and create them in order. Modified files are split by hunk.
+If creating any of the commits fails, the branch and the staged changes are restored.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.RepoPath == "" {
+				var err error
+				options.RepoPath, err = os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+			}
+
+			configPath, err := cmd.Root().PersistentFlags().GetString("config")
+			if err != nil {
+				return fmt.Errorf("failed to get config path: %w", err)
+			}
+			options.ConfigPath = configPath
+
+			service, err := NewSplitService(options)
+			if err != nil {
+				return err
+			}
+
+			return service.Execute(cmd.Context())
+		},
+	}
+
+	var generalFlags = pflag.NewFlagSet("General Flag", pflag.ExitOnError)
+	var advancedFlags = pflag.NewFlagSet("Overwrite Flag", pflag.ExitOnError)
+
+	generalFlags.StringVar(&options.RepoPath, "repo", "", "Repository path")
+	generalFlags.BoolVarP(&options.AutoYes, "yes", "y", false, "Create the commits without asking")
+	generalFlags.BoolVar(&options.DryRun, "dry-run", false, "Print the plan and exit without committing")
+	generalFlags.BoolVar(&options.NoVerify, "no-verify", false, "Skip git hooks verification, akin to using 'git commit --no-verify'")
+
+	AddAdvancedAPIFlags(advancedFlags, &options.CommonOptions)
+
+	cmd.Flags().AddFlagSet(generalFlags)
+	cmd.Flags().AddFlagSet(advancedFlags)
+	SetAdvancedHelpFunc(cmd, generalFlags, advancedFlags)
+
+	return cmd
+}
+
+// Execute splits the staged changes into commits, stopping early when ctx is cancelled
+func (s *SplitService) Execute(ctx context.Context) error {
+	hasStagedChanges, err := s.vcs.HasStagedChanges(s.options.RepoPath)
+	if err != nil {
+		return err
+	}
+	if !hasStagedChanges {
+		return gptcometerrors.NoStagedChangesError()
+	}
+
+	changes, err := s.vcs.GetSplitChanges(s.options.RepoPath, s.cfgManager)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return gptcometerrors.NoStagedChangesError()
+	}
+
+	fmt.Printf("Grouping %d staged changes into commits...\n", len(changes))
+	commits, err := s.planCommits(ctx, changes)
+	if err != nil {
+		return err
+	}
+
+	if s.options.DryRun {
+		fmt.Print(formatSplitPlan(commits, changes))
+		return nil
+	}
+
+	if s.options.AutoYes {
+		fmt.Print(formatSplitPlan(commits, changes))
+	} else {
+		commits, err = s.review(ctx, commits, changes)
+		if err != nil {
+			return err
+		}
+		if commits == nil {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	hashes, err := s.vcs.CommitSplit(s.options.RepoPath, changes, commits, s.options.NoVerify)
+	if err != nil {
+		return fmt.Errorf("failed to split the staged changes: %w", err)
+	}
+
+	fmt.Printf("\nSuccessfully created %d commits:\n", len(hashes))
+	for i, hash := range hashes {
+		fmt.Printf("  %s %s\n", git.ShortHash(hash), git.FirstLine(commits[i].Message))
+	}
+	return nil
+}
+
+// planCommits asks the LLM to group the changes into commits, and translates
+// the messages if the output language is not English
+func (s *SplitService) planCommits(ctx context.Context, changes []git.SplitChange) ([]git.SplitCommit, error) {
+	prompt := strings.Replace(s.cfgManager.GetSplitPrompt(), "{{ placeholder }}", formatSplitChanges(changes), 1)
+	resp, err := s.commit.client.Chat(ctx, prompt, nil)
+	if err != nil {
+		return nil, err
+	}
+	answer, err := removeThinkTags(strings.TrimSpace(resp.Content))
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := parseSplitAnswer(answer, changes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range commits {
+		commits[i].Message, err = s.commit.translateCommitMessage(ctx, commits[i].Message)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commits, nil
+}
+
+// review shows the plan until the user accepts it, editing or planning again
+// in between. It returns the accepted commits, or nil if the user cancels.
+func (s *SplitService) review(ctx context.Context, commits []git.SplitCommit, changes []git.SplitChange) ([]git.SplitCommit, error) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print(formatSplitPlan(commits, changes))
+
+		fmt.Print("\nWould you like to create these commits? ([Y]es/[n]o/[r]etry/[e]dit): ")
+		answer, err := readLine(ctx, reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "" {
+			answer = "y"
+		}
+
+		switch answer {
+		case "y", "yes":
+			return commits, nil
+		case "n", "no":
+			return nil, nil
+		case "r", "retry":
+			planned, err := s.planCommits(ctx, changes)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				fmt.Printf("Error in generating: %v\n", err)
+				continue
+			}
+			commits = planned
+		case "e", "edit":
+			edited, err := s.editor.Edit(formatEditableSplitPlan(commits, changes))
+			if err != nil {
+				fmt.Printf("Error editing plan: %v\n", err)
+				continue
+			}
+			parsed, err := parseEditableSplitPlan(edited, changes)
+			if err != nil {
+				fmt.Printf("Keeping the previous plan: %v\n", err)
+				continue
+			}
+			commits = parsed
+		default:
+			fmt.Println("Invalid option, please try again")
+		}
+	}
+}
+
+// formatSplitChanges formats the changes for the split prompt. The contents of
+// ignored and binary files are left out.
+func formatSplitChanges(changes []git.SplitChange) string {
+	var sb strings.Builder
+	for _, change := range changes {
+		switch {
+		case change.Ignored:
+			fmt.Fprintf(&sb, "### Change %d: %s %s (ignored, contents omitted)\n", change.ID, change.Path, describeChange(change))
+		case change.Binary:
+			fmt.Fprintf(&sb, "### Change %d: %s %s\n", change.ID, change.Path, describeChange(change))
+		default:
+			fmt.Fprintf(&sb, "### Change %d: %s\n%s", change.ID, change.Path, change.Diff)
+			if !strings.HasSuffix(change.Diff, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// splitAnswer is the JSON answer to the split prompt
+type splitAnswer struct {
+	Commits []struct {
+		Message string `json:"message"`
+		Changes []int  `json:"changes"`
+	} `json:"commits"`
+}
+
+// parseSplitAnswer parses the answer of the LLM into commits. Unknown changes
+// and changes already in an earlier commit are dropped, and changes the answer
+// leaves out are added to the last commit.
+func parseSplitAnswer(answer string, changes []git.SplitChange) ([]git.SplitCommit, error) {
+	// The JSON may be wrapped in a code block or text
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the answer has no split plan: %s", answer)
+	}
+	var parsed splitAnswer
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse the split plan: %w", err)
+	}
+
+	known := make(map[int]bool, len(changes))
+	for _, change := range changes {
+		known[change.ID] = true
+	}
+	assigned := make(map[int]bool, len(changes))
+	var commits []git.SplitCommit
+	for _, c := range parsed.Commits {
+		commit := git.SplitCommit{Message: strings.TrimSpace(c.Message)}
+		for _, id := range c.Changes {
+			if known[id] && !assigned[id] {
+				commit.Changes = append(commit.Changes, id)
+				assigned[id] = true
+			}
+		}
+		if commit.Message != "" && len(commit.Changes) > 0 {
+			commits = append(commits, commit)
+		}
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("the split plan has no commits")
+	}
+
+	last := &commits[len(commits)-1]
+	for _, change := range changes {
+		if !assigned[change.ID] {
+			last.Changes = append(last.Changes, change.ID)
+		}
+	}
+	return commits, nil
+}
+
+// formatSplitPlan formats the commits for review, followed by the changes that
+// stay staged
+func formatSplitPlan(commits []git.SplitCommit, changes []git.SplitChange) string {
+	byID := make(map[int]git.SplitChange, len(changes))
+	for _, change := range changes {
+		byID[change.ID] = change
+	}
+
+	var sb strings.Builder
+	committed := make(map[int]bool)
+	for i, commit := range commits {
+		fmt.Fprintf(&sb, "\nCommit %d of %d:\n%s\n", i+1, len(commits), formatBoxedMessage(commit.Message))
+		for _, id := range commit.Changes {
+			committed[id] = true
+			fmt.Fprintf(&sb, "  %s\n", formatChangeLine(byID[id]))
+		}
+	}
+
+	var rest []string
+	for _, change := range changes {
+		if !committed[change.ID] {
+			rest = append(rest, "  "+formatChangeLine(change))
+		}
+	}
+	if len(rest) > 0 {
+		fmt.Fprintf(&sb, "\nLeft staged:\n%s\n", strings.Join(rest, "\n"))
+	}
+	return sb.String()
+}
+
+// splitCommitLine starts a commit in the editable plan
+const splitCommitLine = "=== commit"
+
+// splitChangeLine matches a change in the editable plan, like "[3] main.go ..."
+var splitChangeLine = regexp.MustCompile(`^\[(\d+)\]`)
+
+// formatEditableSplitPlan formats the commits to be edited as text, see
+// parseEditableSplitPlan
+func formatEditableSplitPlan(commits []git.SplitCommit, changes []git.SplitChange) string {
+	byID := make(map[int]git.SplitChange, len(changes))
+	for _, change := range changes {
+		byID[change.ID] = change
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Every commit starts with a \"" + splitCommitLine + "\" line, followed by its message and its changes.\n")
+	sb.WriteString("# Move [<number>] lines between commits, reorder or remove commits. Changes in no commit stay staged.\n")
+	for _, commit := range commits {
+		fmt.Fprintf(&sb, "\n%s\n%s\n\n", splitCommitLine, strings.TrimSpace(commit.Message))
+		for _, id := range commit.Changes {
+			sb.WriteString(formatChangeLine(byID[id]) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// parseEditableSplitPlan parses a plan edited as text. Lines starting with '#'
+// are ignored, a "=== commit" line starts a commit, "[<number>]" lines add a
+// change to the commit and the other lines are its message.
+func parseEditableSplitPlan(text string, changes []git.SplitChange) ([]git.SplitCommit, error) {
+	known := make(map[int]bool, len(changes))
+	for _, change := range changes {
+		known[change.ID] = true
+	}
+
+	var commits []git.SplitCommit
+	var messages [][]string
+	assigned := make(map[int]bool)
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			continue
+		case trimmed == splitCommitLine:
+			commits = append(commits, git.SplitCommit{})
+			messages = append(messages, nil)
+			continue
+		}
+
+		if len(commits) == 0 {
+			if trimmed != "" {
+				return nil, fmt.Errorf("%q is not part of a commit", trimmed)
+			}
+			continue
+		}
+		current := len(commits) - 1
+		if match := splitChangeLine.FindStringSubmatch(trimmed); match != nil {
+			id, _ := strconv.Atoi(match[1])
+			if !known[id] {
+				return nil, fmt.Errorf("there is no change [%d]", id)
+			}
+			if assigned[id] {
+				return nil, fmt.Errorf("change [%d] is in more than one commit", id)
+			}
+			assigned[id] = true
+			commits[current].Changes = append(commits[current].Changes, id)
+			continue
+		}
+		messages[current] = append(messages[current], strings.TrimRight(line, " \t"))
+	}
+
+	for i := range commits {
+		commits[i].Message = strings.TrimSpace(strings.Join(messages[i], "\n"))
+		if commits[i].Message == "" {
+			return nil, fmt.Errorf("commit %d has no message", i+1)
+		}
+		if len(commits[i].Changes) == 0 {
+			return nil, fmt.Errorf("commit %d has no changes", i+1)
+		}
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("the plan has no commits")
+	}
+	return commits, nil
+}
+
+// formatChangeLine formats a change as one line, like "[1] main.go @@ -1,3 +1,4 @@"
+func formatChangeLine(change git.SplitChange) string {
+	return fmt.Sprintf("[%d] %s %s", change.ID, change.Path, describeChange(change))
+}
+
+// describeChange describes a change with its hunk range or its kind of file
+// change, without the function context of the hunk header
+func describeChange(change git.SplitChange) string {
+	if !strings.HasPrefix(change.Header, "@@ ") {
+		return "(" + change.Header + ")"
+	}
+	if end := strings.Index(change.Header[3:], " @@"); end >= 0 {
+		return change.Header[:end+6]
+	}
+	return change.Header
+}
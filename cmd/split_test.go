@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/git"
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSplitRepo creates a repository with a commit and stages two new files
+func setupSplitRepo(t *testing.T) string {
+	t.Helper()
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	t.Cleanup(cleanup)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "base.txt"), []byte("base\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "base.txt"))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "commit", "-m", "initial"))
+
+	for _, name := range []string{"login.go", "logout.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(name+"\n"), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", name))
+	}
+	return repoPath
+}
+
+func newTestSplitService(t *testing.T, repoPath string, options SplitOptions) (*SplitService, *MockClient) {
+	t.Helper()
+	configPath, cleanupConfig := setupTempConfig(t)
+	t.Cleanup(cleanupConfig)
+	cfg, err := config.New(configPath)
+	require.NoError(t, err)
+
+	mockClient := new(MockClient)
+	options.RepoPath = repoPath
+
+	return &SplitService{
+		vcs: &git.GitVCS{},
+		commit: &CommitService{
+			vcs:        &git.GitVCS{},
+			client:     mockClient,
+			cfgManager: cfg,
+		},
+		cfgManager: cfg,
+		options:    options,
+		editor:     new(MockTextEditor),
+	}, mockClient
+}
+
+func stagedFiles(t *testing.T, repoPath string) string {
+	t.Helper()
+	cmd := exec.Command("git", "diff", "--staged", "--name-only")
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	require.NoError(t, err)
+	return strings.TrimSpace(string(output))
+}
+
+func TestSplitService_Execute(t *testing.T) {
+	answer := "```json\n" + `{"commits": [{"message": "feat: add logout", "changes": [2]}, {"message": "feat: add login", "changes": [1]}]}` + "\n```"
+
+	t.Run("commits with yes", func(t *testing.T) {
+		repoPath := setupSplitRepo(t)
+		service, mockClient := newTestSplitService(t, repoPath, SplitOptions{AutoYes: true})
+		mockClient.On("Chat", mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "### Change 1: login.go") && strings.Contains(prompt, "### Change 2: logout.go")
+		}), mock.Anything).Return(&types.CompletionResponse{Content: answer}, nil)
+
+		require.NoError(t, service.Execute(context.Background()))
+		assert.Equal(t, "initial\nfeat: add logout\nfeat: add login", commitSubjects(t, repoPath))
+		assert.Empty(t, stagedFiles(t, repoPath))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		repoPath := setupSplitRepo(t)
+		service, mockClient := newTestSplitService(t, repoPath, SplitOptions{DryRun: true})
+		mockClient.On("Chat", mock.Anything, mock.Anything, mock.Anything).Return(&types.CompletionResponse{Content: answer}, nil)
+
+		require.NoError(t, service.Execute(context.Background()))
+		assert.Equal(t, "initial", commitSubjects(t, repoPath))
+		assert.Equal(t, "login.go\nlogout.go", stagedFiles(t, repoPath))
+	})
+
+	t.Run("invalid answer", func(t *testing.T) {
+		repoPath := setupSplitRepo(t)
+		service, mockClient := newTestSplitService(t, repoPath, SplitOptions{AutoYes: true})
+		mockClient.On("Chat", mock.Anything, mock.Anything, mock.Anything).Return(&types.CompletionResponse{Content: "no idea"}, nil)
+
+		err := service.Execute(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no split plan")
+		assert.Equal(t, "login.go\nlogout.go", stagedFiles(t, repoPath))
+	})
+
+	t.Run("no staged changes", func(t *testing.T) {
+		repoPath := setupSplitRepo(t)
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "reset", "-q"))
+		service, _ := newTestSplitService(t, repoPath, SplitOptions{AutoYes: true})
+
+		assert.Error(t, service.Execute(context.Background()))
+	})
+}
+
+func testSplitChanges() []git.SplitChange {
+	return []git.SplitChange{
+		{ID: 1, Path: "main.go", Header: "@@ -1,3 +1,4 @@ func main()", Diff: "@@ -1,3 +1,4 @@ func main()\n+a\n"},
+		{ID: 2, Path: "main.go", Header: "@@ -20,3 +21,4 @@ func run()", Diff: "@@ -20,3 +21,4 @@ func run()\n+b\n"},
+		{ID: 3, Path: "go.sum", Header: "@@ -1 +1 @@", Diff: "@@ -1 +1 @@\n+c\n", Ignored: true},
+		{ID: 4, Path: "logo.png", Header: "new file", Diff: "GIT binary patch\n", Binary: true},
+	}
+}
+
+func TestFormatSplitChanges(t *testing.T) {
+	formatted := formatSplitChanges(testSplitChanges())
+	assert.Contains(t, formatted, "### Change 1: main.go\n@@ -1,3 +1,4 @@ func main()\n+a\n")
+	assert.Contains(t, formatted, "### Change 3: go.sum @@ -1 +1 @@ (ignored, contents omitted)\n")
+	assert.NotContains(t, formatted, "+c")
+	assert.Contains(t, formatted, "### Change 4: logo.png (new file)\n")
+	assert.NotContains(t, formatted, "GIT binary patch")
+}
+
+func TestParseSplitAnswer(t *testing.T) {
+	changes := testSplitChanges()
+
+	t.Run("valid answer", func(t *testing.T) {
+		commits, err := parseSplitAnswer(`{"commits": [{"message": "feat: a", "changes": [1, 3]}, {"message": "fix: b", "changes": [2, 4]}]}`, changes)
+		require.NoError(t, err)
+		assert.Equal(t, []git.SplitCommit{
+			{Message: "feat: a", Changes: []int{1, 3}},
+			{Message: "fix: b", Changes: []int{2, 4}},
+		}, commits)
+	})
+
+	t.Run("unknown, repeated and missing changes", func(t *testing.T) {
+		commits, err := parseSplitAnswer(`Here is the plan: {"commits": [{"message": "feat: a", "changes": [1, 9]}, {"message": "fix: b", "changes": [1, 2]}, {"message": "chore: c", "changes": [1]}]}`, changes)
+		require.NoError(t, err)
+		assert.Equal(t, []git.SplitCommit{
+			{Message: "feat: a", Changes: []int{1}},
+			{Message: "fix: b", Changes: []int{2, 3, 4}},
+		}, commits)
+	})
+
+	t.Run("no commits", func(t *testing.T) {
+		_, err := parseSplitAnswer(`{"commits": []}`, changes)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := parseSplitAnswer(`{"commits": [}`, changes)
+		assert.Error(t, err)
+	})
+}
+
+func TestEditableSplitPlan(t *testing.T) {
+	changes := testSplitChanges()
+	commits := []git.SplitCommit{
+		{Message: "feat: a\n\n- details", Changes: []int{1, 3}},
+		{Message: "fix: b", Changes: []int{2}},
+	}
+
+	text := formatEditableSplitPlan(commits, changes)
+	assert.Contains(t, text, "=== commit\nfeat: a\n\n- details\n\n[1] main.go @@ -1,3 +1,4 @@\n[3] go.sum @@ -1 +1 @@\n")
+	assert.Contains(t, text, "[2] main.go @@ -20,3 +21,4 @@\n")
+
+	parsed, err := parseEditableSplitPlan(text, changes)
+	require.NoError(t, err)
+	assert.Equal(t, commits, parsed)
+
+	t.Run("moved and left out changes", func(t *testing.T) {
+		parsed, err := parseEditableSplitPlan("=== commit\nfix: b\n[2]\n[3] go.sum\n\n=== commit\nfeat: a\n\n[1]\n", changes)
+		require.NoError(t, err)
+		assert.Equal(t, []git.SplitCommit{
+			{Message: "fix: b", Changes: []int{2, 3}},
+			{Message: "feat: a", Changes: []int{1}},
+		}, parsed)
+	})
+
+	for name, text := range map[string]string{
+		"unknown change":     "=== commit\nfeat: a\n[7]\n",
+		"repeated change":    "=== commit\nfeat: a\n[1]\n=== commit\nfix: b\n[1]\n",
+		"no message":         "=== commit\n[1]\n",
+		"no changes":         "=== commit\nfeat: a\n",
+		"text before commit": "feat: a\n=== commit\nfix: b\n[1]\n",
+		"no commits":         "# nothing\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseEditableSplitPlan(text, changes)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFormatSplitPlan(t *testing.T) {
+	plan := formatSplitPlan([]git.SplitCommit{{Message: "feat: a", Changes: []int{1}}}, testSplitChanges())
+	assert.Contains(t, plan, "Commit 1 of 1:")
+	assert.Contains(t, plan, "feat: a")
+	assert.Contains(t, plan, "Left staged:\n  [2] main.go @@ -20,3 +21,4 @@\n  [3] go.sum @@ -1 +1 @@\n  [4] logo.png (new file)\n")
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/belingud/gptcomet/internal/client"
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/logger"
+	"github.com/belingud/gptcomet/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+// UsageOptions contains the settings of the usage report
+type UsageOptions struct {
+	GroupBy string
+	Since   string
+	Command string
+	JSON    bool
+}
+
+// usageReport is the JSON output of the usage command
+type usageReport struct {
+	GroupBy usage.GroupBy   `json:"group_by"`
+	Rows    []usage.Summary `json:"rows"`
+	Total   usage.Summary   `json:"total"`
+}
+
+// newUsageLedger returns the ledger in the config directory, or nil if the
+// config directory can't be found, in which case requests are not recorded.
+func newUsageLedger() *usage.Ledger {
+	path, err := config.GetUsageLedgerPath()
+	if err != nil {
+		logger.Debug("Usage ledger disabled: %v", err)
+		return nil
+	}
+	return usage.NewLedger(path)
+}
+
+// setLedgerCommand makes c record its requests in the usage ledger as part of
+// command, for the commands that build their client with NewCommitService
+func setLedgerCommand(c client.ClientInterface, command string) {
+	if apiClient, ok := c.(*client.Client); ok {
+		apiClient.SetLedger(newUsageLedger(), command)
+	}
+}
+
+// NewUsageCmd creates and returns a new cobra.Command for the 'usage' subcommand.
+// It reads the usage ledger and reports the requests, token usage, latency and
+// estimated cost, aggregated by day, provider or model.
+//
+// The command supports the following flags:
+//   - --by, -b: Group the usage by day, provider or model (string)
+//   - --since: Only include requests on or after the date, as YYYY-MM-DD (string)
+//   - --command: Only include requests of the command, such as commit or review (string)
+//   - --json: Output the report as JSON instead of a table (bool)
+//
+// The estimated cost uses the pricing table of the config file.
+func NewUsageCmd() *cobra.Command {
+	options := UsageOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Show token usage and estimated cost of past requests",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Root().PersistentFlags().GetString("config")
+			if err != nil {
+				return fmt.Errorf("failed to get config path: %w", err)
+			}
+
+			cfgManager, err := config.New(configPath)
+			if err != nil {
+				return err
+			}
+
+			path, err := config.GetUsageLedgerPath()
+			if err != nil {
+				return fmt.Errorf("failed to get usage ledger path: %w", err)
+			}
+
+			records, err := usage.Load(path)
+			if err != nil {
+				return err
+			}
+
+			return runUsageReport(os.Stdout, records, options, cfgManager.GetModelPrice)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.GroupBy, "by", "b", string(usage.GroupByDay), "Group usage by day, provider or model")
+	cmd.Flags().StringVar(&options.Since, "since", "", "Only include requests on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&options.Command, "command", "", "Only include requests of this command, e.g. commit or review")
+	cmd.Flags().BoolVar(&options.JSON, "json", false, "Output the report as JSON")
+
+	return cmd
+}
+
+// runUsageReport filters records by the options, aggregates them and writes the
+// report to w as a table or as JSON.
+func runUsageReport(w io.Writer, records []usage.Record, options UsageOptions, price usage.PriceFunc) error {
+	groupBy := usage.GroupBy(strings.ToLower(options.GroupBy))
+
+	records, err := filterUsageRecords(records, options)
+	if err != nil {
+		return err
+	}
+
+	rows, err := usage.Aggregate(records, groupBy, price)
+	if err != nil {
+		return err
+	}
+	total := usage.Total(records, "total", price)
+
+	if options.JSON {
+		data, err := json.MarshalIndent(usageReport{GroupBy: groupBy, Rows: rows, Total: total}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal usage report: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	if len(records) == 0 {
+		fmt.Fprintln(w, "No usage recorded yet.")
+		return nil
+	}
+	return writeUsageTable(w, groupBy, rows, total)
+}
+
+// filterUsageRecords returns the records matching the since and command options
+func filterUsageRecords(records []usage.Record, options UsageOptions) ([]usage.Record, error) {
+	var since time.Time
+	if options.Since != "" {
+		var err error
+		since, err = time.ParseInLocation("2006-01-02", options.Since, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since date %q, use YYYY-MM-DD: %w", options.Since, err)
+		}
+	}
+
+	filtered := make([]usage.Record, 0, len(records))
+	for _, record := range records {
+		if !since.IsZero() && record.Time.Before(since) {
+			continue
+		}
+		if options.Command != "" && record.Command != options.Command {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return filtered, nil
+}
+
+// writeUsageTable writes rows and their total as an aligned table
+func writeUsageTable(w io.Writer, groupBy usage.GroupBy, rows []usage.Summary, total usage.Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tREQUESTS\tFAILED\tPROMPT\tCACHED\tCOMPLETION\tTOTAL\tAVG LATENCY\tEST. COST\n", strings.ToUpper(string(groupBy)))
+	for _, row := range append(rows, total) {
+		key := row.Key
+		if key == "" {
+			key = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%dms\t$%.4f\n",
+			key, row.Requests, row.Failures,
+			row.Usage.PromptTokens, row.Usage.CachedTokens, row.Usage.CompletionTokens, row.Usage.TotalTokens,
+			row.AvgLatencyMS, row.Cost)
+	}
+	return tw.Flush()
+}
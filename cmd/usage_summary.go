@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/client"
+	"github.com/belingud/gptcomet/internal/config"
+)
+
+// formatUsageSummary formats the token usage of all requests made by c, with the
+// estimated cost of the models that have a price in the pricing table. Every model
+// is priced with its own price, as fallback providers may have answered. The cost
+// is marked as partial when some models have no price. It returns an empty string
+// when the provider reported no usage.
+func formatUsageSummary(c client.ClientInterface, cfgManager config.ManagerInterface) string {
+	usage := c.Usage()
+	if usage.TotalTokens == 0 {
+		return ""
+	}
+
+	byModel := c.UsageByModel()
+	models := make([]string, 0, len(byModel))
+	for model := range byModel {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	var cost float64
+	var priced, unpriced []string
+	for _, model := range models {
+		if price, ok := cfgManager.GetModelPrice(model); ok {
+			cost += price.Cost(byModel[model])
+			priced = append(priced, model)
+		} else {
+			unpriced = append(unpriced, model)
+		}
+	}
+
+	summary := "Token usage> " + usage.String()
+	if len(priced) > 0 {
+		summary += fmt.Sprintf(", estimated cost: $%.6f", cost)
+		if len(unpriced) > 0 {
+			summary += fmt.Sprintf(" (partial, no price for %s)", strings.Join(unpriced, ", "))
+		}
+	}
+	return summary
+}
+
+// printUsageSummary prints the summary of formatUsageSummary, if there is one
+func printUsageSummary(c client.ClientInterface, cfgManager config.ManagerInterface) {
+	if summary := formatUsageSummary(c, cfgManager); summary != "" {
+		fmt.Println(formatRemindMessage(summary))
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatUsageSummary(t *testing.T) {
+	usage := types.Usage{PromptTokens: 1000000, CompletionTokens: 100000, TotalTokens: 1100000}
+	total := types.Usage{PromptTokens: 2000000, CompletionTokens: 200000, TotalTokens: 2200000}
+
+	tests := []struct {
+		name       string
+		usage      types.Usage
+		byModel    map[string]types.Usage
+		setupMocks func(cfg *testutils.MockConfigManager)
+		want       string
+	}{
+		{
+			name:       "no usage",
+			setupMocks: func(cfg *testutils.MockConfigManager) {},
+			want:       "",
+		},
+		{
+			name:    "without price",
+			usage:   usage,
+			byModel: map[string]types.Usage{"gpt-4o": usage},
+			setupMocks: func(cfg *testutils.MockConfigManager) {
+				cfg.On("GetModelPrice", "gpt-4o").Return(types.ModelPrice{}, false)
+			},
+			want: "Token usage> prompt: 1000000, completion: 100000, total: 1100000",
+		},
+		{
+			name:    "with price",
+			usage:   usage,
+			byModel: map[string]types.Usage{"gpt-4o": usage},
+			setupMocks: func(cfg *testutils.MockConfigManager) {
+				cfg.On("GetModelPrice", "gpt-4o").Return(types.ModelPrice{Input: 2.5, Output: 10}, true)
+			},
+			want: "Token usage> prompt: 1000000, completion: 100000, total: 1100000, estimated cost: $3.500000",
+		},
+		{
+			name:    "fallback model with its own price",
+			usage:   total,
+			byModel: map[string]types.Usage{"gpt-4o": usage, "deepseek-chat": usage},
+			setupMocks: func(cfg *testutils.MockConfigManager) {
+				cfg.On("GetModelPrice", "gpt-4o").Return(types.ModelPrice{Input: 2.5, Output: 10}, true)
+				cfg.On("GetModelPrice", "deepseek-chat").Return(types.ModelPrice{Input: 0.5, Output: 1}, true)
+			},
+			want: "Token usage> prompt: 2000000, completion: 200000, total: 2200000, estimated cost: $4.100000",
+		},
+		{
+			name:    "fallback model without price",
+			usage:   total,
+			byModel: map[string]types.Usage{"gpt-4o": usage, "local-model": usage},
+			setupMocks: func(cfg *testutils.MockConfigManager) {
+				cfg.On("GetModelPrice", "gpt-4o").Return(types.ModelPrice{Input: 2.5, Output: 10}, true)
+				cfg.On("GetModelPrice", "local-model").Return(types.ModelPrice{}, false)
+			},
+			want: "Token usage> prompt: 2000000, completion: 200000, total: 2200000, estimated cost: $3.500000 (partial, no price for local-model)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCfg := new(testutils.MockConfigManager)
+			tt.setupMocks(mockCfg)
+
+			got := formatUsageSummary(&MockClient{usage: tt.usage, usageByModel: tt.byModel}, mockCfg)
+
+			assert.Equal(t, tt.want, got)
+			mockCfg.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/belingud/gptcomet/internal/usage"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUsageReport(t *testing.T) {
+	records := []usage.Record{
+		{Time: time.Date(2026, 9, 30, 12, 0, 0, 0, time.Local), Command: "commit", Provider: "openai", Model: "gpt-4o", Usage: types.Usage{TotalTokens: 10}, Success: true},
+		{Time: time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local), Command: "commit", Provider: "openai", Model: "gpt-4o", Usage: types.Usage{PromptTokens: 1000000, TotalTokens: 1000000}, LatencyMS: 120, Success: true},
+		{Time: time.Date(2026, 10, 1, 13, 0, 0, 0, time.Local), Command: "review", Provider: "deepseek", Model: "deepseek-chat", Usage: types.Usage{TotalTokens: 30}, Success: false},
+	}
+	price := func(model string) (types.ModelPrice, bool) {
+		return types.ModelPrice{Input: 2.5, Output: 10}, model == "gpt-4o"
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := runUsageReport(&buf, records, UsageOptions{GroupBy: "Provider", Since: "2026-10-01"}, price)
+		require.NoError(t, err)
+
+		out := buf.String()
+		assert.Contains(t, out, "PROVIDER")
+		assert.Regexp(t, `deepseek\s+1\s+1\s+0\s+0\s+0\s+30\s+0ms\s+\$0\.0000`, out)
+		assert.Regexp(t, `openai\s+1\s+0\s+1000000\s+0\s+0\s+1000000\s+120ms\s+\$2\.5000`, out)
+		assert.Regexp(t, `total\s+2\s+1\s+`, out)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := runUsageReport(&buf, records, UsageOptions{GroupBy: "model", Command: "commit", JSON: true}, price)
+		require.NoError(t, err)
+
+		var report usageReport
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+		assert.Equal(t, usage.GroupByModel, report.GroupBy)
+		require.Len(t, report.Rows, 1)
+		assert.Equal(t, "gpt-4o", report.Rows[0].Key)
+		assert.Equal(t, 2, report.Total.Requests)
+		assert.InDelta(t, 2.5, report.Total.Cost, 1e-6)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := runUsageReport(&buf, nil, UsageOptions{GroupBy: "day"}, price)
+		require.NoError(t, err)
+		assert.Equal(t, "No usage recorded yet.\n", buf.String())
+	})
+
+	t.Run("invalid since", func(t *testing.T) {
+		err := runUsageReport(&bytes.Buffer{}, records, UsageOptions{GroupBy: "day", Since: "yesterday"}, price)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid group", func(t *testing.T) {
+		err := runUsageReport(&bytes.Buffer{}, records, UsageOptions{GroupBy: "week"}, price)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,216 @@
+// Package cache keeps generated responses on disk, so the same prompt sent to
+// the same model again is answered without a new request.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName is the name of the cache directory in the config directory
+const DirName = "cache"
+
+// entryExt is the file extension of cache entries
+const entryExt = ".json"
+
+// entry is a cached response as stored on disk
+type entry struct {
+	Created time.Time `json:"created"`
+	Value   string    `json:"value"`
+}
+
+// Cache stores responses as one file per key in a directory. Entries expire
+// after the TTL, and the oldest entries are removed when the directory grows
+// beyond the size limit.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+	now     func() time.Time
+}
+
+// New returns a cache in dir. A ttl of zero or less keeps entries until they
+// are removed for size, and a maxSize of zero or less doesn't limit the size.
+func New(dir string, ttl time.Duration, maxSize int64) *Cache {
+	return &Cache{dir: dir, ttl: ttl, maxSize: maxSize, now: time.Now}
+}
+
+// Dir returns the directory of the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key returns the cache key of parts, such as the provider, model and prompt
+// of a request. Parts are separated, so ("ab", "c") and ("a", "bc") differ.
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the value cached for key, and false if there is none or it has
+// expired. Expired entries are removed.
+func (c *Cache) Get(key string) (string, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		os.Remove(path)
+		return "", false
+	}
+	if c.expired(e.Created) {
+		os.Remove(path)
+		return "", false
+	}
+	return e.Value, true
+}
+
+// Put caches value for key, then removes expired entries and the oldest
+// entries beyond the size limit.
+func (c *Cache) Put(key string, value string) error {
+	data, err := json.Marshal(entry{Created: c.now(), Value: value})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first, so readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save cache entry: %w", err)
+	}
+
+	return c.prune()
+}
+
+// Clear removes all entries and returns how many were removed. A missing
+// cache directory has no entries.
+func (c *Cache) Clear() (int, error) {
+	files, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, file := range files {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// cacheFile is an entry file in the cache directory
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// entries returns the entry files of the cache directory
+func (c *Cache) entries() ([]cacheFile, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	files := make([]cacheFile, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), entryExt) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{
+			path:    filepath.Join(c.dir, dirEntry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+// prune removes expired entries, then the oldest entries until the cache fits
+// in its size limit. Entries are written once, so their modification time is
+// their creation time.
+func (c *Cache) prune() error {
+	files, err := c.entries()
+	if err != nil {
+		return err
+	}
+
+	kept := files[:0]
+	var total int64
+	for _, file := range files {
+		if c.expired(file.modTime) {
+			os.Remove(file.path)
+			continue
+		}
+		kept = append(kept, file)
+		total += file.size
+	}
+
+	if c.maxSize <= 0 || total <= c.maxSize {
+		return nil
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].modTime.Before(kept[j].modTime)
+	})
+	for _, file := range kept {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		total -= file.size
+	}
+	return nil
+}
+
+// expired reports whether an entry created at created has outlived the TTL
+func (c *Cache) expired(created time.Time) bool {
+	return c.ttl > 0 && c.now().Sub(created) > c.ttl
+}
+
+// path returns the file of the entry for key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+entryExt)
+}
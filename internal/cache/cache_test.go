@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("openai", "gpt-4o", "prompt"), Key("openai", "gpt-4o", "prompt"))
+	assert.NotEqual(t, Key("ab", "c"), Key("a", "bc"))
+	assert.Len(t, Key("x"), 64)
+}
+
+func TestCache_PutAndGet(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), DirName), time.Hour, 0)
+
+	_, ok := c.Get(Key("missing"))
+	assert.False(t, ok)
+
+	require.NoError(t, c.Put(Key("a"), "feat: add cache"))
+	value, ok := c.Get(Key("a"))
+	require.True(t, ok)
+	assert.Equal(t, "feat: add cache", value)
+
+	require.NoError(t, c.Put(Key("a"), "fix: replace entry"))
+	value, ok = c.Get(Key("a"))
+	require.True(t, ok)
+	assert.Equal(t, "fix: replace entry", value)
+}
+
+func TestCache_Expired(t *testing.T) {
+	c := New(t.TempDir(), time.Minute, 0)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Put(Key("a"), "value"))
+
+	c.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, ok := c.Get(Key("a"))
+	assert.False(t, ok)
+
+	_, err := os.Stat(c.path(Key("a")))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCache_CorruptEntry(t *testing.T) {
+	c := New(t.TempDir(), 0, 0)
+	require.NoError(t, os.WriteFile(c.path(Key("a")), []byte("{not json"), 0644))
+
+	_, ok := c.Get(Key("a"))
+	assert.False(t, ok)
+}
+
+func TestCache_PruneBySize(t *testing.T) {
+	dir := t.TempDir()
+	value := strings.Repeat("x", 100)
+
+	c := New(dir, 0, 0)
+	base := time.Now()
+	for i, key := range []string{"old", "middle", "new"} {
+		require.NoError(t, c.Put(Key(key), value))
+		mtime := base.Add(time.Duration(i) * time.Second)
+		require.NoError(t, os.Chtimes(c.path(Key(key)), mtime, mtime))
+	}
+
+	// Each entry is a bit larger than the value, so only two fit in 400 bytes
+	c.maxSize = 400
+	require.NoError(t, c.prune())
+
+	_, ok := c.Get(Key("old"))
+	assert.False(t, ok)
+	_, ok = c.Get(Key("middle"))
+	assert.True(t, ok)
+	_, ok = c.Get(Key("new"))
+	assert.True(t, ok)
+}
+
+func TestCache_Clear(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "missing"), 0, 0)
+
+	removed, err := c.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	require.NoError(t, c.Put(Key("a"), "a"))
+	require.NoError(t, c.Put(Key("b"), "b"))
+
+	removed, err = c.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	_, ok := c.Get(Key("a"))
+	assert.False(t, ok)
+}
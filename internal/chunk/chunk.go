@@ -0,0 +1,148 @@
+package chunk
+
+import (
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/tokenizer"
+)
+
+// EstimateTokens returns a rough, model independent estimate of the number of tokens in text
+func EstimateTokens(text string) int {
+	return tokenizer.Estimate("", text)
+}
+
+// Split splits a unified diff into chunks whose estimated token count stays
+// within budget.
+//
+// Whole files are packed together as long as they fit. A file that exceeds the
+// budget on its own is split by hunk, and every part repeats the file header so
+// it can be understood without the others. A single hunk that still exceeds the
+// budget is cut by lines.
+//
+// Both git ("diff --git") and SVN ("Index:") diffs are supported. A non-positive
+// budget returns the whole diff as a single chunk.
+func Split(diff string, budget int) []string {
+	if strings.TrimSpace(diff) == "" {
+		return nil
+	}
+	if budget <= 0 || EstimateTokens(diff) <= budget {
+		return []string{diff}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, file := range splitFiles(diff) {
+		if EstimateTokens(file) > budget {
+			flush()
+			chunks = append(chunks, splitFile(file, budget)...)
+			continue
+		}
+		if EstimateTokens(current.String())+EstimateTokens(file) > budget {
+			flush()
+		}
+		current.WriteString(file)
+	}
+	flush()
+
+	return chunks
+}
+
+// splitFiles splits a diff into the sections of the individual files
+func splitFiles(diff string) []string {
+	return splitBefore(diff, func(line string) bool {
+		return strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "Index: ")
+	})
+}
+
+// splitFile splits the diff of a single file into parts within budget. Every
+// part starts with the file header, followed by as many hunks as fit.
+func splitFile(file string, budget int) []string {
+	sections := splitBefore(file, func(line string) bool {
+		return strings.HasPrefix(line, "@@")
+	})
+
+	header := ""
+	if len(sections) > 0 && !strings.HasPrefix(sections[0], "@@") {
+		header = sections[0]
+		sections = sections[1:]
+	}
+	hunkBudget := budget - EstimateTokens(header)
+
+	var parts []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			parts = append(parts, header+current.String())
+			current.Reset()
+		}
+	}
+
+	for _, hunk := range sections {
+		if EstimateTokens(hunk) > hunkBudget {
+			flush()
+			for _, piece := range splitLines(hunk, hunkBudget) {
+				parts = append(parts, header+piece)
+			}
+			continue
+		}
+		if EstimateTokens(current.String())+EstimateTokens(hunk) > hunkBudget {
+			flush()
+		}
+		current.WriteString(hunk)
+	}
+	flush()
+
+	if len(parts) == 0 {
+		return splitLines(file, budget)
+	}
+	return parts
+}
+
+// splitLines cuts text at line boundaries into pieces within budget. A single
+// line longer than the budget becomes a piece of its own.
+func splitLines(text string, budget int) []string {
+	var pieces []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if current.Len() > 0 && EstimateTokens(current.String())+EstimateTokens(line) > budget {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
+
+// splitBefore splits text into sections that start at every line for which
+// isStart returns true. Text before the first start line forms its own section.
+func splitBefore(text string, isStart func(line string) bool) []string {
+	var sections []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if isStart(line) && current.Len() > 0 {
+			sections = append(sections, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		sections = append(sections, current.String())
+	}
+	return sections
+}
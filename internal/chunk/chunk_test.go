@@ -0,0 +1,146 @@
+package chunk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const fileA = "diff --git a/a.go b/a.go\nindex 1..2 100644\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-old a\n+new a\n"
+const fileB = "diff --git a/b.go b/b.go\nindex 1..2 100644\n--- a/b.go\n+++ b/b.go\n@@ -1,2 +1,2 @@\n-old b\n+new b\n"
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{strings.Repeat("x", 400), 100},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplit_FitsInBudget(t *testing.T) {
+	diff := fileA + fileB
+	chunks := Split(diff, 1000)
+	if len(chunks) != 1 || chunks[0] != diff {
+		t.Errorf("Split() = %q, want the whole diff", chunks)
+	}
+
+	chunks = Split(diff, 0)
+	if len(chunks) != 1 || chunks[0] != diff {
+		t.Errorf("Split() with zero budget = %q, want the whole diff", chunks)
+	}
+
+	if chunks := Split("  \n", 10); chunks != nil {
+		t.Errorf("Split() of empty diff = %q, want nil", chunks)
+	}
+}
+
+func TestSplit_ByFile(t *testing.T) {
+	budget := EstimateTokens(fileA) + 2
+	chunks := Split(fileA+fileB, budget)
+	if len(chunks) != 2 {
+		t.Fatalf("Split() returned %d chunks, want 2", len(chunks))
+	}
+	if chunks[0] != fileA || chunks[1] != fileB {
+		t.Errorf("Split() = %q, want one chunk per file", chunks)
+	}
+}
+
+func TestSplit_ByHunk(t *testing.T) {
+	header := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n"
+	hunk1 := "@@ -1,2 +1,2 @@ func one()\n-" + strings.Repeat("a", 60) + "\n+" + strings.Repeat("b", 60) + "\n"
+	hunk2 := "@@ -10,2 +10,2 @@ func two()\n-" + strings.Repeat("c", 60) + "\n+" + strings.Repeat("d", 60) + "\n"
+
+	budget := EstimateTokens(header+hunk1) + 2
+	chunks := Split(header+hunk1+hunk2, budget)
+	if len(chunks) != 2 {
+		t.Fatalf("Split() returned %d chunks, want 2", len(chunks))
+	}
+	if chunks[0] != header+hunk1 {
+		t.Errorf("chunks[0] = %q, want %q", chunks[0], header+hunk1)
+	}
+	if chunks[1] != header+hunk2 {
+		t.Errorf("chunks[1] = %q, want %q", chunks[1], header+hunk2)
+	}
+}
+
+func TestSplit_ByLine(t *testing.T) {
+	header := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n"
+	var hunk strings.Builder
+	hunk.WriteString("@@ -0,0 +1,20 @@\n")
+	for i := 0; i < 20; i++ {
+		hunk.WriteString("+" + strings.Repeat("x", 39) + "\n")
+	}
+
+	budget := EstimateTokens(header) + 30
+	chunks := Split(header+hunk.String(), budget)
+	if len(chunks) < 2 {
+		t.Fatalf("Split() returned %d chunks, want the hunk cut by lines", len(chunks))
+	}
+
+	var body strings.Builder
+	for i, chunk := range chunks {
+		if !strings.HasPrefix(chunk, header) {
+			t.Errorf("chunks[%d] does not start with the file header", i)
+		}
+		if EstimateTokens(chunk) > budget {
+			t.Errorf("chunks[%d] has %d tokens, more than the budget of %d", i, EstimateTokens(chunk), budget)
+		}
+		body.WriteString(strings.TrimPrefix(chunk, header))
+	}
+	if body.String() != hunk.String() {
+		t.Errorf("joined chunks lost content:\n%s\nwant:\n%s", body.String(), hunk.String())
+	}
+}
+
+func TestSplit_SVN(t *testing.T) {
+	fileA := "Index: a.go\n===================================================================\n--- a.go\n+++ a.go\n@@ -1 +1 @@\n-old a\n+new a\n"
+	fileB := "Index: b.go\n===================================================================\n--- b.go\n+++ b.go\n@@ -1 +1 @@\n-old b\n+new b\n"
+
+	chunks := Split(fileA+fileB, EstimateTokens(fileA)+2)
+	if len(chunks) != 2 || chunks[0] != fileA || chunks[1] != fileB {
+		t.Errorf("Split() = %q, want one chunk per file", chunks)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var seen []string
+	summary, err := Summarize(fileA+fileB, EstimateTokens(fileA)+2, func(chunk string) (string, error) {
+		seen = append(seen, chunk)
+		return "  summary " + string(rune('A'+len(seen)-1)) + "\n", nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("Summarize() summarized %d chunks, want 2", len(seen))
+	}
+	for _, want := range []string{"split into 2 parts", "Summary of part 1/2:\nsummary A\n", "Summary of part 2/2:\nsummary B\n"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Summarize() = %q, want it to contain %q", summary, want)
+		}
+	}
+}
+
+func TestSummarize_Error(t *testing.T) {
+	calls := 0
+	_, err := Summarize(fileA+fileB, EstimateTokens(fileA)+2, func(chunk string) (string, error) {
+		calls++
+		return "", errors.New("api error")
+	}, nil)
+	if err == nil {
+		t.Fatal("Summarize() error = nil, want error")
+	}
+	if calls != 1 {
+		t.Errorf("Summarize() made %d calls after an error, want 1", calls)
+	}
+}
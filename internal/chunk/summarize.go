@@ -0,0 +1,58 @@
+package chunk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/ui"
+)
+
+// SummarizeFunc summarizes a single chunk of a diff
+type SummarizeFunc func(chunk string) (string, error)
+
+// Summarize is the map stage of the chunking pipeline. It splits the diff into
+// chunks within budget, summarizes them one by one and returns the summaries
+// as a single text that replaces the diff in the final prompt.
+//
+// Every chunk is reported as a stage of progress when progress is not nil.
+func Summarize(diff string, budget int, summarize SummarizeFunc, progress *ui.Progress) (string, error) {
+	chunks := Split(diff, budget)
+
+	stages := make([]string, len(chunks))
+	for i := range chunks {
+		stages[i] = fmt.Sprintf("Summarizing chunk %d/%d", i+1, len(chunks))
+	}
+	if progress != nil {
+		progress.AddStages(stages...)
+	}
+
+	summaries := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		if progress != nil {
+			progress.Start(stages[i])
+		}
+		summary, err := summarize(chunk)
+		if err != nil {
+			if progress != nil {
+				progress.Error(stages[i], err)
+			}
+			return "", err
+		}
+		summaries[i] = strings.TrimSpace(summary)
+		if progress != nil {
+			progress.Complete(stages[i])
+		}
+	}
+
+	return formatSummaries(summaries), nil
+}
+
+// formatSummaries joins the chunk summaries into the text sent in place of the diff
+func formatSummaries(summaries []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The diff is too large to show at once. It was split into %d parts, and each part is summarized below.\n", len(summaries))
+	for i, summary := range summaries {
+		fmt.Fprintf(&b, "\nSummary of part %d/%d:\n%s\n", i+1, len(summaries), summary)
+	}
+	return b.String()
+}
@@ -1,66 +1,66 @@
 package client
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"math"
 	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
-	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/proxy"
 
+	"github.com/belingud/gptcomet/internal/cache"
 	"github.com/belingud/gptcomet/internal/constants"
 	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/internal/llm"
 	"github.com/belingud/gptcomet/internal/logger"
+	"github.com/belingud/gptcomet/internal/tokenizer"
+	"github.com/belingud/gptcomet/internal/usage"
 	"github.com/belingud/gptcomet/pkg/types"
-	"github.com/tidwall/gjson"
 )
 
-// sanitizeURLForLogging returns a sanitized version of the URL for logging purposes.
-// For gemini/vertex providers, it strips query parameters to avoid logging sensitive data like API keys.
-// For other providers, it returns the URL as-is.
-func sanitizeURLForLogging(provider string, rawURL string) string {
-	if provider != "gemini" && provider != "vertex" {
-		return rawURL
-	}
-
-	parsed, err := url.Parse(rawURL)
-	if err != nil {
-		// If parsing fails, return the original URL as a fallback
-		return rawURL
-	}
-
-	// Remove query parameters and fragment
-	parsed.RawQuery = ""
-	parsed.Fragment = ""
-
-	return parsed.String()
-}
-
 type ClientInterface interface {
 	Chat(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, error)
-	TranslateMessage(prompt string, message string, lang string) (string, error)
-	GenerateCommitMessage(diff string, prompt string) (string, error)
-	GenerateReviewComment(diff string, prompt string) (string, error)
-	GenerateReviewCommentStream(diff string, prompt string, callback func(string) error) error
+	TranslateMessage(ctx context.Context, prompt string, message string, lang string) (string, error)
+	GenerateCommitMessage(ctx context.Context, diff string, prompt string) (string, error)
+	GenerateCommitMessageStream(ctx context.Context, diff string, prompt string, callback func(string) error) error
+	GenerateCommitMessages(ctx context.Context, diff string, prompt string, n int) ([]string, error)
+	GenerateReviewComment(ctx context.Context, diff string, prompt string) (string, error)
+	GenerateReviewCommentStream(ctx context.Context, diff string, prompt string, callback func(string) error) error
+	Usage() types.Usage
+	UsageByModel() map[string]types.Usage
 }
 
 // Client represents an LLM client
 type Client struct {
 	config *types.ClientConfig
 	llm    llm.LLM
+
+	// usage adds up the token usage of all requests made by the client
+	usage   types.Usage
+	usageMu sync.Mutex
+
+	// ledger records every request of command, if set
+	ledger  *usage.Ledger
+	command string
+
+	// fallbacks are tried in order when the provider fails
+	fallbacks []*Client
+
+	// cache answers repeated prompts without a new request, if set
+	cache *cache.Cache
+
+	// httpClient is created once and reused by all requests
+	httpClient *http.Client
+	httpErr    error
+	httpOnce   sync.Once
 }
 
 // New creates a new client with the given config
@@ -85,21 +85,79 @@ func New(config *types.ClientConfig) (*Client, error) {
 	}, nil
 }
 
-// Chat sends a chat message to the LLM provider with retry logic
+// NewWithFallbacks creates a new client with the given config, which moves on to
+// the fallback providers in order when the provider is down, rate limited or
+// rejects the API key.
+func NewWithFallbacks(config *types.ClientConfig, fallbacks []*types.ClientConfig) (*Client, error) {
+	c, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fallbackConfig := range fallbacks {
+		fallback, err := New(fallbackConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.fallbacks = append(c.fallbacks, fallback)
+	}
+	return c, nil
+}
+
+// Chat sends a chat message to the LLM provider with retry logic.
+// The message is sent as a user turn after the history, which may hold
+// system, user and assistant messages from earlier turns.
+//
+// When the provider fails and fallback providers are configured, the message
+// is sent to them in order until one of them answers.
 func (c *Client) Chat(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, error) {
+	resp, _, err := c.chatWithFallback(ctx, message, history)
+	return resp, err
+}
+
+// chatWithFallback sends a chat message like Chat, and also returns the client
+// of the provider that answered
+func (c *Client) chatWithFallback(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, *Client, error) {
+	resp, err := c.chat(ctx, message, history)
+	answered := c
+	for _, fallback := range c.fallbacks {
+		if !shouldFallback(err) {
+			break
+		}
+		logger.Warn("Provider %s failed, falling back to %s", c.config.Provider, fallback.config.Provider)
+		resp, err = fallback.chat(ctx, message, history)
+		answered = fallback
+		if err == nil {
+			logger.Info("Answered by fallback provider: %s, model: %s", fallback.config.Provider, fallback.config.Model)
+		}
+	}
+	return resp, answered, err
+}
+
+// chat sends a chat message to the provider of the client, without fallback
+func (c *Client) chat(ctx context.Context, message string, history []types.Message) (resp *types.CompletionResponse, err error) {
 	client, err := c.getClient()
 	if err != nil {
 		logger.Error("Get client failed: %v", err)
 		return nil, err
 	}
 
+	messages := buildMessages(message, history)
+	if err := c.checkContextWindow(messages); err != nil {
+		return nil, err
+	}
+	ctx, finish := c.trackRequest(ctx)
+	defer func() { finish(err) }()
+
 	logger.Debug("Using proxy: %s", c.config.Proxy)
 
 	var lastErr error
+	attempts := 0
 	maxRetries := c.config.Retries
 
 	for i := 0; i < maxRetries; i++ {
-		content, err := c.llm.MakeRequest(ctx, client, message, false)
+		attempts++
+		content, err := c.llm.MakeRequest(ctx, client, messages, false)
 		if err == nil {
 			logger.Debug("Request succeeded after %d retries", i)
 			return &types.CompletionResponse{
@@ -111,37 +169,342 @@ func (c *Client) Chat(ctx context.Context, message string, history []types.Messa
 		lastErr = err
 		logger.Warn("Request failed (attempt %d/%d): %v", i+1, maxRetries, err)
 
-		// Don't retry on context cancellation or deadline exceeded
-		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// Don't retry once the request is cancelled, e.g. by Ctrl-C
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
 			break
 		}
 
-		// Exponential backoff with jitter
-		if i < maxRetries {
-			delay := time.Duration(float64(constants.BaseRetryDelay) * math.Pow(2, float64(i)))
-			jitter := time.Duration(rand.Int63n(int64(float64(delay) * constants.MaxJitterPercent)))
-			sleepDuration := delay + jitter
+		// Failures such as a rejected API key or a bad request won't succeed on retry
+		if !isRetryable(err) {
+			return nil, err
+		}
 
-			logger.Info("Retrying in %v...", sleepDuration)
-			time.Sleep(sleepDuration)
+		if i == maxRetries-1 {
+			break
+		}
+
+		sleepDuration := retryDelay(i, err)
+		if sleepDuration > constants.MaxRetryAfter {
+			logger.Warn("Provider asked to retry after %v, giving up", sleepDuration)
+			break
+		}
+		logger.Info("Retrying in %v...", sleepDuration)
+		if err := sleepContext(ctx, sleepDuration); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil, gptErrors.RequestRetryError(maxRetries, lastErr)
+	return nil, gptErrors.RequestRetryError(attempts, lastErr)
+}
+
+// sleepContext waits for d, returning the error of ctx early if it is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isRetryable reports whether a failed request may succeed when sent again.
+// Responses with a 4xx status are not retried, except for timeouts, conflicts
+// and rate limits; errors without a status, such as network errors, are retried.
+func isRetryable(err error) bool {
+	status, ok := gptErrors.StatusCode(err)
+	if !ok {
+		return true
+	}
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return status < 400 || status >= 500
+}
+
+// retryDelay returns how long to wait before the next attempt after the given
+// attempt failed with err. The Retry-After header of the response is honored,
+// otherwise the delay grows exponentially with jitter.
+func retryDelay(attempt int, err error) time.Duration {
+	if wait, ok := gptErrors.RetryAfter(err); ok {
+		return wait
+	}
+
+	delay := time.Duration(float64(constants.BaseRetryDelay) * math.Pow(2, float64(attempt)))
+	jitter := time.Duration(rand.Int63n(int64(float64(delay) * constants.MaxJitterPercent)))
+	return delay + jitter
+}
+
+// addUsage adds the token usage of a response to the total of the client
+func (c *Client) addUsage(usage types.Usage) {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	c.usage.Add(usage)
+}
+
+// SetLedger makes the client record every request in ledger as part of command.
+// A nil ledger disables recording.
+func (c *Client) SetLedger(ledger *usage.Ledger, command string) {
+	c.ledger = ledger
+	c.command = command
+	for _, fallback := range c.fallbacks {
+		fallback.SetLedger(ledger, command)
+	}
+}
+
+// SetCache makes the client answer commit messages, review comments and
+// translations from cache when the same prompt was sent to the same provider
+// and model before. A nil cache disables caching.
+func (c *Client) SetCache(cache *cache.Cache) {
+	c.cache = cache
+}
+
+type refreshCacheKey struct{}
+
+// WithRefreshCache returns a copy of ctx whose requests are sent even when an
+// answer is cached, such as when the user asks for another message. The new
+// answer replaces the cached one.
+func WithRefreshCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshCacheKey{}, true)
+}
+
+// cachedChat sends prompt to the provider and returns the trimmed answer. When
+// the client has a cache, an answer cached for the same kind of request,
+// provider, model and prompt is returned without sending a request, and new
+// answers are cached under the provider and model that answered. The answers
+// of the fallback providers are looked up after the one of the provider of the
+// client, in the order of the chain, so a failing provider isn't paid for
+// twice through its fallback.
+func (c *Client) cachedChat(ctx context.Context, kind string, prompt string) (string, error) {
+	if c.cache == nil {
+		return c.chatContent(ctx, prompt)
+	}
+
+	if refresh, _ := ctx.Value(refreshCacheKey{}).(bool); !refresh {
+		for _, client := range append([]*Client{c}, c.fallbacks...) {
+			key := cache.Key(kind, client.config.Provider, client.config.Model, prompt)
+			if content, ok := c.cache.Get(key); ok {
+				logger.Debug("Using cached %s response of %s", kind, client.config.Model)
+				return content, nil
+			}
+		}
+	}
+
+	resp, answered, err := c.chatWithFallback(ctx, prompt, nil)
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(resp.Content)
+	key := cache.Key(kind, answered.config.Provider, answered.config.Model, prompt)
+	if err := c.cache.Put(key, content); err != nil {
+		logger.Warn("Failed to cache response: %v", err)
+	}
+	return content, nil
+}
+
+// chatContent sends prompt to the provider and returns the trimmed answer
+func (c *Client) chatContent(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Chat(ctx, prompt, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(resp.Content), nil
+}
+
+// shouldFallback reports whether a failed request should be sent to the next
+// provider: after all retries failed, when the API key is rejected, or when
+// the provider has a server error.
+func shouldFallback(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if gptErrors.HasTitle(err, gptErrors.ErrTitleRequestRetry) || gptErrors.HasTitle(err, gptErrors.ErrTitleAPIAuth) {
+		return true
+	}
+	if status, ok := gptErrors.StatusCode(err); ok {
+		return status == http.StatusUnauthorized || status == http.StatusForbidden || status >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// trackRequest returns a copy of ctx that adds the token usage of responses to
+// the total of the client, and a function to call with the result of the request
+// when it's done, which records the usage, latency and outcome in the ledger.
+func (c *Client) trackRequest(ctx context.Context) (context.Context, func(err error)) {
+	start := time.Now()
+	var requestUsage types.Usage
+	var mu sync.Mutex
+
+	ctx = llm.WithUsageRecorder(ctx, func(u types.Usage) {
+		c.addUsage(u)
+		mu.Lock()
+		defer mu.Unlock()
+		requestUsage.Add(u)
+	})
+
+	finish := func(err error) {
+		if c.ledger == nil {
+			return
+		}
+		mu.Lock()
+		record := usage.Record{
+			Time:      start,
+			Command:   c.command,
+			Provider:  c.config.Provider,
+			Model:     c.config.Model,
+			Usage:     requestUsage,
+			LatencyMS: time.Since(start).Milliseconds(),
+			Success:   err == nil,
+		}
+		mu.Unlock()
+		if err != nil {
+			record.Error = err.Error()
+		}
+		if err := c.ledger.Append(record); err != nil {
+			logger.Warn("Failed to record usage: %v", err)
+		}
+	}
+	return ctx, finish
+}
+
+// Usage returns the token usage of all requests made by the client so far,
+// including retries, translations, summaries and fallback providers.
+func (c *Client) Usage() types.Usage {
+	c.usageMu.Lock()
+	total := c.usage
+	c.usageMu.Unlock()
+
+	for _, fallback := range c.fallbacks {
+		total.Add(fallback.Usage())
+	}
+	return total
+}
+
+// UsageByModel returns the token usage of Usage by the model that spent it, so
+// that the requests answered by a fallback provider are priced with its model.
+// Models without usage are left out.
+func (c *Client) UsageByModel() map[string]types.Usage {
+	byModel := make(map[string]types.Usage)
+	c.usageMu.Lock()
+	if c.usage != (types.Usage{}) {
+		byModel[c.config.Model] = c.usage
+	}
+	c.usageMu.Unlock()
+
+	for _, fallback := range c.fallbacks {
+		for model, usage := range fallback.UsageByModel() {
+			total := byModel[model]
+			total.Add(usage)
+			byModel[model] = total
+		}
+	}
+	return byModel
+}
+
+// checkContextWindow estimates the prompt tokens of messages and checks that they
+// fit in the context window of the model, together with the tokens reserved for the
+// answer. The context window is taken from the config or from the known windows of
+// well-known models; models with an unknown window are not checked.
+//
+// When the prompt doesn't fit, a ContextWindowExceededError is returned, unless the
+// context guard is "warn", which only logs a warning, or "off".
+func (c *Client) checkContextWindow(messages []types.Message) error {
+	if c.config.ContextGuard == types.ContextGuardOff {
+		return nil
+	}
+
+	window := c.config.ContextWindow
+	if window <= 0 {
+		window = tokenizer.ContextWindow(c.config.Model)
+	}
+	if window <= 0 {
+		return nil
+	}
+
+	promptTokens := tokenizer.EstimateMessages(c.config.Model, messages)
+	logger.Debug("Estimated prompt tokens: %d, context window of %s: %d", promptTokens, c.config.Model, window)
+	if promptTokens+c.config.MaxTokens <= window {
+		return nil
+	}
+
+	if c.config.ContextGuard == types.ContextGuardWarn {
+		logger.Warn("Prompt is about %d tokens, it may not fit in the context window of %s (%d tokens)", promptTokens, c.config.Model, window)
+		return nil
+	}
+	return gptErrors.ContextWindowExceededError(c.config.Provider, c.config.Model, promptTokens, c.config.MaxTokens, window)
+}
+
+// buildMessages appends message as a user turn to a copy of history
+func buildMessages(message string, history []types.Message) []types.Message {
+	messages := make([]types.Message, 0, len(history)+1)
+	messages = append(messages, history...)
+	return append(messages, types.Message{
+		Role:    types.RoleUser,
+		Content: message,
+	})
+}
+
+// newDialer creates a net.Dialer that gives up connecting after the connect
+// timeout of the configuration
+func (c *Client) newDialer() *net.Dialer {
+	connectTimeout := time.Duration(c.config.ConnectTimeout) * time.Second
+	if connectTimeout <= 0 {
+		connectTimeout = constants.DefaultConnectTimeout
+	}
+	return &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: constants.KeepAlive,
+	}
+}
+
+// newTransport creates an http.Transport with the connection pool, connect timeout
+// and compression settings of the configuration. HTTP/2 is attempted for TLS
+// connections, also when a custom dialer is used for a SOCKS5 proxy.
+func (c *Client) newTransport() *http.Transport {
+	maxIdleConns := c.config.MaxIdleConns
+	if maxIdleConns <= 0 {
+		maxIdleConns = constants.MaxIdleConns
+	}
+	maxIdleConnsPerHost := c.config.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = constants.MaxIdleConnsPerHost
+	}
+	idleConnTimeout := time.Duration(c.config.IdleConnTimeout) * time.Second
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = constants.IdleConnTimeout
+	}
+	disableCompression := constants.DisableCompression
+	if c.config.DisableCompression != nil {
+		disableCompression = *c.config.DisableCompression
+	}
+
+	return &http.Transport{
+		DialContext:         c.newDialer().DialContext,
+		MaxIdleConns:        maxIdleConns,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+		IdleConnTimeout:     idleConnTimeout,
+		TLSHandshakeTimeout: constants.TLSHandshakeTimeout,
+		DisableCompression:  disableCompression,
+		ForceAttemptHTTP2:   true,
+	}
 }
 
 // createProxyTransport creates an http.Transport with proxy settings based on the configuration
 func (c *Client) createProxyTransport() (*http.Transport, error) {
 	logger.Debug("Starting proxy configuration with URL: %s", c.config.Proxy)
 
+	transport := c.newTransport()
+
 	// Return default transport if no proxy configured
 	if c.config.Proxy == "" {
 		logger.Debug("No proxy configured, using direct connection")
-		return &http.Transport{
-			MaxIdleConns:       constants.MaxIdleConns,
-			IdleConnTimeout:    constants.IdleConnTimeout,
-			DisableCompression: constants.DisableCompression,
-		}, nil
+		return transport, nil
 	}
 
 	logger.Info("Using proxy: %s", c.config.Proxy)
@@ -154,12 +517,7 @@ func (c *Client) createProxyTransport() (*http.Transport, error) {
 	switch proxyURL.Scheme {
 	case constants.ProxySchemeHTTP, constants.ProxySchemeHTTPS:
 		logger.Debug("Configuring HTTP/HTTPS proxy: %s", proxyURL.String())
-		transport := &http.Transport{
-			Proxy:              http.ProxyURL(proxyURL),
-			MaxIdleConns:       constants.MaxIdleConns,
-			IdleConnTimeout:    constants.IdleConnTimeout,
-			DisableCompression: constants.DisableCompression,
-		}
+		transport.Proxy = http.ProxyURL(proxyURL)
 
 		// Add proxy authentication if provided
 		if proxyURL.User != nil {
@@ -192,90 +550,141 @@ func (c *Client) createProxyTransport() (*http.Transport, error) {
 		}
 
 		// Create SOCKS5 dialer
-		dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, proxy.Direct)
+		dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, c.newDialer())
 		if err != nil {
 			return nil, gptErrors.ProxyConfigurationError(err)
 		}
 
-		return &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				logger.Debug("Attempting SOCKS5 connection to: %s", addr)
-				return dialer.Dial(network, addr)
-			},
-			MaxIdleConns:       constants.MaxIdleConns,
-			IdleConnTimeout:    constants.IdleConnTimeout,
-			DisableCompression: constants.DisableCompression,
-		}, nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			logger.Debug("Attempting SOCKS5 connection to: %s", addr)
+			if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+				return contextDialer.DialContext(ctx, network, addr)
+			}
+			return dialer.Dial(network, addr)
+		}
+		return transport, nil
 
 	default:
 		return nil, gptErrors.UnsupportedProxySchemeError(proxyURL.Scheme)
 	}
 }
 
-// getClient returns an HTTP client configured with proxy settings if specified
+// getClient returns the HTTP client of the client, configured with proxy settings
+// if specified. It is created on first use and shared by all requests, so pooled
+// connections are reused across retries, translations and chunk summaries.
 func (c *Client) getClient() (*http.Client, error) {
-	// Create a transport with proxy if configured
-	transport, err := c.createProxyTransport()
+	c.httpOnce.Do(func() {
+		// Create a transport with proxy if configured
+		transport, err := c.createProxyTransport()
+		if err != nil {
+			logger.Error("Create proxy transport failed: %v", err)
+			c.httpErr = err
+			return
+		}
+
+		// Create a client with the configured transport and timeout
+		c.httpClient = &http.Client{
+			Transport: transport,
+			Timeout:   time.Duration(c.config.Timeout) * time.Second,
+		}
+	})
+
+	return c.httpClient, c.httpErr
+}
+
+// ListModels returns the models available from the provider, through the same
+// HTTP client as the requests, so proxy and timeout settings apply
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	client, err := c.getClient()
 	if err != nil {
-		logger.Error("Create proxy transport failed: %v", err)
 		return nil, err
 	}
-
-	// Create a client with the configured transport and timeout
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(c.config.Timeout) * time.Second,
-	}
-
-	return client, nil
+	return llm.ListModels(ctx, client, c.llm)
 }
 
 // TranslateMessage translates the given message to the specified language
-func (c *Client) TranslateMessage(prompt string, message string, lang string) (string, error) {
+func (c *Client) TranslateMessage(ctx context.Context, prompt string, message string, lang string) (string, error) {
 	// Format the prompt
 	formattedPrompt := fmt.Sprintf(prompt, message, lang)
 
 	// Send the request
-	resp, err := c.Chat(context.Background(), formattedPrompt, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return strings.TrimSpace(resp.Content), nil
+	return c.cachedChat(ctx, "translation", formattedPrompt)
 }
 
 // GenerateCommitMessage generates a commit message for the given diff
-func (c *Client) GenerateCommitMessage(diff string, prompt string) (string, error) {
+func (c *Client) GenerateCommitMessage(ctx context.Context, diff string, prompt string) (string, error) {
 	formattedPrompt := strings.Replace(prompt, "{{ placeholder }}", diff, 1)
 
 	// Send the request
-	resp, err := c.Chat(context.Background(), formattedPrompt, nil)
-	if err != nil {
-		return "", err
+	return c.cachedChat(ctx, "commit", formattedPrompt)
+}
+
+// GenerateCommitMessageStream generates a commit message for the given diff,
+// passing each chunk to callback as it arrives
+func (c *Client) GenerateCommitMessageStream(ctx context.Context, diff string, prompt string, callback func(string) error) error {
+	formattedPrompt := strings.Replace(prompt, "{{ placeholder }}", diff, 1)
+
+	// Send the request
+	return c.Stream(ctx, formattedPrompt, nil, func(resp *types.CompletionResponse) error {
+		return callback(resp.Content)
+	})
+}
+
+// GenerateCommitMessages generates n alternative commit messages for the given diff.
+// The requests are sent in parallel, and failed requests are skipped as long as
+// at least one of them succeeds. Candidates are never answered from cache, as
+// they would all be the same.
+func (c *Client) GenerateCommitMessages(ctx context.Context, diff string, prompt string, n int) ([]string, error) {
+	if n < 1 {
+		n = 1
+	}
+	formattedPrompt := strings.Replace(prompt, "{{ placeholder }}", diff, 1)
+	results := make([]string, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = c.chatContent(ctx, formattedPrompt)
+		}(i)
+	}
+	wg.Wait()
+
+	messages := make([]string, 0, n)
+	var firstErr error
+	for i := range results {
+		if errs[i] != nil {
+			logger.Warn("Candidate %d/%d failed: %v", i+1, n, errs[i])
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		messages = append(messages, results[i])
+	}
+	if len(messages) == 0 {
+		return nil, firstErr
 	}
 
-	return strings.TrimSpace(resp.Content), nil
+	return messages, nil
 }
 
 // GenerateReviewComment generates a review comment for the given diff
-func (c *Client) GenerateReviewComment(diff string, prompt string) (string, error) {
+func (c *Client) GenerateReviewComment(ctx context.Context, diff string, prompt string) (string, error) {
 	formattedPrompt := strings.Replace(prompt, "{{ placeholder }}", diff, 1)
 
 	// Send the request
-	resp, err := c.Chat(context.Background(), formattedPrompt, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return strings.TrimSpace(resp.Content), nil
+	return c.cachedChat(ctx, "review", formattedPrompt)
 }
 
 // GenerateReviewCommentStream generates a review comment for the given diff
-func (c *Client) GenerateReviewCommentStream(diff string, prompt string, callback func(string) error) error {
+func (c *Client) GenerateReviewCommentStream(ctx context.Context, diff string, prompt string, callback func(string) error) error {
 	formattedPrompt := strings.Replace(prompt, "{{ placeholder }}", diff, 1)
 
 	// Send the request
-	return c.Stream(context.Background(), formattedPrompt, func(resp *types.CompletionResponse) error {
+	return c.Stream(ctx, formattedPrompt, nil, func(resp *types.CompletionResponse) error {
 		return callback(resp.Content)
 	})
 }
@@ -286,170 +695,60 @@ func (c *Client) GenerateReviewCommentStream(diff string, prompt string, callbac
 // Parameters:
 //   - ctx: the context for the request, used for cancellation and timeouts
 //   - message: the message to send to the LLM provider
+//   - history: earlier messages of the conversation, sent before message
 //   - callback: a function that processes the CompletionResponse received from the LLM provider
 //
+// When the provider fails before the first chunk, the request is sent to the fallback
+// providers in order until one of them answers.
+//
 // Returns an error if the client cannot be obtained, the request fails, or the callback function
 // returns an error.
-func (c *Client) Stream(ctx context.Context, message string, callback func(*types.CompletionResponse) error) error {
-	client, err := c.getClient()
-	if err != nil {
-		return err
+func (c *Client) Stream(ctx context.Context, message string, history []types.Message, callback func(*types.CompletionResponse) error) error {
+	// Only fall back before anything was passed to callback, to not repeat output
+	started := false
+	tracked := func(resp *types.CompletionResponse) error {
+		started = true
+		return callback(resp)
 	}
 
-	// Format the message for the provider
-	payload, err := c.llm.FormatMessages(message)
-	if err != nil {
-		return gptErrors.MessageFormattingError(err)
-	}
-
-	// Check if provider has a custom streaming URL method (e.g., Gemini)
-	// If it does, use that URL instead of adding "stream" to the payload
-	url := ""
-	if buildStreamURLMethod := reflect.ValueOf(c.llm).MethodByName("BuildStreamURL"); buildStreamURLMethod.IsValid() {
-		// Provider has custom streaming URL (e.g., Gemini uses :streamGenerateContent)
-		results := buildStreamURLMethod.Call(nil)
-		if len(results) > 0 && results[0].Kind() == reflect.String {
-			url = results[0].String()
-			logger.Debug("Using provider's custom streaming URL")
+	err := c.stream(ctx, message, history, tracked)
+	for _, fallback := range c.fallbacks {
+		if started || !shouldFallback(err) {
+			break
 		}
-	} else {
-		// Provider doesn't have custom streaming URL, use standard URL and add "stream" param
-		url = c.llm.BuildURL()
-		if payloadMap, ok := payload.(map[string]interface{}); ok {
-			payloadMap["stream"] = true
+		logger.Warn("Provider %s failed, falling back to %s", c.config.Provider, fallback.config.Provider)
+		err = fallback.stream(ctx, message, history, tracked)
+		if err == nil {
+			logger.Info("Answered by fallback provider: %s, model: %s", fallback.config.Provider, fallback.config.Model)
 		}
 	}
+	return err
+}
 
-	// Marshal the payload
-	reqBody, err := json.Marshal(payload)
-	if err != nil {
-		return gptErrors.RequestMarshalingError(err)
-	}
-
-	logger.Debug("Request URL: %s", sanitizeURLForLogging(c.config.Provider, url))
-	headers := c.llm.BuildHeaders()
-
-	// Create the request
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
-	if err != nil {
-		return gptErrors.RequestCreationError(err)
-	}
-
-	// Set the headers
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-
-	logger.Info("Sending streaming request to %s...", c.llm.Name())
-
-	// Send the request
-	resp, err := client.Do(req)
+// stream sends a streaming request to the provider of the client, without fallback
+func (c *Client) stream(ctx context.Context, message string, history []types.Message, callback func(*types.CompletionResponse) error) (err error) {
+	client, err := c.getClient()
 	if err != nil {
-		return gptErrors.RequestExecutionError(err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	// Check the response status
-	if resp.StatusCode != constants.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return gptErrors.APIStatusError(resp.StatusCode, string(respBody), nil)
+	messages := buildMessages(message, history)
+	if err := c.checkContextWindow(messages); err != nil {
+		return err
 	}
+	ctx, finish := c.trackRequest(ctx)
+	defer func() { finish(err) }()
 
-	logger.Debug("Request succeeded, processing streaming response")
-
-	// Process the streaming response
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Skip empty lines and SSE comments
-		if line == "" || strings.HasPrefix(line, ":") {
-			continue
-		}
-
-		// Check for data prefix - handle both "data: " and "data:" formats (SSE)
-		// If line doesn't start with "data:", treat it as NDJSON (Ollama format)
-		var data string
-		if strings.HasPrefix(line, constants.SSEDataPrefix) {
-			// "data: " format (with space)
-			data = strings.TrimPrefix(line, constants.SSEDataPrefix)
-		} else if strings.HasPrefix(line, "data:") {
-			// "data:" format (without space) - also valid per SSE spec
-			data = strings.TrimPrefix(line, "data:")
-		} else {
-			// Not SSE format, treat as direct JSON (NDJSON format like Ollama)
-			data = line
-		}
-
-		// Check for [DONE] message
-		if data == constants.SSEDone {
-			// Send a newline before breaking to avoid % prompt appearing right after output
-			callback(&types.CompletionResponse{
-				Content: "\n",
-				Raw:     make(map[string]interface{}),
-			})
-			break
-		}
-
-		// Parse the JSON data
-		var streamResp map[string]interface{}
-		if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
-			continue
-		}
-
-		// Check for Ollama-specific "done" flag (NDJSON format)
-		if done, ok := streamResp["done"].(bool); ok && done {
-			logger.Debug("Ollama stream finished (done: true)")
-			// Send a newline before breaking to avoid % prompt appearing right after output
-			callback(&types.CompletionResponse{
-				Content: "\n",
-				Raw:     make(map[string]interface{}),
-			})
-			break
-		}
-
-		// Extract the content using the provider's configured stream answer path
-		streamAnswerPath := c.config.StreamAnswerPath
-		if streamAnswerPath == "" {
-			// Fallback: try to convert non-streaming path to streaming path
-			// For OpenAI-compatible: "choices.0.message.content" -> "choices.0.delta.content"
-			streamAnswerPath = c.config.AnswerPath
-			if strings.Contains(streamAnswerPath, "message") {
-				streamAnswerPath = strings.Replace(streamAnswerPath, "message", "delta", 1)
-			} else {
-				// If conversion fails, use OpenAI default
-				streamAnswerPath = "choices.0.delta.content"
-			}
-		}
-
-		// Use gjson to extract the content
-		content := gjson.GetBytes([]byte(data), streamAnswerPath).String()
-
-		// For Ollama: if response is empty, try thinking field (some models output thinking process)
-		if content == "" && c.config.Provider == "ollama" {
-			content = gjson.GetBytes([]byte(data), "thinking").String()
-		}
-
-		// Skip empty content
-		if content == "" {
-			logger.Debug("Empty content from stream chunk")
-			continue
-		}
-
-		// Call the callback with the content
-		err := callback(&types.CompletionResponse{
-			Content: content,
-			Raw:     streamResp,
-		})
-		if err != nil {
-			return gptErrors.CallbackError(err)
-		}
+	if err := c.llm.MakeStreamRequest(ctx, client, messages, callback); err != nil {
+		return err
 	}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		return gptErrors.WrapError(err, "Response Reading Failed", "Error occurred while reading streaming response")
+	// Send a newline after the stream ends to avoid % prompt appearing right after output
+	if err := callback(&types.CompletionResponse{
+		Content: "\n",
+		Raw:     make(map[string]interface{}),
+	}); err != nil {
+		return gptErrors.CallbackError(err)
 	}
-
 	return nil
 }
@@ -4,11 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/belingud/gptcomet/internal/cache"
+	"github.com/belingud/gptcomet/internal/constants"
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/internal/llm"
+	"github.com/belingud/gptcomet/internal/usage"
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/stretchr/testify/assert"
@@ -17,12 +26,13 @@ import (
 
 // MockLLM implements the LLM interface for testing
 type MockLLM struct {
-	makeRequestFunc       func(ctx context.Context, client *http.Client, message string, stream bool) (string, error)
+	makeRequestFunc       func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error)
+	makeStreamRequestFunc func(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error
 	buildHeadersFunc      func() map[string]string
 	buildURLFunc          func() string
-	formatMessagesFunc    func(message string) (interface{}, error)
+	formatMessagesFunc    func(messages []types.Message) (interface{}, error)
 	getRequiredConfigFunc func() map[string]config.ConfigRequirement
-	getUsageFunc          func(data []byte) (string, error)
+	getUsageFunc          func(data []byte) (*types.Usage, error)
 	parseResponseFunc     func(response []byte) (string, error)
 	name                  string
 }
@@ -31,8 +41,15 @@ func (m *MockLLM) Name() string {
 	return m.name
 }
 
-func (m *MockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return m.makeRequestFunc(ctx, client, message, stream)
+func (m *MockLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return m.makeRequestFunc(ctx, client, messages, stream)
+}
+
+func (m *MockLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error {
+	if m.makeStreamRequestFunc != nil {
+		return m.makeStreamRequestFunc(ctx, client, messages, callback)
+	}
+	return nil
 }
 
 func (m *MockLLM) BuildHeaders() map[string]string {
@@ -49,11 +66,11 @@ func (m *MockLLM) BuildURL() string {
 	return ""
 }
 
-func (m *MockLLM) FormatMessages(message string) (interface{}, error) {
+func (m *MockLLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	if m.formatMessagesFunc != nil {
-		return m.formatMessagesFunc(message)
+		return m.formatMessagesFunc(messages)
 	}
-	return message, nil
+	return messages, nil
 }
 
 func (m *MockLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
@@ -63,11 +80,11 @@ func (m *MockLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 	return map[string]config.ConfigRequirement{}
 }
 
-func (m *MockLLM) GetUsage(data []byte) (string, error) {
+func (m *MockLLM) GetUsage(data []byte) (*types.Usage, error) {
 	if m.getUsageFunc != nil {
 		return m.getUsageFunc(data)
 	}
-	return "", nil
+	return nil, nil
 }
 
 func (m *MockLLM) ParseResponse(response []byte) (string, error) {
@@ -136,7 +153,7 @@ func TestCreateProxyTransport(t *testing.T) {
 
 func TestChat(t *testing.T) {
 	mockLLM := &MockLLM{
-		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 			if !stream {
 				return "mock response", nil
 			}
@@ -155,10 +172,153 @@ func TestChat(t *testing.T) {
 	assert.Equal(t, "mock response", resp.Content)
 }
 
+func TestChatWithHistory(t *testing.T) {
+	history := []types.Message{
+		{Role: types.RoleSystem, Content: "You write commit messages"},
+		{Role: types.RoleUser, Content: "diff"},
+		{Role: types.RoleAssistant, Content: "feat: first try"},
+	}
+
+	var got []types.Message
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+			got = messages
+			return "feat: second try", nil
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, Retries: 3},
+		llm:    mockLLM,
+	}
+
+	resp, err := client.Chat(context.Background(), "make it shorter", history)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: second try", resp.Content)
+	require.Len(t, got, 4)
+	assert.Equal(t, history, got[:3])
+	assert.Equal(t, types.Message{Role: types.RoleUser, Content: "make it shorter"}, got[3])
+	assert.Len(t, history, 3, "history must not be modified")
+}
+
+func TestChatContextWindowGuard(t *testing.T) {
+	longMessage := strings.Repeat("a", 4000)
+
+	tests := []struct {
+		name      string
+		config    types.ClientConfig
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "fits",
+			config:    types.ClientConfig{Model: "gpt-4o", MaxTokens: 1024, Retries: 1},
+			wantCalls: 1,
+		},
+		{
+			name:    "exceeds configured window",
+			config:  types.ClientConfig{Model: "gpt-4o", MaxTokens: 100, ContextWindow: 500, Retries: 1},
+			wantErr: true,
+		},
+		{
+			name:    "exceeds window with reserved answer tokens",
+			config:  types.ClientConfig{Model: "gpt-4", MaxTokens: 8000, Retries: 1},
+			wantErr: true,
+		},
+		{
+			name:      "warn only",
+			config:    types.ClientConfig{Model: "gpt-4o", ContextWindow: 500, ContextGuard: types.ContextGuardWarn, Retries: 1},
+			wantCalls: 1,
+		},
+		{
+			name:      "guard off",
+			config:    types.ClientConfig{Model: "gpt-4o", ContextWindow: 500, ContextGuard: types.ContextGuardOff, Retries: 1},
+			wantCalls: 1,
+		},
+		{
+			name:      "unknown model",
+			config:    types.ClientConfig{Model: "my-local-model", MaxTokens: 1000000, Retries: 1},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mockLLM := &MockLLM{
+				makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+					calls++
+					return "mock response", nil
+				},
+				name: "mock",
+			}
+
+			config := tt.config
+			client := &Client{config: &config, llm: mockLLM}
+
+			_, err := client.Chat(context.Background(), longMessage, nil)
+			if tt.wantErr {
+				var gptErr *gptErrors.GPTCometError
+				require.ErrorAs(t, err, &gptErr)
+				assert.Equal(t, gptErrors.ErrTitleContextWindow, gptErr.Title)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestGenerateCommitMessages(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			if calls == 1 {
+				return "", errors.New("permanent error")
+			}
+			return fmt.Sprintf("feat: candidate %d", calls), nil
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, Retries: 1},
+		llm:    mockLLM,
+	}
+
+	messages, err := client.GenerateCommitMessages(context.Background(), "diff", "prompt {{ placeholder }}", 3)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, 3, calls)
+}
+
+func TestGenerateCommitMessagesAllFail(t *testing.T) {
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+			return "", errors.New("permanent error")
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, Retries: 1},
+		llm:    mockLLM,
+	}
+
+	messages, err := client.GenerateCommitMessages(context.Background(), "diff", "prompt", 2)
+	assert.Error(t, err)
+	assert.Nil(t, messages)
+}
+
 func TestChatWithRetries(t *testing.T) {
 	var attempt int
 	mockLLM := &MockLLM{
-		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 			if attempt < 2 {
 				attempt++
 				return "", errors.New("temporary error")
@@ -182,7 +342,7 @@ func TestChatWithRetries(t *testing.T) {
 func TestChatErrorAfterRetries(t *testing.T) {
 	var attempt int
 	mockLLM := &MockLLM{
-		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 			attempt++
 			return "", errors.New("persistent error")
 		},
@@ -202,7 +362,7 @@ func TestChatErrorAfterRetries(t *testing.T) {
 
 func TestChatContextCancellation(t *testing.T) {
 	mockLLM := &MockLLM{
-		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 			return "", context.Canceled
 		},
 		name: "mock",
@@ -223,7 +383,7 @@ func TestChatContextCancellation(t *testing.T) {
 
 func TestTranslateMessage(t *testing.T) {
 	mockLLM := &MockLLM{
-		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 			return "translated message", nil
 		},
 		name: "mock",
@@ -234,7 +394,7 @@ func TestTranslateMessage(t *testing.T) {
 		llm:    mockLLM,
 	}
 
-	_, err := client.TranslateMessage("translate to %s: %s", "hello", "fr")
+	_, err := client.TranslateMessage(context.Background(), "translate to %s: %s", "hello", "fr")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "after 0 attempts")
 }
@@ -266,7 +426,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockLLM := &MockLLM{
-				makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+				makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 					return tt.mockResponse, tt.mockError
 				},
 				name: "mock",
@@ -277,7 +437,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 				llm:    mockLLM,
 			}
 
-			msg, err := client.GenerateCommitMessage("diff", "generate commit message for: %s")
+			msg, err := client.GenerateCommitMessage(context.Background(), "diff", "generate commit message for: %s")
 			if tt.wantError {
 				assert.Error(t, err)
 				return
@@ -323,7 +483,7 @@ func TestGenerateReviewComment(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var attempt int
 			mockLLM := &MockLLM{
-				makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+				makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 					if tt.name == "retry success" && attempt < 2 {
 						attempt++
 						return "", errors.New("temporary error")
@@ -338,7 +498,7 @@ func TestGenerateReviewComment(t *testing.T) {
 				llm:    mockLLM,
 			}
 
-			comment, err := client.GenerateReviewComment("diff", "generate review comment for: %s")
+			comment, err := client.GenerateReviewComment(context.Background(), "diff", "generate review comment for: %s")
 			if tt.wantError {
 				assert.Error(t, err)
 				if tt.name == "retry success" {
@@ -352,175 +512,572 @@ func TestGenerateReviewComment(t *testing.T) {
 	}
 }
 
-// MockRoundTripper is a testable implementation of http.RoundTripper
-type MockRoundTripper struct {
-	RoundTripFunc func(req *http.Request) (*http.Response, error)
-}
-
-// RoundTrip implements the http.RoundTripper interface
-func (m *MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	if m.RoundTripFunc != nil {
-		return m.RoundTripFunc(req)
-	}
-	return nil, errors.New("mock RoundTrip function not implemented")
-}
-
 func TestStream(t *testing.T) {
-	// Define test cases
 	tests := []struct {
 		name           string
-		setupMock      func() *MockLLM
-		message        string
+		proxy          string
+		streamFunc     func(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error
 		expectedChunks []string
 		wantErr        bool
 		errorContains  string
 	}{
 		{
 			name: "Success",
-			setupMock: func() *MockLLM {
-				return &MockLLM{
-					name: "mock",
-					formatMessagesFunc: func(message string) (interface{}, error) {
-						return message, nil
-					},
-					buildURLFunc: func() string {
-						return "https://api.example.com/chat"
-					},
-					buildHeadersFunc: func() map[string]string {
-						return map[string]string{"Authorization": "Bearer test-token"}
-					},
-					makeRequestFunc: func(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-						return "", nil // Not needed for streaming
-					},
+			streamFunc: func(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error {
+				for _, chunk := range []string{"hello", " world", "!"} {
+					if err := callback(&types.CompletionResponse{Content: chunk, Raw: map[string]interface{}{}}); err != nil {
+						return err
+					}
 				}
+				return nil
 			},
-			message:        "test message",
 			expectedChunks: []string{"hello", " world", "!", "\n"},
-			wantErr:        false,
 		},
 		{
-			name: "FormatMessages Error",
-			setupMock: func() *MockLLM {
-				return &MockLLM{
-					name: "mock",
-					formatMessagesFunc: func(message string) (interface{}, error) {
-						return nil, errors.New("format error")
-					},
-				}
+			name: "Provider Error",
+			streamFunc: func(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error {
+				return gptErrors.MessageFormattingError(errors.New("format error"))
 			},
-			message:        "test message",
-			expectedChunks: nil,
-			wantErr:        true,
-			errorContains:  "Message Formatting Failed",
+			wantErr:       true,
+			errorContains: "Message Formatting Failed",
 		},
 		{
-			name: "GetClient Error",
-			setupMock: func() *MockLLM {
-				return &MockLLM{
-					name: "mock",
-					formatMessagesFunc: func(message string) (interface{}, error) {
-						return message, nil
-					},
-				}
-			},
-			message:        "test message",
-			expectedChunks: nil,
-			wantErr:        true,
-			errorContains:  "Unsupported Proxy Scheme", // Fix error message expectation
+			name:          "GetClient Error",
+			proxy:         "invalid://proxy.example.com",
+			wantErr:       true,
+			errorContains: "Unsupported Proxy Scheme",
 		},
 	}
 
-	// Run tests
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Setup mock LLM
-			mockLLM := tt.setupMock()
-
-			// Create client with appropriate configuration
-			var client *Client
-			if tt.name == "GetClient Error" {
-				// Configure invalid proxy to trigger getClient error
-				client = &Client{
-					config: &types.ClientConfig{Proxy: "invalid://proxy.example.com"},
-					llm:    mockLLM,
-				}
-			} else {
-				client = &Client{
-					config: &types.ClientConfig{},
-					llm:    mockLLM,
-				}
+			client := &Client{
+				config: &types.ClientConfig{Proxy: tt.proxy},
+				llm: &MockLLM{
+					name:                  "mock",
+					makeStreamRequestFunc: tt.streamFunc,
+				},
 			}
 
-			// For success case, create a test server that returns our mock response
-			var testServer *httptest.Server
-			if tt.name == "Success" {
-				// Create a test server that returns our mock streaming response
-				testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					// Verify request properties
-					assert.Equal(t, "POST", r.Method)
-					assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
-
-					// Set necessary headers for SSE
-					w.Header().Set("Content-Type", "text/event-stream")
-					w.Header().Set("Cache-Control", "no-cache")
-					w.Header().Set("Connection", "keep-alive")
-					w.WriteHeader(http.StatusOK)
-
-					// Write the chunks as SSE events in the format expected by the Stream method
-					flusher, ok := w.(http.Flusher)
-					if !ok {
-						t.Fatal("ResponseWriter does not implement Flusher")
-					}
+			var receivedChunks []string
+			err := client.Stream(context.Background(), "test message", nil, func(resp *types.CompletionResponse) error {
+				assert.NotNil(t, resp.Raw)
+				receivedChunks = append(receivedChunks, resp.Content)
+				return nil
+			})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
 
-					// Send each chunk as a properly formatted SSE event
-					for _, chunk := range tt.expectedChunks {
-						// Create a compact JSON structure expected by the Stream method
-						responseData := fmt.Sprintf(`{"choices":[{"delta":{"content":"%s"}}]}`, chunk)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedChunks, receivedChunks)
+		})
+	}
+}
 
-						// Write as SSE data event
-						fmt.Fprintf(w, "data: %s\n\n", responseData)
-						flusher.Flush()
-					}
+func TestClientUsageAccumulates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices": [{"message": {"content": "feat: usage"}}], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15, "prompt_tokens_details": {"cached_tokens": 4}}}`)
+	}))
+	defer server.Close()
 
-					// Send a [DONE] event to signal completion
-					fmt.Fprintf(w, "data: [DONE]\n\n")
-					flusher.Flush()
-				}))
-				defer testServer.Close()
+	config := &types.ClientConfig{
+		APIBase:    server.URL,
+		Model:      "gpt-4o",
+		AnswerPath: "choices.0.message.content",
+		Retries:    1,
+		Timeout:    5,
+	}
+	client := &Client{config: config, llm: llm.NewOpenAILLM(config)}
 
-				// Override the buildURL method to use our test server instead
-				mockLLM.buildURLFunc = func() string {
-					return testServer.URL
-				}
+	for i := 0; i < 2; i++ {
+		_, err := client.Chat(context.Background(), "hello", nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, types.Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30, CachedTokens: 8}, client.Usage())
+}
+
+func TestClientUsageByModel(t *testing.T) {
+	newClient := func(provider, model string, promptTokens int) *Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"choices": [{"message": {"content": "answer"}}], "usage": {"prompt_tokens": %d, "completion_tokens": 5, "total_tokens": %d}}`, promptTokens, promptTokens+5)
+		}))
+		t.Cleanup(server.Close)
+		config := &types.ClientConfig{
+			APIBase:    server.URL,
+			Provider:   provider,
+			Model:      model,
+			AnswerPath: "choices.0.message.content",
+			Retries:    1,
+			Timeout:    5,
+		}
+		return &Client{config: config, llm: llm.NewOpenAILLM(config)}
+	}
+	fallback := newClient("deepseek", "deepseek-chat", 100)
+	primary := newClient("openai", "gpt-4o", 10)
+	primary.fallbacks = []*Client{fallback}
+
+	assert.Empty(t, primary.UsageByModel())
+
+	_, err := primary.Chat(context.Background(), "hello", nil)
+	require.NoError(t, err)
+	_, err = fallback.Chat(context.Background(), "hello", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]types.Usage{
+		"gpt-4o":        {PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		"deepseek-chat": {PromptTokens: 100, CompletionTokens: 5, TotalTokens: 105},
+	}, primary.UsageByModel())
+	assert.Equal(t, types.Usage{PromptTokens: 110, CompletionTokens: 10, TotalTokens: 120}, primary.Usage())
+}
+
+func TestClientRecordsRequestsInLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), usage.LedgerFileName)
+
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+			if messages[0].Content == "fail" {
+				return "", errors.New("api error")
 			}
+			return "mock response", nil
+		},
+		name: "mock",
+	}
+	client := &Client{config: &types.ClientConfig{Provider: "openai", Model: "gpt-4o", Retries: 1}, llm: mockLLM}
+	client.SetLedger(usage.NewLedger(path), "commit")
 
-			// Call Stream with our test callback
-			var receivedChunks []string
-			err := client.Stream(context.Background(), tt.message, func(resp *types.CompletionResponse) error {
-				// Record the received chunk
-				if tt.name == "Success" {
-					// Check that Raw is populated
-					assert.NotNil(t, resp.Raw)
+	_, err := client.Chat(context.Background(), "hello", nil)
+	require.NoError(t, err)
+	_, err = client.Chat(context.Background(), "fail", nil)
+	require.Error(t, err)
+
+	records, err := usage.Load(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "commit", records[0].Command)
+	assert.Equal(t, "openai", records[0].Provider)
+	assert.Equal(t, "gpt-4o", records[0].Model)
+	assert.True(t, records[0].Success)
+	assert.False(t, records[1].Success)
+	assert.NotEmpty(t, records[1].Error)
+}
+
+func TestClientCachesResponses(t *testing.T) {
+	var calls int
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+			calls++
+			return fmt.Sprintf("answer %d", calls), nil
+		},
+		name: "mock",
+	}
+	client := &Client{config: &types.ClientConfig{Provider: "openai", Model: "gpt-4o", Retries: 1}, llm: mockLLM}
+	client.SetCache(cache.New(t.TempDir(), time.Hour, 0))
+
+	msg, err := client.GenerateCommitMessage(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "answer 1", msg)
+
+	// The same diff and prompt is answered from cache
+	msg, err = client.GenerateCommitMessage(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "answer 1", msg)
+	assert.Equal(t, 1, calls)
+
+	// Another diff, kind of request or model is sent again
+	msg, err = client.GenerateCommitMessage(context.Background(), "other diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "answer 2", msg)
+	msg, err = client.GenerateReviewComment(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "answer 3", msg)
+	client.config.Model = "gpt-4o-mini"
+	msg, err = client.GenerateCommitMessage(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "answer 4", msg)
+
+	// A refresh sends the request again and caches the new answer
+	msg, err = client.GenerateCommitMessage(WithRefreshCache(context.Background()), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "answer 5", msg)
+	msg, err = client.GenerateCommitMessage(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "answer 5", msg)
+
+	// Candidates are never cached
+	messages, err := client.GenerateCommitMessages(context.Background(), "diff", "commit {{ placeholder }}", 2)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, 7, calls)
+}
+
+func TestClientCachesFallbackResponses(t *testing.T) {
+	var primaryErr error = &gptErrors.HTTPStatusError{StatusCode: 503, Body: "unavailable"}
+	primaryCalls, fallbackCalls := 0, 0
+	responses := cache.New(t.TempDir(), time.Hour, 0)
+	fallback := &Client{
+		config: &types.ClientConfig{Provider: "deepseek", Model: "deepseek-chat", Retries: 1},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+				fallbackCalls++
+				return "fallback response", nil
+			},
+			name: "fallback",
+		},
+		cache: responses,
+	}
+	client := &Client{
+		config: &types.ClientConfig{Provider: "openai", Model: "gpt-4o", Retries: 1},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+				primaryCalls++
+				if primaryErr != nil {
+					return "", primaryErr
 				}
+				return "primary response", nil
+			},
+			name: "primary",
+		},
+		fallbacks: []*Client{fallback},
+		cache:     responses,
+	}
 
-				// Add to received chunks
-				receivedChunks = append(receivedChunks, resp.Content)
+	msg, err := client.GenerateCommitMessage(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "fallback response", msg)
 
-				return nil
-			})
+	// While the primary provider is down, the same diff is answered from cache
+	msg, err = client.GenerateCommitMessage(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "fallback response", msg)
+	assert.Equal(t, 1, primaryCalls)
+	assert.Equal(t, 1, fallbackCalls)
+
+	// It is cached for the fallback provider that answered
+	msg, err = fallback.GenerateCommitMessage(context.Background(), "diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "fallback response", msg)
+	assert.Equal(t, 1, fallbackCalls)
+
+	// An answer of the primary provider comes first once it works again
+	primaryErr = nil
+	msg, err = client.GenerateCommitMessage(context.Background(), "other diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "primary response", msg)
+	msg, err = client.GenerateCommitMessage(context.Background(), "other diff", "commit {{ placeholder }}")
+	require.NoError(t, err)
+	assert.Equal(t, "primary response", msg)
+	assert.Equal(t, 2, primaryCalls)
+}
+
+func TestChatFallback(t *testing.T) {
+	failing := func(err error) *MockLLM {
+		return &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+				return "", err
+			},
+			name: "failing",
+		}
+	}
+	answering := func(content string) *MockLLM {
+		return &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+				return content, nil
+			},
+			name: "answering",
+		}
+	}
+
+	tests := []struct {
+		name         string
+		primary      *MockLLM
+		config       types.ClientConfig
+		wantContent  string
+		wantFallback bool
+		wantErr      bool
+	}{
+		{
+			name:         "server error",
+			primary:      failing(&gptErrors.HTTPStatusError{StatusCode: 503, Body: "unavailable"}),
+			config:       types.ClientConfig{Provider: "openai", Retries: 1},
+			wantContent:  "fallback response",
+			wantFallback: true,
+		},
+		{
+			name:         "auth error",
+			primary:      failing(gptErrors.APIAuthenticationError("openai", nil)),
+			config:       types.ClientConfig{Provider: "openai", Retries: 1},
+			wantContent:  "fallback response",
+			wantFallback: true,
+		},
+		{
+			name:        "primary answers",
+			primary:     answering("primary response"),
+			config:      types.ClientConfig{Provider: "openai", Retries: 1},
+			wantContent: "primary response",
+		},
+		{
+			name:    "context window exceeded",
+			primary: failing(errors.New("not called")),
+			config:  types.ClientConfig{Provider: "openai", Model: "gpt-4o", ContextWindow: 10, Retries: 1},
+			wantErr: true,
+		},
+	}
 
-			// Check if error matches expectation
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallbackCalls := 0
+			fallback := &Client{
+				config: &types.ClientConfig{Provider: "deepseek", Retries: 1},
+				llm: &MockLLM{
+					makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+						fallbackCalls++
+						return "fallback response", nil
+					},
+					name: "fallback",
+				},
+			}
+			config := tt.config
+			client := &Client{config: &config, llm: tt.primary, fallbacks: []*Client{fallback}}
+
+			resp, err := client.Chat(context.Background(), strings.Repeat("a", 100), nil)
 			if tt.wantErr {
-				assert.Error(t, err)
-				if tt.errorContains != "" {
-					assert.Contains(t, err.Error(), tt.errorContains)
-				}
+				require.Error(t, err)
+				assert.Zero(t, fallbackCalls)
 				return
 			}
-
-			// If successful, check that all expected chunks were received
 			require.NoError(t, err)
-			assert.Equal(t, tt.expectedChunks, receivedChunks)
+			assert.Equal(t, tt.wantContent, resp.Content)
+			if tt.wantFallback {
+				assert.Equal(t, 1, fallbackCalls)
+			} else {
+				assert.Zero(t, fallbackCalls)
+			}
+		})
+	}
+}
+
+func TestStreamFallback(t *testing.T) {
+	newClient := func(primaryChunks []string) (*Client, *int) {
+		fallbackCalls := 0
+		primary := &MockLLM{
+			makeStreamRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error {
+				for _, chunk := range primaryChunks {
+					if err := callback(&types.CompletionResponse{Content: chunk}); err != nil {
+						return err
+					}
+				}
+				return gptErrors.APIStatusError(502, "bad gateway", &gptErrors.HTTPStatusError{StatusCode: 502, Body: "bad gateway"})
+			},
+		}
+		fallback := &Client{
+			config: &types.ClientConfig{Provider: "deepseek"},
+			llm: &MockLLM{
+				makeStreamRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error {
+					fallbackCalls++
+					return callback(&types.CompletionResponse{Content: "fallback"})
+				},
+			},
+		}
+		return &Client{config: &types.ClientConfig{Provider: "openai"}, llm: primary, fallbacks: []*Client{fallback}}, &fallbackCalls
+	}
+
+	t.Run("fails before first chunk", func(t *testing.T) {
+		client, fallbackCalls := newClient(nil)
+		var output strings.Builder
+		err := client.Stream(context.Background(), "hello", nil, func(resp *types.CompletionResponse) error {
+			output.WriteString(resp.Content)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, *fallbackCalls)
+		assert.Equal(t, "fallback\n", output.String())
+	})
+
+	t.Run("fails after first chunk", func(t *testing.T) {
+		client, fallbackCalls := newClient([]string{"partial"})
+		err := client.Stream(context.Background(), "hello", nil, func(resp *types.CompletionResponse) error {
+			return nil
+		})
+		require.Error(t, err)
+		assert.Zero(t, *fallbackCalls)
+	})
+}
+
+func TestChatRetryPolicy(t *testing.T) {
+	statusError := func(status int, retryAfter string) error {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return gptErrors.ResponseStatusError("openai", status, header, "error")
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+		wantTitle string
+	}{
+		{
+			name:      "bad request is not retried",
+			err:       statusError(400, ""),
+			wantCalls: 1,
+			wantTitle: gptErrors.ErrTitleAPIStatusError,
+		},
+		{
+			name:      "unauthorized is not retried",
+			err:       statusError(401, ""),
+			wantCalls: 1,
+			wantTitle: gptErrors.ErrTitleAPIAuth,
+		},
+		{
+			name:      "server error is retried",
+			err:       statusError(503, "0"),
+			wantCalls: 3,
+			wantTitle: gptErrors.ErrTitleRequestRetry,
+		},
+		{
+			name:      "rate limit is retried after Retry-After",
+			err:       statusError(429, "0"),
+			wantCalls: 3,
+			wantTitle: gptErrors.ErrTitleRequestRetry,
+		},
+		{
+			name:      "too long Retry-After gives up",
+			err:       statusError(429, "3600"),
+			wantCalls: 1,
+			wantTitle: gptErrors.ErrTitleRequestRetry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mockLLM := &MockLLM{
+				makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+					calls++
+					return "", tt.err
+				},
+				name: "mock",
+			}
+			client := &Client{config: &types.ClientConfig{Provider: "openai", Retries: 3}, llm: mockLLM}
+
+			_, err := client.Chat(context.Background(), "hello", nil)
+
+			var gptErr *gptErrors.GPTCometError
+			require.ErrorAs(t, err, &gptErr)
+			assert.Equal(t, tt.wantTitle, gptErr.Title)
+			assert.Equal(t, tt.wantCalls, calls)
 		})
 	}
 }
+
+func TestChatCancelledDuringRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+			calls++
+			// Cancel while the client waits a minute before the next attempt
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return "", gptErrors.ResponseStatusError("openai", http.StatusTooManyRequests, http.Header{"Retry-After": []string{"60"}}, "error")
+		},
+		name: "mock",
+	}
+	fallbackCalls := 0
+	fallback := &Client{config: &types.ClientConfig{Provider: "fallback", Retries: 1}, llm: &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+			fallbackCalls++
+			return "feat: fallback", nil
+		},
+		name: "fallback",
+	}}
+	client := &Client{config: &types.ClientConfig{Provider: "openai", Retries: 3}, llm: mockLLM, fallbacks: []*Client{fallback}}
+
+	start := time.Now()
+	_, err := client.Chat(ctx, "hello", nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, 1, calls)
+	assert.Zero(t, fallbackCalls, "a cancelled request must not fall back")
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		client := &Client{config: &types.ClientConfig{}}
+		transport := client.newTransport()
+
+		assert.Equal(t, constants.MaxIdleConns, transport.MaxIdleConns)
+		assert.Equal(t, constants.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, constants.IdleConnTimeout, transport.IdleConnTimeout)
+		assert.Equal(t, constants.DisableCompression, transport.DisableCompression)
+		assert.True(t, transport.ForceAttemptHTTP2)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		disable := false
+		client := &Client{config: &types.ClientConfig{
+			MaxIdleConns:        20,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     30,
+			DisableCompression:  &disable,
+		}}
+		transport := client.newTransport()
+
+		assert.Equal(t, 20, transport.MaxIdleConns)
+		assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+		assert.False(t, transport.DisableCompression)
+	})
+}
+
+func TestGetClientIsReused(t *testing.T) {
+	client := &Client{config: &types.ClientConfig{Timeout: 10}}
+
+	first, err := client.getClient()
+	require.NoError(t, err)
+	second, err := client.getClient()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	invalid := &Client{config: &types.ClientConfig{Proxy: "invalid://proxy.example.com"}}
+	_, err = invalid.getClient()
+	assert.Error(t, err)
+	_, err = invalid.getClient()
+	assert.Error(t, err, "the error of the first call should be kept")
+}
+
+func TestChatReusesConnections(t *testing.T) {
+	var mu sync.Mutex
+	connections := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices": [{"message": {"content": "feat: pooled"}}]}`)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			connections++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	config := &types.ClientConfig{APIBase: server.URL, AnswerPath: "choices.0.message.content", Retries: 1, Timeout: 5}
+	client := &Client{config: config, llm: llm.NewOpenAILLM(config)}
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Chat(context.Background(), "hello", nil)
+		require.NoError(t, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, connections)
+}
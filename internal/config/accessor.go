@@ -1,10 +1,13 @@
 package config
 
 import (
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/belingud/gptcomet/pkg/config/defaults"
+	"github.com/belingud/gptcomet/pkg/types"
 )
 
 // Get returns the value associated with the given key. The key is split
@@ -98,6 +101,15 @@ func (m *Manager) SetNestedValue(keys []string, value interface{}) {
 //   - output.rich_template
 //   - output.translate_title
 //   - console.verbose
+//   - chunk.enabled
+//   - chunk.token_budget
+//   - cache.enabled
+//   - cache.ttl
+//   - cache.max_size_mb
+//   - pricing
+//   - fallback_providers
+//   - custom_providers
+//   - protected_branches
 //   - <provider>.api_base
 //   - <provider>.api_key
 //   - <provider>.model
@@ -110,9 +122,26 @@ func (m *Manager) SetNestedValue(keys []string, value interface{}) {
 //   - <provider>.extra_headers
 //   - <provider>.completion_path
 //   - <provider>.answer_path
+//   - <provider>.stream_answer_path
+//   - <provider>.context_window
+//   - <provider>.context_guard
+//   - <provider>.max_idle_conns
+//   - <provider>.max_idle_conns_per_host
+//   - <provider>.idle_conn_timeout
+//   - <provider>.disable_compression
+//   - <provider>.timeout
+//   - <provider>.connect_timeout
+//   - <provider>.stream_idle_timeout
+//   - <provider>.stream_usage
+//   - <provider>.command
+//   - <provider>.args
+//   - <provider>.type
 //   - prompt.brief_commit_message
 //   - prompt.rich_commit_message
 //   - prompt.translation
+//   - prompt.feedback
+//   - prompt.chunk_summary
+//   - prompt.split
 //
 // The <provider> placeholder in the returned list will be replaced with the name of the current provider.
 func (m *Manager) GetSupportedKeys() []string {
@@ -129,6 +158,10 @@ func (m *Manager) GetSupportedKeys() []string {
 	// Root level keys
 	keys["provider"] = true
 	keys["file_ignore"] = true
+	keys["pricing"] = true
+	keys["fallback_providers"] = true
+	keys["custom_providers"] = true
+	keys["protected_branches"] = true
 
 	// Output keys
 	outputKeys := []string{
@@ -149,6 +182,25 @@ func (m *Manager) GetSupportedKeys() []string {
 		keys["console."+key] = true
 	}
 
+	// Chunk keys
+	chunkKeys := []string{
+		"enabled",
+		"token_budget",
+	}
+	for _, key := range chunkKeys {
+		keys["chunk."+key] = true
+	}
+
+	// Cache keys
+	cacheKeys := []string{
+		"enabled",
+		"ttl",
+		"max_size_mb",
+	}
+	for _, key := range cacheKeys {
+		keys["cache."+key] = true
+	}
+
 	// Provider keys
 	providerKeys := []string{
 		"api_base",
@@ -164,6 +216,20 @@ func (m *Manager) GetSupportedKeys() []string {
 		"extra_body",
 		"completion_path",
 		"answer_path",
+		"stream_answer_path",
+		"context_window",
+		"context_guard",
+		"max_idle_conns",
+		"max_idle_conns_per_host",
+		"idle_conn_timeout",
+		"disable_compression",
+		"timeout",
+		"connect_timeout",
+		"stream_idle_timeout",
+		"stream_usage",
+		"command",
+		"args",
+		"type",
 	}
 	for _, key := range providerKeys {
 		keys["<provider>."+key] = true
@@ -174,6 +240,9 @@ func (m *Manager) GetSupportedKeys() []string {
 		"brief_commit_message",
 		"rich_commit_message",
 		"translation",
+		"feedback",
+		"chunk_summary",
+		"split",
 	}
 	for _, key := range promptKeys {
 		keys["prompt."+key] = true
@@ -257,6 +326,177 @@ func (m *Manager) GetTranslationPrompt() string {
 	return defaults.PromptDefaults["translation"]
 }
 
+// GetFeedbackPrompt retrieves the prompt used to refine a commit message with user feedback.
+// If the prompt configuration is not set or if the feedback prompt is not found,
+// it returns the default feedback prompt from defaults package.
+//
+// Returns:
+//   - string: The feedback prompt to be used
+func (m *Manager) GetFeedbackPrompt() string {
+	promptConfig, ok := m.config["prompt"].(map[string]interface{})
+	if !ok {
+		// return default prompt if not set in config
+		return defaults.PromptDefaults["feedback"]
+	}
+	if feedback, ok := promptConfig["feedback"].(string); ok {
+		return feedback
+	}
+	// return default prompt if not set in config
+	return defaults.PromptDefaults["feedback"]
+}
+
+// GetChunkSummaryPrompt retrieves the prompt used to summarize a single chunk of a large diff.
+// If the prompt configuration is not set or if the chunk summary prompt is not found,
+// it returns the default chunk summary prompt from defaults package.
+//
+// Returns:
+//   - string: The chunk summary prompt to be used
+func (m *Manager) GetChunkSummaryPrompt() string {
+	promptConfig, ok := m.config["prompt"].(map[string]interface{})
+	if !ok {
+		// return default prompt if not set in config
+		return defaults.PromptDefaults["chunk_summary"]
+	}
+	if summary, ok := promptConfig["chunk_summary"].(string); ok {
+		return summary
+	}
+	// return default prompt if not set in config
+	return defaults.PromptDefaults["chunk_summary"]
+}
+
+// GetSplitPrompt retrieves the prompt used to group the staged changes into commits.
+// If the prompt configuration is not set or if the split prompt is not found,
+// it returns the default split prompt from defaults package.
+//
+// Returns:
+//   - string: The split prompt to be used
+func (m *Manager) GetSplitPrompt() string {
+	promptConfig, ok := m.config["prompt"].(map[string]interface{})
+	if !ok {
+		// return default prompt if not set in config
+		return defaults.PromptDefaults["split"]
+	}
+	if split, ok := promptConfig["split"].(string); ok {
+		return split
+	}
+	// return default prompt if not set in config
+	return defaults.PromptDefaults["split"]
+}
+
+// GetChunkEnabled returns whether large diffs should be summarized in chunks.
+// If the configuration value is not found, it returns false by default.
+func (m *Manager) GetChunkEnabled() bool {
+	value, ok := m.Get("chunk.enabled")
+	if !ok {
+		return false
+	}
+
+	if b, ok := value.(bool); ok {
+		return b
+	}
+
+	return false
+}
+
+// GetChunkTokenBudget returns the maximum number of estimated tokens sent at once
+// before a diff is summarized in chunks. Invalid or non-positive values fall back
+// to defaults.DefaultChunkTokenBudget.
+func (m *Manager) GetChunkTokenBudget() int {
+	chunkConfig, ok := m.config["chunk"].(map[string]interface{})
+	if !ok {
+		return defaults.DefaultChunkTokenBudget
+	}
+
+	budget := getIntValue(chunkConfig, "token_budget", defaults.DefaultChunkTokenBudget)
+	if budget <= 0 {
+		return defaults.DefaultChunkTokenBudget
+	}
+	return budget
+}
+
+// GetCacheEnabled returns whether generated responses are cached on disk.
+// If the configuration value is not found, it returns true by default.
+func (m *Manager) GetCacheEnabled() bool {
+	value, ok := m.Get("cache.enabled")
+	if !ok {
+		return true
+	}
+
+	if b, ok := value.(bool); ok {
+		return b
+	}
+
+	return true
+}
+
+// GetCacheTTL returns how long cached responses are kept. Invalid or
+// non-positive values fall back to defaults.DefaultCacheTTL seconds.
+func (m *Manager) GetCacheTTL() time.Duration {
+	ttl := defaults.DefaultCacheTTL
+	if cacheConfig, ok := m.config["cache"].(map[string]interface{}); ok {
+		ttl = getIntValue(cacheConfig, "ttl", defaults.DefaultCacheTTL)
+	}
+	if ttl <= 0 {
+		ttl = defaults.DefaultCacheTTL
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// GetCacheMaxSize returns the maximum size of the response cache in bytes.
+// Invalid or non-positive values fall back to defaults.DefaultCacheMaxSizeMB.
+func (m *Manager) GetCacheMaxSize() int64 {
+	size := defaults.DefaultCacheMaxSizeMB
+	if cacheConfig, ok := m.config["cache"].(map[string]interface{}); ok {
+		size = getIntValue(cacheConfig, "max_size_mb", defaults.DefaultCacheMaxSizeMB)
+	}
+	if size <= 0 {
+		size = defaults.DefaultCacheMaxSizeMB
+	}
+	return int64(size) * 1024 * 1024
+}
+
+// GetModelPrice returns the price of model from the pricing table, in USD per
+// million tokens. The model is looked up by its exact name first, then case
+// insensitively and without a "vendor/" prefix.
+//
+// Example configuration:
+//
+//	pricing:
+//	  gpt-4o:
+//	    input: 2.5
+//	    output: 10
+//	    cached_input: 1.25
+//
+// Returns:
+//   - types.ModelPrice: The price of the model
+//   - bool: Whether the model has a price in the table
+func (m *Manager) GetModelPrice(model string) (types.ModelPrice, bool) {
+	pricing, ok := m.config["pricing"].(map[string]interface{})
+	if !ok || model == "" {
+		return types.ModelPrice{}, false
+	}
+
+	entry, ok := pricing[model].(map[string]interface{})
+	if !ok {
+		name := model[strings.LastIndex(model, "/")+1:]
+		for key, value := range pricing {
+			if strings.EqualFold(key, model) || strings.EqualFold(key, name) {
+				entry, ok = value.(map[string]interface{})
+				break
+			}
+		}
+	}
+	if !ok {
+		return types.ModelPrice{}, false
+	}
+
+	return types.ModelPrice{
+		Input:       getFloatValue(entry, "input", 0),
+		Output:      getFloatValue(entry, "output", 0),
+		CachedInput: getFloatValue(entry, "cached_input", 0),
+	}, true
+}
+
 // GetOutputTranslateTitle returns whether the title should be translated in the output.
 // If the configuration value is not found, it returns false by default.
 func (m *Manager) GetOutputTranslateTitle() bool {
@@ -272,6 +512,59 @@ func (m *Manager) GetOutputTranslateTitle() bool {
 	return false
 }
 
+// GetFallbackProviders returns the providers to try, in order, when the
+// configured provider fails. It returns nil if no fallback is configured.
+func (m *Manager) GetFallbackProviders() []string {
+	value, ok := m.Get("fallback_providers")
+	if !ok {
+		return nil
+	}
+
+	var result []string
+	switch providers := value.(type) {
+	case []interface{}:
+		for _, provider := range providers {
+			if str, ok := provider.(string); ok && str != "" {
+				result = append(result, str)
+			}
+		}
+	case []string:
+		for _, provider := range providers {
+			if provider != "" {
+				result = append(result, provider)
+			}
+		}
+	}
+	return result
+}
+
+// GetProtectedBranches returns the branches that reword refuses to rewrite
+// without --force. If the configuration value is not found, it returns a copy
+// of defaults.DefaultProtectedBranches.
+func (m *Manager) GetProtectedBranches() []string {
+	value, ok := m.Get("protected_branches")
+	if !ok {
+		return slices.Clone(defaults.DefaultProtectedBranches)
+	}
+
+	var result []string
+	switch branches := value.(type) {
+	case []interface{}:
+		for _, branch := range branches {
+			if str, ok := branch.(string); ok && str != "" {
+				result = append(result, str)
+			}
+		}
+	case []string:
+		for _, branch := range branches {
+			if branch != "" {
+				result = append(result, branch)
+			}
+		}
+	}
+	return result
+}
+
 // GetFileIgnore retrieves the list of file patterns to ignore from the configuration.
 // It returns:
 //   - A slice of strings containing the ignore patterns if configured
@@ -3,8 +3,11 @@ package config
 import (
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/belingud/gptcomet/pkg/config/defaults"
+	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -302,6 +305,16 @@ func TestManager_GetSupportedKeys(t *testing.T) {
 				"<provider>.model",
 				"prompt.brief_commit_message",
 				"console.verbose",
+				"chunk.enabled",
+				"chunk.token_budget",
+				"prompt.chunk_summary",
+				"prompt.split",
+				"pricing",
+				"fallback_providers",
+				"custom_providers",
+				"protected_branches",
+				"cache.enabled",
+				"<provider>.stream_answer_path",
 			},
 		},
 		{
@@ -520,6 +533,218 @@ prompt:
 	}
 }
 
+func TestManager_GetFeedbackPrompt(t *testing.T) {
+	tests := []struct {
+		name        string
+		configData  string
+		wantDefault bool
+	}{
+		{
+			name:        "No prompt config - returns default",
+			configData:  `{}`,
+			wantDefault: true,
+		},
+		{
+			name: "No feedback prompt in config",
+			configData: `
+prompt:
+  review: "Review prompt"
+`,
+			wantDefault: true,
+		},
+		{
+			name: "Custom feedback prompt",
+			configData: `
+prompt:
+  feedback: "Custom feedback prompt"
+`,
+			wantDefault: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+
+			prompt := cfg.GetFeedbackPrompt()
+			assert.NotEmpty(t, prompt)
+
+			if tt.wantDefault {
+				// Should return default feedback prompt
+				assert.Contains(t, prompt, "FEEDBACK")
+			} else {
+				// Should return custom prompt
+				assert.Equal(t, "Custom feedback prompt", prompt)
+			}
+		})
+	}
+}
+
+func TestManager_GetChunkSummaryPrompt(t *testing.T) {
+	tests := []struct {
+		name        string
+		configData  string
+		wantDefault bool
+	}{
+		{
+			name:        "No prompt config - returns default",
+			configData:  `{}`,
+			wantDefault: true,
+		},
+		{
+			name: "Custom chunk summary prompt",
+			configData: `
+prompt:
+  chunk_summary: "Custom chunk summary prompt"
+`,
+			wantDefault: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+
+			prompt := cfg.GetChunkSummaryPrompt()
+			if tt.wantDefault {
+				assert.Equal(t, defaults.PromptDefaults["chunk_summary"], prompt)
+			} else {
+				assert.Equal(t, "Custom chunk summary prompt", prompt)
+			}
+		})
+	}
+}
+
+func TestManager_GetSplitPrompt(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `{}`)
+	defer cleanup()
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, defaults.PromptDefaults["split"], cfg.GetSplitPrompt())
+
+	configFile, cleanup = testutils.TestConfig(t, `
+prompt:
+  split: "Custom split prompt"
+`)
+	defer cleanup()
+	cfg, err = New(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Custom split prompt", cfg.GetSplitPrompt())
+}
+
+func TestManager_GetChunkSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		configData  string
+		wantEnabled bool
+		wantBudget  int
+	}{
+		{
+			name:        "Defaults",
+			configData:  `{}`,
+			wantEnabled: false,
+			wantBudget:  defaults.DefaultChunkTokenBudget,
+		},
+		{
+			name: "Enabled with custom budget",
+			configData: `
+chunk:
+  enabled: true
+  token_budget: 2000
+`,
+			wantEnabled: true,
+			wantBudget:  2000,
+		},
+		{
+			name: "Invalid values fall back to defaults",
+			configData: `
+chunk:
+  enabled: "yes"
+  token_budget: -1
+`,
+			wantEnabled: false,
+			wantBudget:  defaults.DefaultChunkTokenBudget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantEnabled, cfg.GetChunkEnabled())
+			assert.Equal(t, tt.wantBudget, cfg.GetChunkTokenBudget())
+		})
+	}
+}
+
+func TestManager_GetCacheSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		configData  string
+		wantEnabled bool
+		wantTTL     time.Duration
+		wantMaxSize int64
+	}{
+		{
+			name:        "Defaults",
+			configData:  `{}`,
+			wantEnabled: true,
+			wantTTL:     defaults.DefaultCacheTTL * time.Second,
+			wantMaxSize: defaults.DefaultCacheMaxSizeMB * 1024 * 1024,
+		},
+		{
+			name: "Custom settings",
+			configData: `
+cache:
+  enabled: false
+  ttl: 60
+  max_size_mb: 2
+`,
+			wantEnabled: false,
+			wantTTL:     time.Minute,
+			wantMaxSize: 2 * 1024 * 1024,
+		},
+		{
+			name: "Invalid values fall back to defaults",
+			configData: `
+cache:
+  enabled: "no"
+  ttl: -1
+  max_size_mb: 0
+`,
+			wantEnabled: true,
+			wantTTL:     defaults.DefaultCacheTTL * time.Second,
+			wantMaxSize: defaults.DefaultCacheMaxSizeMB * 1024 * 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantEnabled, cfg.GetCacheEnabled())
+			assert.Equal(t, tt.wantTTL, cfg.GetCacheTTL())
+			assert.Equal(t, tt.wantMaxSize, cfg.GetCacheMaxSize())
+		})
+	}
+}
+
 func TestManager_GetFileIgnore(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -589,3 +814,142 @@ file_ignore:
 		})
 	}
 }
+
+func TestManager_GetModelPrice(t *testing.T) {
+	configData := `
+pricing:
+  gpt-4o:
+    input: 2.5
+    output: 10
+    cached_input: 1.25
+  DeepSeek-Chat:
+    input: 0.27
+    output: 1.1
+`
+	tests := []struct {
+		name      string
+		model     string
+		wantPrice types.ModelPrice
+		wantOK    bool
+	}{
+		{
+			name:      "Exact match",
+			model:     "gpt-4o",
+			wantPrice: types.ModelPrice{Input: 2.5, Output: 10, CachedInput: 1.25},
+			wantOK:    true,
+		},
+		{
+			name:      "Case insensitive match with provider prefix",
+			model:     "deepseek/deepseek-chat",
+			wantPrice: types.ModelPrice{Input: 0.27, Output: 1.1},
+			wantOK:    true,
+		},
+		{
+			name:   "Unknown model",
+			model:  "claude-3-5-sonnet",
+			wantOK: false,
+		},
+	}
+
+	configFile, cleanup := testutils.TestConfig(t, configData)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, ok := cfg.GetModelPrice(tt.model)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantPrice, price)
+		})
+	}
+}
+
+func TestManager_GetFallbackProviders(t *testing.T) {
+	tests := []struct {
+		name       string
+		configData string
+		want       []string
+	}{
+		{
+			name:       "Not configured",
+			configData: `{}`,
+			want:       nil,
+		},
+		{
+			name: "Fallback providers in order",
+			configData: `
+fallback_providers:
+  - deepseek
+  - ""
+  - ollama
+`,
+			want: []string{"deepseek", "ollama"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, cfg.GetFallbackProviders())
+		})
+	}
+}
+
+func TestManager_GetProtectedBranches(t *testing.T) {
+	tests := []struct {
+		name       string
+		configData string
+		want       []string
+	}{
+		{
+			name:       "Not configured",
+			configData: `{}`,
+			want:       []string{"main", "master"},
+		},
+		{
+			name: "Configured branches",
+			configData: `
+protected_branches:
+  - main
+  - ""
+  - release
+`,
+			want: []string{"main", "release"},
+		},
+		{
+			name:       "No protected branches",
+			configData: `protected_branches: []`,
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, cfg.GetProtectedBranches())
+		})
+	}
+
+	t.Run("Defaults are copied", func(t *testing.T) {
+		configFile, cleanup := testutils.TestConfig(t, `{}`)
+		defer cleanup()
+
+		cfg, err := New(configFile)
+		require.NoError(t, err)
+
+		cfg.GetProtectedBranches()[0] = "changed"
+		assert.Equal(t, []string{"main", "master"}, cfg.GetProtectedBranches())
+	})
+}
@@ -6,8 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/belingud/gptcomet/internal/cache"
+	"github.com/belingud/gptcomet/internal/constants"
 	gptcometerrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/internal/usage"
 	"github.com/belingud/gptcomet/pkg/config/defaults"
 	"github.com/belingud/gptcomet/pkg/types"
 
@@ -33,8 +37,20 @@ type ManagerInterface interface {
 	SetNestedValue(keys []string, value interface{})
 	Load() error
 	GetTranslationPrompt() string
+	GetFeedbackPrompt() string
+	GetChunkSummaryPrompt() string
+	GetSplitPrompt() string
+	GetChunkEnabled() bool
+	GetChunkTokenBudget() int
+	GetCacheEnabled() bool
+	GetCacheTTL() time.Duration
+	GetCacheMaxSize() int64
+	GetModelPrice(model string) (types.ModelPrice, bool)
 	GetOutputTranslateTitle() bool
 	GetFileIgnore() []string
+	GetFallbackProviders() []string
+	GetProtectedBranches() []string
+	GetCustomProviders() (map[string]types.ProviderDefinition, error)
 }
 
 // Manager handles configuration management
@@ -99,6 +115,11 @@ func New(configPath string) (*Manager, error) {
 // - retries: the number of times to retry the request if it fails (defaults to 3)
 // - answer_path: the JSON path to the answer field in the response (defaults to an empty string)
 // - completion_path: the JSON path to the completion field in the response (defaults to an empty string)
+// - timeout: the seconds a request may take in total, or a stream until its first data, 0 for no limit (defaults to 60)
+// - connect_timeout: the seconds to wait for a connection (defaults to 10)
+// - stream_idle_timeout: the seconds a stream may send no data before it's aborted, 0 for no limit (defaults to 30)
+// - stream_usage: whether OpenAI-compatible streams ask for their token usage (defaults to true)
+// - type: the built-in provider the section works like, e.g. exec (defaults to the provider name)
 //
 // If any of the required configuration options are not set, an error is returned.
 func (m *Manager) GetClientConfig(initProvider string) (*types.ClientConfig, error) {
@@ -112,13 +133,27 @@ func (m *Manager) GetClientConfig(initProvider string) (*types.ClientConfig, err
 	} else {
 		provider = initProvider
 	}
+	definition, err := m.getCustomProvider(provider)
+	if err != nil {
+		return nil, err
+	}
 	providerConfig, ok := m.config[provider].(map[string]interface{})
 	if !ok {
-		return nil, gptcometerrors.NewConfigError("Configuration Error", fmt.Sprintf("Provider config not found: %s", provider), nil, []string{fmt.Sprintf("Configure the provider: gptcomet config set %s.api_key <key>", provider)})
+		if definition == nil {
+			return nil, gptcometerrors.NewConfigError("Configuration Error", fmt.Sprintf("Provider config not found: %s", provider), nil, []string{fmt.Sprintf("Configure the provider: gptcomet config set %s.api_key <key>", provider)})
+		}
+		// A declared provider works without a section of its own, e.g. without an API key
+		providerConfig = map[string]interface{}{}
+	}
+	// A section can use a built-in provider under another name, e.g. type: exec
+	providerType := provider
+	if t, ok := providerConfig["type"].(string); ok && t != "" {
+		providerType = t
 	}
+
 	var apiKey string
-	if provider == "ollama" {
-		apiKey = ""
+	if providerType == "ollama" || providerType == "exec" || (definition != nil && definition.AuthStyle == types.AuthStyleNone) {
+		apiKey, _ = providerConfig["api_key"].(string)
 	} else {
 		apiKeyValue, ok := providerConfig["api_key"].(string)
 		if !ok || apiKeyValue == "" {
@@ -130,13 +165,18 @@ func (m *Manager) GetClientConfig(initProvider string) (*types.ClientConfig, err
 	apiBase := defaults.DefaultAPIBase
 	if base, ok := providerConfig["api_base"].(string); ok {
 		apiBase = base
-	} else if provider == "ollama" {
+	} else if definition != nil {
+		apiBase = definition.APIBase
+	} else if providerType == "exec" {
+		// The command of the exec provider knows its endpoint
+		apiBase = ""
+	} else if providerType == "ollama" {
 		// Ollama uses local default
 		apiBase = "http://localhost:11434/api"
-	} else if provider == "gemini" {
+	} else if providerType == "gemini" {
 		// Gemini API endpoint
 		apiBase = "https://generativelanguage.googleapis.com/v1beta/models"
-	} else if provider == "vertex" {
+	} else if providerType == "vertex" {
 		// Vertex AI uses project-specific endpoint, but we'll use a default
 		apiBase = "https://us-central1-aiplatform.googleapis.com/v1"
 	}
@@ -144,6 +184,8 @@ func (m *Manager) GetClientConfig(initProvider string) (*types.ClientConfig, err
 	model := defaults.DefaultModel
 	if m, ok := providerConfig["model"].(string); ok {
 		model = m
+	} else if definition != nil && definition.DefaultModel != "" {
+		model = definition.DefaultModel
 	}
 
 	proxy := ""
@@ -174,6 +216,10 @@ func (m *Manager) GetClientConfig(initProvider string) (*types.ClientConfig, err
 		TopP:             topP,
 		Temperature:      temperature,
 		FrequencyPenalty: frequencyPenalty,
+		Definition:       definition,
+	}
+	if providerType != provider {
+		clientConfig.Type = providerType
 	}
 	if m, ok := providerConfig["retries"].(float64); ok {
 		clientConfig.Retries = int(m)
@@ -183,10 +229,45 @@ func (m *Manager) GetClientConfig(initProvider string) (*types.ClientConfig, err
 		clientConfig.AnswerPath = answerPath
 	}
 
+	if streamAnswerPath, ok := providerConfig["stream_answer_path"].(string); ok {
+		clientConfig.StreamAnswerPath = streamAnswerPath
+	}
+
 	if completionPath, ok := providerConfig["completion_path"].(string); ok {
 		clientConfig.CompletionPath = &completionPath
 	}
 
+	// Command of the exec provider
+	if command, ok := providerConfig["command"].(string); ok {
+		clientConfig.Command = command
+	}
+	if args, ok := providerConfig["args"].([]interface{}); ok {
+		for _, arg := range args {
+			clientConfig.CommandArgs = append(clientConfig.CommandArgs, fmt.Sprint(arg))
+		}
+	}
+
+	clientConfig.ContextWindow = getIntValue(providerConfig, "context_window", 0)
+	if guard, ok := providerConfig["context_guard"].(string); ok {
+		clientConfig.ContextGuard = guard
+	}
+
+	// Timeouts in seconds
+	clientConfig.Timeout = int64(getIntValue(providerConfig, "timeout", int(constants.DefaultTimeout/time.Second)))
+	clientConfig.ConnectTimeout = int64(getIntValue(providerConfig, "connect_timeout", int(constants.DefaultConnectTimeout/time.Second)))
+	clientConfig.StreamIdleTimeout = int64(getIntValue(providerConfig, "stream_idle_timeout", int(constants.DefaultStreamIdleTimeout/time.Second)))
+
+	// Connection pool settings, zero values use the defaults of the client
+	clientConfig.MaxIdleConns = getIntValue(providerConfig, "max_idle_conns", 0)
+	clientConfig.MaxIdleConnsPerHost = getIntValue(providerConfig, "max_idle_conns_per_host", 0)
+	clientConfig.IdleConnTimeout = int64(getIntValue(providerConfig, "idle_conn_timeout", 0))
+	if disable, ok := providerConfig["disable_compression"].(bool); ok {
+		clientConfig.DisableCompression = &disable
+	}
+	if streamUsage, ok := providerConfig["stream_usage"].(bool); ok {
+		clientConfig.StreamUsage = &streamUsage
+	}
+
 	// Parse extra_headers (additional request headers)
 	if extraHeadersStr, ok := providerConfig["extra_headers"].(string); ok && extraHeadersStr != "" && extraHeadersStr != "{}" {
 		extraHeaders := make(map[string]string)
@@ -208,6 +289,93 @@ func (m *Manager) GetClientConfig(initProvider string) (*types.ClientConfig, err
 	return clientConfig, nil
 }
 
+// GetCustomProviders returns the OpenAI-compatible providers declared in the
+// custom_providers section of the config, by name. It returns an error if a
+// declaration can't be parsed, has no api_base or has an unknown auth_style.
+//
+// Example configuration:
+//
+//	custom_providers:
+//	  myvendor:
+//	    api_base: https://api.myvendor.com/v1
+//	    default_model: my-model
+//	    auth_style: header
+//	    auth_header: x-api-key
+//	    usage_paths:
+//	      prompt_tokens: usage.input_tokens
+//	      completion_tokens: usage.output_tokens
+func (m *Manager) GetCustomProviders() (map[string]types.ProviderDefinition, error) {
+	section, ok := m.config["custom_providers"].(map[string]interface{})
+	if !ok || len(section) == 0 {
+		return nil, nil
+	}
+
+	definitions := make(map[string]types.ProviderDefinition, len(section))
+	for name, value := range section {
+		definition, err := parseCustomProvider(name, value)
+		if err != nil {
+			return nil, err
+		}
+		definitions[name] = definition
+	}
+	return definitions, nil
+}
+
+// getCustomProvider returns the declaration of provider, or nil if it isn't
+// declared in the custom_providers section. Only the declaration of provider
+// is validated, a broken declaration of another provider doesn't matter.
+func (m *Manager) getCustomProvider(provider string) (*types.ProviderDefinition, error) {
+	section, _ := m.config["custom_providers"].(map[string]interface{})
+	value, ok := section[provider]
+	if !ok {
+		return nil, nil
+	}
+	definition, err := parseCustomProvider(provider, value)
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// parseCustomProvider parses and validates the declaration of the custom
+// provider name
+func parseCustomProvider(name string, value interface{}) (types.ProviderDefinition, error) {
+	var definition types.ProviderDefinition
+
+	// Convert the generic map to a definition through YAML
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return definition, gptcometerrors.NewConfigError("Configuration Error", fmt.Sprintf("Failed to read custom provider %s", name), err, nil)
+	}
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return definition, gptcometerrors.NewConfigError("Configuration Error", fmt.Sprintf("Failed to parse custom provider %s", name), err, nil)
+	}
+
+	definition.Name = name
+	if definition.APIBase == "" {
+		return definition, gptcometerrors.NewConfigError(
+			"Configuration Error",
+			fmt.Sprintf("Custom provider %s has no api_base", name),
+			nil,
+			[]string{fmt.Sprintf("Set it with: gptcomet config set custom_providers.%s.api_base <url>", name)},
+		)
+	}
+	definition.AuthStyle = strings.ToLower(definition.AuthStyle)
+	switch definition.AuthStyle {
+	case "":
+		definition.AuthStyle = types.AuthStyleBearer
+	case types.AuthStyleBearer, types.AuthStyleHeader, types.AuthStyleNone:
+	default:
+		return definition, gptcometerrors.NewConfigError(
+			"Configuration Error",
+			fmt.Sprintf("Custom provider %s has an unknown auth_style: %s", name, definition.AuthStyle),
+			nil,
+			[]string{"Use one of: bearer, header, none"},
+		)
+	}
+	return definition, nil
+}
+
 // SetProvider sets the provider configuration.
 //
 // It takes the provider name, API key, API base, and model name as arguments.
@@ -240,11 +408,31 @@ func (m *Manager) SetProvider(provider, apiKey, apiBase, model string) error {
 // If the key is not found, the value is not set.
 //
 // If the key is "output.lang" or "output.review_lang", the value must be a valid language code.
-// If the key is "output.translate_title", the value must be a boolean.
+// If the key is "output.translate_title" or "chunk.enabled", the value must be a boolean.
+// If the key is "<provider>.context_guard", the value must be "fail", "warn" or "off".
+// If the key is "<provider>.disable_compression" or "<provider>.stream_usage", the value must be a boolean.
 //
 // The method saves the configuration to the file and returns an error if
 // the save fails.
 func (m *Manager) Set(key string, value interface{}) error {
+	if strings.HasSuffix(key, ".context_guard") {
+		switch value {
+		case types.ContextGuardFail, types.ContextGuardWarn, types.ContextGuardOff:
+		default:
+			return gptcometerrors.InvalidConfigValueError(key, fmt.Sprintf("%v", value), "context_guard must be one of: fail, warn, off")
+		}
+	}
+	if strings.HasSuffix(key, ".disable_compression") {
+		if _, ok := value.(bool); !ok {
+			return gptcometerrors.InvalidConfigValueError(key, fmt.Sprintf("%v", value), "disable_compression must be a boolean value")
+		}
+	}
+	if strings.HasSuffix(key, ".stream_usage") {
+		if _, ok := value.(bool); !ok {
+			return gptcometerrors.InvalidConfigValueError(key, fmt.Sprintf("%v", value), "stream_usage must be a boolean value")
+		}
+	}
+
 	switch key {
 	case "output.lang", "output.review_lang":
 		if str, ok := value.(string); ok {
@@ -256,6 +444,10 @@ func (m *Manager) Set(key string, value interface{}) error {
 		if _, ok := value.(bool); !ok {
 			return gptcometerrors.InvalidConfigValueError(key, fmt.Sprintf("%v", value), "translate_title must be a boolean value")
 		}
+	case "chunk.enabled":
+		if _, ok := value.(bool); !ok {
+			return gptcometerrors.InvalidConfigValueError(key, fmt.Sprintf("%v", value), "chunk.enabled must be a boolean value")
+		}
 	}
 
 	keys := strings.Split(key, ".")
@@ -433,6 +625,28 @@ func getConfigDir() (string, error) {
 	return filepath.Join(homeDir, ".config", "gptcomet"), nil
 }
 
+// GetUsageLedgerPath returns the path to the usage ledger, which is kept
+// in the configuration directory next to the default config file.
+func GetUsageLedgerPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, usage.LedgerFileName), nil
+}
+
+// GetCacheDir returns the directory of the response cache, which is kept
+// in the configuration directory next to the default config file.
+func GetCacheDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, cache.DirName), nil
+}
+
 // List returns the current configuration as a YAML-formatted string.
 // This method masks sensitive information such as API keys before converting to YAML.
 // It excludes the prompt section from the configuration.
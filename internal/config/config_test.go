@@ -110,6 +110,45 @@ openai:
 			value:   "gpt-4",
 			wantErr: false,
 		},
+		{
+			name:       "Set context guard",
+			configData: "",
+			key:        "openai.context_guard",
+			value:      "warn",
+			wantErr:    false,
+		},
+		{
+			name:        "Set invalid context guard",
+			configData:  "",
+			key:         "openai.context_guard",
+			value:       "ignore",
+			wantErr:     true,
+			errContains: "context_guard must be one of",
+		},
+		{
+			name:        "Set invalid disable compression",
+			configData:  "",
+			key:         "openai.disable_compression",
+			value:       "no",
+			wantErr:     true,
+			errContains: "disable_compression must be a boolean",
+		},
+		{
+			name:        "Set invalid stream usage",
+			configData:  "",
+			key:         "openai.stream_usage",
+			value:       "off",
+			wantErr:     true,
+			errContains: "stream_usage must be a boolean",
+		},
+		{
+			name:        "Set invalid chunk enabled",
+			configData:  "",
+			key:         "chunk.enabled",
+			value:       "yes",
+			wantErr:     true,
+			errContains: "chunk.enabled must be a boolean",
+		},
 	}
 
 	for _, tt := range tests {
@@ -261,6 +300,127 @@ anthropic:
 				assert.Equal(t, "anthropic-key", cfg.APIKey)
 			},
 		},
+		{
+			name: "Custom provider declared in config",
+			configData: `
+provider: myvendor
+custom_providers:
+  myvendor:
+    api_base: https://api.myvendor.com/v1
+    default_model: my-model
+    answer_path: output.text
+myvendor:
+  api_key: vendor-key
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, "myvendor", cfg.Provider)
+				assert.Equal(t, "vendor-key", cfg.APIKey)
+				assert.Equal(t, "https://api.myvendor.com/v1", cfg.APIBase)
+				assert.Equal(t, "my-model", cfg.Model)
+				require.NotNil(t, cfg.Definition)
+				assert.Equal(t, "myvendor", cfg.Definition.Name)
+				assert.Equal(t, types.AuthStyleBearer, cfg.Definition.AuthStyle)
+				assert.Equal(t, "output.text", cfg.Definition.AnswerPath)
+			},
+		},
+		{
+			name: "Custom provider without auth needs no section",
+			configData: `
+provider: local
+custom_providers:
+  local:
+    api_base: http://localhost:8000/v1
+    auth_style: none
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, "", cfg.APIKey)
+				assert.Equal(t, "http://localhost:8000/v1", cfg.APIBase)
+				require.NotNil(t, cfg.Definition)
+			},
+		},
+		{
+			name: "Exec provider with command and args",
+			configData: `
+provider: exec
+exec:
+  command: /usr/local/bin/gateway-llm
+  args: [--region, eu]
+  model: internal-model
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, "", cfg.APIKey)
+				assert.Equal(t, "", cfg.APIBase)
+				assert.Equal(t, "/usr/local/bin/gateway-llm", cfg.Command)
+				assert.Equal(t, []string{"--region", "eu"}, cfg.CommandArgs)
+				assert.Equal(t, "internal-model", cfg.Model)
+			},
+		},
+		{
+			name: "Provider with exec type",
+			configData: `
+provider: gateway
+gateway:
+  type: exec
+  command: /usr/local/bin/gateway-llm
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, "gateway", cfg.Provider)
+				assert.Equal(t, "exec", cfg.Type)
+				assert.Equal(t, "", cfg.APIKey)
+				assert.Equal(t, "", cfg.APIBase)
+				assert.Equal(t, "/usr/local/bin/gateway-llm", cfg.Command)
+			},
+		},
+		{
+			name: "Custom provider with unknown auth style",
+			configData: `
+provider: local
+custom_providers:
+  local:
+    api_base: http://localhost:8000/v1
+    auth_style: basic
+`,
+			initProvider: "",
+			wantErr:      true,
+			errContains:  "unknown auth_style",
+		},
+		{
+			name: "Invalid custom provider that isn't selected",
+			configData: `
+provider: openai
+openai:
+  api_key: sk-test
+custom_providers:
+  local:
+    default_model: my-model
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, "openai", cfg.Provider)
+				assert.Nil(t, cfg.Definition)
+			},
+		},
+		{
+			name: "Custom provider without api_base",
+			configData: `
+provider: local
+custom_providers:
+  local:
+    default_model: my-model
+`,
+			initProvider: "",
+			wantErr:      true,
+			errContains:  "has no api_base",
+		},
 		{
 			name: "Ollama provider (no API key required)",
 			configData: `
@@ -503,6 +663,91 @@ openai:
 			wantErr:      true,
 			errContains:  "Failed to parse extra_body",
 		},
+		{
+			name: "Context window and guard",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+  context_window: 32000
+  context_guard: warn
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, 32000, cfg.ContextWindow)
+				assert.Equal(t, types.ContextGuardWarn, cfg.ContextGuard)
+			},
+		},
+		{
+			name: "Connection pool settings",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+  max_idle_conns: 20
+  max_idle_conns_per_host: 4
+  idle_conn_timeout: 30
+  disable_compression: false
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, 20, cfg.MaxIdleConns)
+				assert.Equal(t, 4, cfg.MaxIdleConnsPerHost)
+				assert.Equal(t, int64(30), cfg.IdleConnTimeout)
+				require.NotNil(t, cfg.DisableCompression)
+				assert.False(t, *cfg.DisableCompression)
+			},
+		},
+		{
+			name: "Timeouts",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+  timeout: 120
+  connect_timeout: 5
+  stream_idle_timeout: 0
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, int64(120), cfg.Timeout)
+				assert.Equal(t, int64(5), cfg.ConnectTimeout)
+				assert.Equal(t, int64(0), cfg.StreamIdleTimeout, "zero disables the idle timeout")
+			},
+		},
+		{
+			name: "Stream usage turned off",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+  stream_usage: false
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				require.NotNil(t, cfg.StreamUsage)
+				assert.False(t, *cfg.StreamUsage)
+			},
+		},
+		{
+			name: "Default timeouts",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+`,
+			initProvider: "",
+			wantErr:      false,
+			validateFunc: func(t *testing.T, cfg *types.ClientConfig) {
+				assert.Equal(t, int64(60), cfg.Timeout)
+				assert.Equal(t, int64(10), cfg.ConnectTimeout)
+				assert.Equal(t, int64(30), cfg.StreamIdleTimeout)
+			},
+		},
 		{
 			name: "All configuration options",
 			configData: `
@@ -39,9 +39,18 @@ const (
 	// MaxIdleConns controls the maximum number of idle (keep-alive) connections across all hosts
 	MaxIdleConns = 100
 
+	// MaxIdleConnsPerHost controls the maximum number of idle (keep-alive) connections to keep per host
+	MaxIdleConnsPerHost = 10
+
 	// IdleConnTimeout is the maximum amount of time an idle connection will remain idle before closing itself
 	IdleConnTimeout = 90 * time.Second
 
+	// TLSHandshakeTimeout is the maximum amount of time to wait for a TLS handshake
+	TLSHandshakeTimeout = 10 * time.Second
+
+	// KeepAlive is the interval between TCP keep-alive probes of open connections
+	KeepAlive = 30 * time.Second
+
 	// DisableCompression prevents the Transport from requesting compression with an "Accept-Encoding: gzip" request header
 	DisableCompression = true
 )
@@ -51,6 +60,9 @@ const (
 	// BaseRetryDelay is the base delay for exponential backoff retry logic
 	BaseRetryDelay = 500 * time.Millisecond
 
+	// MaxRetryAfter is the longest Retry-After the client waits for before giving up
+	MaxRetryAfter = 2 * time.Minute
+
 	// DefaultMaxRetries is the default maximum number of retry attempts
 	DefaultMaxRetries = 3
 
@@ -176,6 +188,12 @@ const (
 
 	// DefaultTimeout is the default HTTP request timeout
 	DefaultTimeout = 60 * time.Second
+
+	// DefaultConnectTimeout is the default time to wait for a connection to the provider
+	DefaultConnectTimeout = 10 * time.Second
+
+	// DefaultStreamIdleTimeout is the default time a stream may send no data before it is aborted
+	DefaultStreamIdleTimeout = 30 * time.Second
 )
 
 // Common Strings
@@ -26,6 +26,13 @@ const (
 	ErrTitleAPIStatusError     = "API Request Failed"
 	ErrTitleCallbackError      = "Callback Function Failed"
 	ErrTitleUnsupportedProxy   = "Unsupported Proxy Scheme"
+	ErrTitleContextWindow      = "Prompt Exceeds Context Window"
+	ErrTitleStreamIdle         = "Stream Stalled"
+	ErrTitleStreamTimeout      = "Stream Timed Out"
+	ErrTitleProviderCommand    = "Provider Command Failed"
+	ErrTitleModelListing       = "Model Listing Not Supported"
+	ErrTitleNoCommitChanges    = "No Changes in Commit"
+	ErrTitleVCSUnsupported     = "Operation Not Supported"
 
 	// Common Messages
 	ErrMsgConfigNotFound     = "Cannot find configuration file at: %s"
@@ -52,6 +59,13 @@ const (
 	ErrMsgAPIStatusError     = "API request failed with status code %d."
 	ErrMsgCallbackError      = "Callback function returned an error."
 	ErrMsgUnsupportedProxy   = "Proxy scheme '%s' is not supported."
+	ErrMsgStreamIdle         = "Provider '%s' sent no data for %v, the stream was aborted."
+	ErrMsgStreamTimeout      = "Provider '%s' sent no data within %v, the stream was aborted."
+	ErrMsgProviderCommand    = "Provider command '%s' failed: %v"
+	ErrMsgModelListing       = "Provider '%s' can't list its models."
+	ErrMsgNoCommitChanges    = "Commit '%s' has no changes to generate a commit message for."
+	ErrMsgVCSUnsupported     = "%s doesn't support %s."
+	ErrMsgContextWindow      = "The prompt is about %d tokens and %d tokens are reserved for the answer, but model '%s' accepts at most %d tokens."
 
 	// Common Suggestions
 	SuggInitConfig            = "Run 'gptcomet config init' to create a default configuration"
@@ -94,4 +108,14 @@ const (
 	SuggVerifyRequestPayload  = "Verify that the request payload is correctly formatted"
 	SuggCheckErrorDetails     = "Check the error details for more information"
 	SuggSupportedProxySchemes = "Supported proxy schemes: http, https, socks5"
+	SuggIgnoreLargeFiles      = "Ignore large or generated files: gptcomet config append file_ignore <pattern>"
+	SuggEnableChunking        = "Summarize large diffs in chunks: gptcomet config set chunk.enabled true, or pass --chunk"
+	SuggSetProviderCommand    = "Set the command: gptcomet config set %s.command <path>"
+	SuggRunProviderCommand    = "Run the command with a request on stdin to check its output"
+	SuggSetStreamIdleTimeout  = "If the model pauses long while answering, raise the idle timeout: gptcomet config set %s.stream_idle_timeout <seconds>"
+	SuggSetTimeout            = "If the model thinks long before answering, raise the timeout: gptcomet config set %s.timeout <seconds>"
+	SuggSetContextWindow      = "If the model accepts more tokens, set its context window: gptcomet config set %s.context_window <tokens>"
+	SuggCheckCommit           = "Check the changes of the commit: git show --stat %s"
+	SuggCheckFileIgnore       = "Check the ignored files: gptcomet config get file_ignore"
+	SuggUseGit                = "Use git for this operation"
 )
@@ -1,8 +1,12 @@
 package errors
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // ErrorType represents the category of error
@@ -61,6 +65,75 @@ func (e *GPTCometError) Unwrap() error {
 	return e.Cause
 }
 
+// HTTPStatusError is the error of an API response with a non-OK status code.
+// It keeps the headers of the response, such as Retry-After.
+type HTTPStatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// Error implements the error interface
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// RetryAfter returns how long the server asked to wait before retrying, from the
+// retry-after-ms or Retry-After header. Retry-After may be a number of seconds or
+// an HTTP date.
+func (e *HTTPStatusError) RetryAfter() (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(e.Header.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	value := strings.TrimSpace(e.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// StatusCode returns the status code of the first HTTPStatusError in the chain of err,
+// and false if there is none
+func StatusCode(err error) (int, bool) {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode, true
+	}
+	return 0, false
+}
+
+// RetryAfter returns how long the server asked to wait before retrying, from the
+// first HTTPStatusError in the chain of err, and false if it didn't ask
+func RetryAfter(err error) (time.Duration, bool) {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter()
+	}
+	return 0, false
+}
+
+// HasTitle reports whether err or any error it wraps is a GPTCometError with the given title
+func HasTitle(err error, title string) bool {
+	for err != nil {
+		if e, ok := err.(*GPTCometError); ok && e.Title == title {
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
+
 // WrapError creates a new GPTCometError wrapping an existing error
 func WrapError(err error, title, message string) *GPTCometError {
 	if err == nil {
@@ -2,8 +2,11 @@ package errors
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGPTCometError_Error(t *testing.T) {
@@ -276,6 +279,27 @@ func TestModelNotFoundError(t *testing.T) {
 	}
 }
 
+func TestContextWindowExceededError(t *testing.T) {
+	err := ContextWindowExceededError("openai", "gpt-4", 9000, 1024, 8192)
+
+	if err.Type != ErrTypeValidation {
+		t.Errorf("ContextWindowExceededError() Type = %v, want %v", err.Type, ErrTypeValidation)
+	}
+
+	for _, want := range []string{"9000", "1024", "gpt-4", "8192"} {
+		if !strings.Contains(err.Message, want) {
+			t.Errorf("ContextWindowExceededError() message should contain %q, got: %s", want, err.Message)
+		}
+	}
+
+	suggestions := strings.Join(err.Suggestions, "\n")
+	for _, want := range []string{"file_ignore", "chunk.enabled", "openai.context_window"} {
+		if !strings.Contains(suggestions, want) {
+			t.Errorf("ContextWindowExceededError() suggestions should mention %q, got: %v", want, err.Suggestions)
+		}
+	}
+}
+
 func TestGetAPIKeyEnvVar(t *testing.T) {
 	tests := []struct {
 		provider string
@@ -328,3 +352,95 @@ func TestGetSuggestionIcon(t *testing.T) {
 		}
 	}
 }
+
+func TestStatusCode(t *testing.T) {
+	statusErr := &HTTPStatusError{StatusCode: 503, Body: "unavailable"}
+
+	if code, ok := StatusCode(RequestRetryError(3, statusErr)); !ok || code != 503 {
+		t.Errorf("StatusCode() = %d, %v, want 503, true", code, ok)
+	}
+	if _, ok := StatusCode(errors.New("network error")); ok {
+		t.Error("StatusCode() of an error without status should return false")
+	}
+	if got := statusErr.Error(); got != "request failed with status 503: unavailable" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestHasTitle(t *testing.T) {
+	err := fmt.Errorf("chat: %w", RequestRetryError(3, APIAuthenticationError("openai", nil)))
+
+	if !HasTitle(err, ErrTitleRequestRetry) {
+		t.Error("HasTitle() should find the wrapped retry error")
+	}
+	if !HasTitle(err, ErrTitleAPIAuth) {
+		t.Error("HasTitle() should find the cause of the retry error")
+	}
+	if HasTitle(err, ErrTitleContextWindow) {
+		t.Error("HasTitle() should not find a missing title")
+	}
+}
+
+func TestResponseStatusError(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantTitle string
+	}{
+		{status: 401, wantTitle: ErrTitleAPIAuth},
+		{status: 403, wantTitle: ErrTitleAPIAuth},
+		{status: 429, wantTitle: ErrTitleAPIRequest},
+		{status: 400, wantTitle: ErrTitleAPIStatusError},
+		{status: 503, wantTitle: ErrTitleAPIStatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			header := http.Header{"Retry-After": []string{"3"}}
+			err := ResponseStatusError("openai", tt.status, header, `{"error": "details"}`)
+
+			if err.Title != tt.wantTitle {
+				t.Errorf("ResponseStatusError() Title = %q, want %q", err.Title, tt.wantTitle)
+			}
+			if !strings.Contains(err.Message, `{"error": "details"}`) {
+				t.Errorf("ResponseStatusError() should contain the response body, got: %s", err.Message)
+			}
+			if code, ok := StatusCode(err); !ok || code != tt.status {
+				t.Errorf("StatusCode() = %d, %v, want %d, true", code, ok, tt.status)
+			}
+			if wait, ok := RetryAfter(err); !ok || wait != 3*time.Second {
+				t.Errorf("RetryAfter() = %v, %v, want 3s, true", wait, ok)
+			}
+		})
+	}
+}
+
+func TestHTTPStatusError_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "no header", header: http.Header{}, wantOK: false},
+		{name: "seconds", header: http.Header{"Retry-After": []string{"20"}}, want: 20 * time.Second, wantOK: true},
+		{name: "milliseconds", header: http.Header{"Retry-After-Ms": []string{"1500"}, "Retry-After": []string{"2"}}, want: 1500 * time.Millisecond, wantOK: true},
+		{name: "past date", header: http.Header{"Retry-After": []string{"Wed, 21 Oct 2015 07:28:00 GMT"}}, want: 0, wantOK: true},
+		{name: "invalid", header: http.Header{"Retry-After": []string{"soon"}}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &HTTPStatusError{StatusCode: 429, Header: tt.header}
+			got, ok := err.RetryAfter()
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("RetryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	err := &HTTPStatusError{StatusCode: 503, Header: http.Header{"Retry-After": []string{future}}}
+	if got, ok := err.RetryAfter(); !ok || got < 59*time.Minute || got > time.Hour {
+		t.Errorf("RetryAfter() of a date = %v, %v, want about 1h", got, ok)
+	}
+}
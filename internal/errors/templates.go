@@ -2,8 +2,10 @@ package errors
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Common error templates and constructors
@@ -348,6 +350,29 @@ func APIStatusError(statusCode int, responseBody string, cause error) *GPTCometE
 	)
 }
 
+// ResponseStatusError is returned when the API of provider responds with a non-OK
+// status code. Authentication and rate limit failures get their own templates.
+// The cause is an HTTPStatusError with the status code, headers and body of the
+// response, so callers can decide whether to retry.
+func ResponseStatusError(provider string, statusCode int, header http.Header, body string) *GPTCometError {
+	statusErr := &HTTPStatusError{StatusCode: statusCode, Header: header, Body: body}
+
+	var err *GPTCometError
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = APIAuthenticationError(provider, statusErr)
+	case http.StatusTooManyRequests:
+		err = APIRateLimitError(statusCode, statusErr)
+	default:
+		return APIStatusError(statusCode, body, statusErr)
+	}
+
+	if body != "" {
+		err.Message += fmt.Sprintf("\nResponse: %s", body)
+	}
+	return err
+}
+
 // CallbackError is returned when a callback function fails
 func CallbackError(cause error) *GPTCometError {
 	return NewValidationError(
@@ -387,6 +412,104 @@ func ProxyURLParseError(cause error) *GPTCometError {
 	)
 }
 
+// ContextWindowExceededError is returned when the prompt and the reserved answer
+// tokens don't fit in the context window of the model
+func ContextWindowExceededError(provider, model string, promptTokens, maxTokens, contextWindow int) *GPTCometError {
+	return NewValidationError(
+		ErrTitleContextWindow,
+		fmt.Sprintf(ErrMsgContextWindow, promptTokens, maxTokens, model, contextWindow),
+		nil,
+		[]string{
+			SuggIgnoreLargeFiles,
+			SuggEnableChunking,
+			fmt.Sprintf(SuggSetContextWindow, provider),
+		},
+	)
+}
+
+// StreamIdleTimeoutError is returned when a streaming response sends no data
+// for longer than the stream idle timeout
+func StreamIdleTimeoutError(provider string, timeout time.Duration) *GPTCometError {
+	return NewNetworkError(
+		ErrTitleStreamIdle,
+		fmt.Sprintf(ErrMsgStreamIdle, provider, timeout),
+		nil,
+		[]string{
+			SuggCheckProviderStatus,
+			fmt.Sprintf(SuggSetStreamIdleTimeout, provider),
+		},
+	)
+}
+
+// StreamTimeoutError is returned when a streaming response sends no data
+// within the request timeout
+func StreamTimeoutError(provider string, timeout time.Duration) *GPTCometError {
+	return NewNetworkError(
+		ErrTitleStreamTimeout,
+		fmt.Sprintf(ErrMsgStreamTimeout, provider, timeout),
+		nil,
+		[]string{
+			SuggCheckProviderStatus,
+			fmt.Sprintf(SuggSetTimeout, provider),
+		},
+	)
+}
+
+// ProviderCommandError is returned when the command of an exec provider is
+// not set, can't be started or exits with an error
+func ProviderCommandError(provider, command string, cause error) *GPTCometError {
+	return NewAPIError(
+		ErrTitleProviderCommand,
+		fmt.Sprintf(ErrMsgProviderCommand, command, cause),
+		cause,
+		[]string{
+			fmt.Sprintf(SuggSetProviderCommand, provider),
+			SuggRunProviderCommand,
+		},
+	)
+}
+
+// ModelListingNotSupportedError is returned when the provider has no API to
+// list its models
+func ModelListingNotSupportedError(provider string) *GPTCometError {
+	return NewAPIError(
+		ErrTitleModelListing,
+		fmt.Sprintf(ErrMsgModelListing, provider),
+		nil,
+		[]string{
+			SuggVisitDocs,
+			fmt.Sprintf(SuggSetValidModel, provider),
+		},
+	)
+}
+
+// NoCommitChangesError is returned when a commit has no changes left after
+// filtering with file_ignore
+func NoCommitChangesError(commit string) *GPTCometError {
+	return NewGitError(
+		ErrTitleNoCommitChanges,
+		fmt.Sprintf(ErrMsgNoCommitChanges, commit),
+		nil,
+		[]string{
+			fmt.Sprintf(SuggCheckCommit, commit),
+			SuggCheckFileIgnore,
+		},
+	)
+}
+
+// VCSUnsupportedError is returned when the version control system can't do
+// an operation
+func VCSUnsupportedError(vcs, operation string) *GPTCometError {
+	return NewGitError(
+		ErrTitleVCSUnsupported,
+		fmt.Sprintf(ErrMsgVCSUnsupported, vcs, operation),
+		nil,
+		[]string{
+			SuggUseGit,
+		},
+	)
+}
+
 // getAPIKeyEnvVar returns the environment variable name for the provider's API key
 func getAPIKeyEnvVar(provider string) string {
 	switch provider {
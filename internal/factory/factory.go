@@ -5,6 +5,7 @@ import (
 	"github.com/belingud/gptcomet/internal/config"
 	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/internal/git"
+	"github.com/belingud/gptcomet/internal/logger"
 	"github.com/belingud/gptcomet/pkg/types"
 )
 
@@ -78,9 +79,9 @@ func NewServiceDependenciesWithClient(options ServiceOptions) (*ServiceDependenc
 		return nil, gptErrors.DependencyCreationError("client config", err)
 	}
 
-	apiClient, err := client.New(clientConfig)
+	apiClient, err := NewClientWithFallbacks(cfgManager, clientConfig)
 	if err != nil {
-		return nil, gptErrors.DependencyCreationError("API client", err)
+		return nil, err
 	}
 
 	return &ServiceDependencies{
@@ -108,3 +109,36 @@ func NewAPIClient(clientConfig *types.ClientConfig) (client.ClientInterface, err
 	}
 	return apiClient, nil
 }
+
+// NewClientWithFallbacks creates an API client with the given configuration, which
+// falls back to the providers of fallback_providers in order when the provider fails.
+// Fallback providers that are not configured correctly are skipped with a warning,
+// as is the provider of clientConfig itself.
+//
+// Parameters:
+//   - cfgManager: Configuration manager to read the fallback providers from
+//   - clientConfig: Configuration for the primary provider
+//
+// Returns:
+//   - *client.Client: Configured API client
+//   - error: Error if client creation fails
+func NewClientWithFallbacks(cfgManager config.ManagerInterface, clientConfig *types.ClientConfig) (*client.Client, error) {
+	var fallbacks []*types.ClientConfig
+	for _, provider := range cfgManager.GetFallbackProviders() {
+		if provider == clientConfig.Provider {
+			continue
+		}
+		fallbackConfig, err := cfgManager.GetClientConfig(provider)
+		if err != nil {
+			logger.Warn("Skipping fallback provider %s: %v", provider, err)
+			continue
+		}
+		fallbacks = append(fallbacks, fallbackConfig)
+	}
+
+	apiClient, err := client.NewWithFallbacks(clientConfig, fallbacks)
+	if err != nil {
+		return nil, gptErrors.DependencyCreationError("API client", err)
+	}
+	return apiClient, nil
+}
@@ -1,10 +1,13 @@
 package factory
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -179,3 +182,20 @@ func TestServiceDependencies(t *testing.T) {
 		assert.Nil(t, deps.APIClient, "APIClient should be nil")
 	})
 }
+
+func TestNewClientWithFallbacks(t *testing.T) {
+	primary := &types.ClientConfig{Provider: "openai", APIKey: "key", Model: "gpt-4o"}
+
+	cfgManager := new(testutils.MockConfigManager)
+	cfgManager.On("GetFallbackProviders").Return([]string{"openai", "deepseek", "missing"})
+	cfgManager.On("GetClientConfig", "deepseek").Return(&types.ClientConfig{Provider: "deepseek", APIKey: "key", Model: "deepseek-chat"}, nil)
+	cfgManager.On("GetClientConfig", "missing").Return(nil, errors.New("provider config not found"))
+
+	client, err := NewClientWithFallbacks(cfgManager, primary)
+	require.NoError(t, err)
+	assert.NotNil(t, client)
+
+	// The primary provider itself is not added as a fallback
+	cfgManager.AssertNotCalled(t, "GetClientConfig", "openai")
+	cfgManager.AssertExpectations(t)
+}
@@ -0,0 +1,270 @@
+package git
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileStatus is the kind of change of a file in a diff
+type FileStatus string
+
+const (
+	FileModified FileStatus = "modified"
+	FileAdded    FileStatus = "added"
+	FileDeleted  FileStatus = "deleted"
+	FileRenamed  FileStatus = "renamed"
+	FileCopied   FileStatus = "copied"
+)
+
+// FileDiff is the diff of one file in a git diff
+type FileDiff struct {
+	// OldPath is the path before the change, empty for added files
+	OldPath string
+	// NewPath is the path after the change, empty for deleted files
+	NewPath string
+	Status  FileStatus
+	// Similarity is the similarity index of renamed and copied files, in percent
+	Similarity int
+	// OldMode and NewMode are the file modes, like "100644", when the diff
+	// shows them: for added and deleted files, and for mode changes
+	OldMode string
+	NewMode string
+	// Binary reports whether the file is binary. Its header then holds the
+	// binary patch, if the diff has one, and it has no hunks.
+	Binary bool
+	// Added and Deleted are the numbers of added and deleted lines
+	Added   int
+	Deleted int
+	// Header is the text of the diff before the first hunk, starting with the
+	// "diff --git" line
+	Header string
+	Hunks  []Hunk
+}
+
+// Hunk is a hunk of a file diff
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Context is the function context git prints after the line ranges of the
+	// hunk header, like "func main()", or empty if there is none
+	Context string
+	// Added and Deleted are the numbers of added and deleted lines
+	Added   int
+	Deleted int
+	// Text is the hunk as in the diff, starting with its "@@" header line
+	Text string
+}
+
+// hunkHeaderRegex matches a hunk header, like "@@ -1,3 +1,4 @@ func main()"
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParseDiff parses the output of git diff into the diffs of its files. The
+// text of every file is kept as is, so FormatDiff gives back the original
+// diff of the files.
+func ParseDiff(diff string) []FileDiff {
+	var files []FileDiff
+	var current *FileDiff
+	var header strings.Builder
+
+	flushHeader := func() {
+		if current != nil && len(current.Hunks) == 0 {
+			current.Header = header.String()
+		}
+	}
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		text := strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			flushHeader()
+			files = append(files, FileDiff{Status: FileModified})
+			current = &files[len(files)-1]
+			header.Reset()
+			header.WriteString(line)
+		case current == nil:
+			// Anything before the first file, which git diff doesn't print
+		case strings.HasPrefix(text, "@@ "):
+			flushHeader()
+			current.Hunks = append(current.Hunks, parseHunkHeader(text))
+			current.Hunks[len(current.Hunks)-1].Text = line
+		case len(current.Hunks) > 0:
+			hunk := &current.Hunks[len(current.Hunks)-1]
+			hunk.Text += line
+			switch {
+			case strings.HasPrefix(text, "+"):
+				hunk.Added++
+				current.Added++
+			case strings.HasPrefix(text, "-"):
+				hunk.Deleted++
+				current.Deleted++
+			}
+		default:
+			header.WriteString(line)
+			parseHeaderLine(current, text)
+		}
+	}
+	flushHeader()
+
+	for i := range files {
+		finishFileDiff(&files[i])
+	}
+	return files
+}
+
+// parseHunkHeader parses a hunk header line. The line counts default to 1,
+// as in "@@ -1 +1 @@".
+func parseHunkHeader(line string) Hunk {
+	match := hunkHeaderRegex.FindStringSubmatch(line)
+	if match == nil {
+		return Hunk{}
+	}
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	oldStart, _ := strconv.Atoi(match[1])
+	newStart, _ := strconv.Atoi(match[3])
+	return Hunk{
+		OldStart: oldStart,
+		OldLines: count(match[2]),
+		NewStart: newStart,
+		NewLines: count(match[4]),
+		Context:  match[5],
+	}
+}
+
+// parseHeaderLine reads a line of the header of a file diff into file
+func parseHeaderLine(file *FileDiff, line string) {
+	cut := func(prefix string) string {
+		return strings.TrimPrefix(line, prefix)
+	}
+
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		file.Status = FileAdded
+		file.NewMode = cut("new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		file.Status = FileDeleted
+		file.OldMode = cut("deleted file mode ")
+	case strings.HasPrefix(line, "old mode "):
+		file.OldMode = cut("old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		file.NewMode = cut("new mode ")
+	case strings.HasPrefix(line, "rename from "):
+		file.Status = FileRenamed
+		file.OldPath = unquotePath(cut("rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		file.NewPath = unquotePath(cut("rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		file.Status = FileCopied
+		file.OldPath = unquotePath(cut("copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		file.NewPath = unquotePath(cut("copy to "))
+	case strings.HasPrefix(line, "similarity index "):
+		file.Similarity, _ = strconv.Atoi(strings.TrimSuffix(cut("similarity index "), "%"))
+	case strings.HasPrefix(line, "--- "):
+		if path := headerPath(cut("--- ")); path != "/dev/null" {
+			file.OldPath = strings.TrimPrefix(unquotePath(path), "a/")
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if path := headerPath(cut("+++ ")); path != "/dev/null" {
+			file.NewPath = strings.TrimPrefix(unquotePath(path), "b/")
+		}
+	case strings.HasPrefix(line, "GIT binary patch"), strings.HasPrefix(line, "Binary files "):
+		file.Binary = true
+	}
+}
+
+// headerPath returns the path of a "---" or "+++" line without the tab git
+// appends to paths that contain a space
+func headerPath(path string) string {
+	return strings.TrimSuffix(path, "\t")
+}
+
+// finishFileDiff fills in the paths that the header of file doesn't have
+// lines for, from its "diff --git a/<path> b/<path>" line
+func finishFileDiff(file *FileDiff) {
+	if file.OldPath == "" && file.NewPath == "" {
+		gitLine, _, _ := strings.Cut(strings.TrimPrefix(file.Header, "diff --git "), "\n")
+		path := gitLinePath(gitLine)
+		file.OldPath, file.NewPath = path, path
+	}
+	switch file.Status {
+	case FileAdded:
+		file.OldPath = ""
+	case FileDeleted:
+		file.NewPath = ""
+	}
+}
+
+// gitLinePath returns the path of a "diff --git" line of a file that is not
+// renamed, where the old and new paths are the same
+func gitLinePath(gitLine string) string {
+	gitLine = strings.TrimRight(gitLine, "\r")
+	if strings.HasPrefix(gitLine, `"`) {
+		if i := strings.Index(gitLine[1:], `" `); i >= 0 {
+			return strings.TrimPrefix(unquotePath(gitLine[:i+2]), "a/")
+		}
+	}
+	if half := (len(gitLine) - 1) / 2; half > 2 && gitLine[half] == ' ' {
+		return gitLine[2:half]
+	}
+	return gitLine
+}
+
+// unquotePath unquotes a path that git quoted because of special characters
+func unquotePath(path string) string {
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+// Path returns the path of the file after the change, or before it for
+// deleted files
+func (f *FileDiff) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// ModeChanged reports whether the mode of a file that is kept changes
+func (f *FileDiff) ModeChanged() bool {
+	return f.OldMode != "" && f.NewMode != "" && f.OldMode != f.NewMode
+}
+
+// String returns the diff of the file, as in the parsed diff
+func (f *FileDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(f.Header)
+	for _, hunk := range f.Hunks {
+		sb.WriteString(hunk.Text)
+	}
+	return sb.String()
+}
+
+// Header returns the header line of the hunk, like "@@ -1,3 +1,4 @@ func main()"
+func (h *Hunk) Header() string {
+	line, _, _ := strings.Cut(h.Text, "\n")
+	return strings.TrimRight(line, "\r")
+}
+
+// FormatDiff returns the diff of files, as in the parsed diff
+func FormatDiff(files []FileDiff) string {
+	var sb strings.Builder
+	for i := range files {
+		sb.WriteString(files[i].String())
+	}
+	return sb.String()
+}
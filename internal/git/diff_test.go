@@ -0,0 +1,195 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1234567..89abcde 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,5 @@
+ package main
++
+ import "fmt"
+-import "os"
++import "strings"
+@@ -20,3 +21,2 @@ func main() {
+ 	fmt.Println("a")
+-	fmt.Println("b")
+ }
+\ No newline at end of file
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3b18e51
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/old.txt b/old.txt
+deleted file mode 100755
+index 3b18e51..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-hello
+diff --git a/a.go b/b.go
+similarity index 90%
+rename from a.go
+rename to b.go
+index 1234567..89abcde 100644
+--- a/a.go
++++ b/b.go
+@@ -1 +1 @@
+-package a
++package b
+diff --git a/c.go b/d.go
+similarity index 100%
+copy from c.go
+copy to d.go
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/img.png b/img.png
+index 1234567..89abcde 100644
+Binary files a/img.png and b/img.png differ
+diff --git "a/sp\303\244ce.txt" "b/sp\303\244ce.txt"
+index 1234567..89abcde 100644
+--- "a/sp\303\244ce.txt"
++++ "b/sp\303\244ce.txt"
+@@ -1 +1 @@
+-a
++b
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(testDiff)
+	require.Len(t, files, 8)
+
+	t.Run("modified file", func(t *testing.T) {
+		file := files[0]
+		assert.Equal(t, "main.go", file.OldPath)
+		assert.Equal(t, "main.go", file.NewPath)
+		assert.Equal(t, FileModified, file.Status)
+		assert.False(t, file.Binary)
+		assert.False(t, file.ModeChanged())
+		assert.Equal(t, 2, file.Added)
+		assert.Equal(t, 2, file.Deleted)
+		assert.Equal(t, "diff --git a/main.go b/main.go\nindex 1234567..89abcde 100644\n--- a/main.go\n+++ b/main.go\n", file.Header)
+
+		require.Len(t, file.Hunks, 2)
+		assert.Equal(t, Hunk{
+			OldStart: 1, OldLines: 4, NewStart: 1, NewLines: 5,
+			Added: 2, Deleted: 1,
+			Text: "@@ -1,4 +1,5 @@\n package main\n+\n import \"fmt\"\n-import \"os\"\n+import \"strings\"\n",
+		}, file.Hunks[0])
+		assert.Equal(t, "@@ -1,4 +1,5 @@", file.Hunks[0].Header())
+
+		hunk := file.Hunks[1]
+		assert.Equal(t, "func main() {", hunk.Context)
+		assert.Equal(t, "@@ -20,3 +21,2 @@ func main() {", hunk.Header())
+		assert.Equal(t, []int{20, 3, 21, 2}, []int{hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines})
+		assert.Equal(t, 0, hunk.Added)
+		assert.Equal(t, 1, hunk.Deleted)
+		assert.Contains(t, hunk.Text, "\\ No newline at end of file\n")
+	})
+
+	t.Run("added file", func(t *testing.T) {
+		file := files[1]
+		assert.Equal(t, FileAdded, file.Status)
+		assert.Empty(t, file.OldPath)
+		assert.Equal(t, "new.txt", file.NewPath)
+		assert.Equal(t, "100644", file.NewMode)
+		assert.Equal(t, 1, file.Added)
+		require.Len(t, file.Hunks, 1)
+		assert.Equal(t, []int{0, 0, 1, 1}, []int{file.Hunks[0].OldStart, file.Hunks[0].OldLines, file.Hunks[0].NewStart, file.Hunks[0].NewLines})
+	})
+
+	t.Run("deleted file", func(t *testing.T) {
+		file := files[2]
+		assert.Equal(t, FileDeleted, file.Status)
+		assert.Equal(t, "old.txt", file.OldPath)
+		assert.Empty(t, file.NewPath)
+		assert.Equal(t, "old.txt", file.Path())
+		assert.Equal(t, "100755", file.OldMode)
+		assert.False(t, file.ModeChanged())
+		assert.Equal(t, 1, file.Deleted)
+	})
+
+	t.Run("renamed file", func(t *testing.T) {
+		file := files[3]
+		assert.Equal(t, FileRenamed, file.Status)
+		assert.Equal(t, "a.go", file.OldPath)
+		assert.Equal(t, "b.go", file.NewPath)
+		assert.Equal(t, 90, file.Similarity)
+		assert.Len(t, file.Hunks, 1)
+	})
+
+	t.Run("copied file", func(t *testing.T) {
+		file := files[4]
+		assert.Equal(t, FileCopied, file.Status)
+		assert.Equal(t, "c.go", file.OldPath)
+		assert.Equal(t, "d.go", file.NewPath)
+		assert.Equal(t, 100, file.Similarity)
+		assert.Empty(t, file.Hunks)
+	})
+
+	t.Run("mode change", func(t *testing.T) {
+		file := files[5]
+		assert.Equal(t, FileModified, file.Status)
+		assert.Equal(t, "run.sh", file.Path())
+		assert.Equal(t, "run.sh", file.OldPath)
+		assert.True(t, file.ModeChanged())
+		assert.Equal(t, "100644", file.OldMode)
+		assert.Equal(t, "100755", file.NewMode)
+	})
+
+	t.Run("binary file", func(t *testing.T) {
+		file := files[6]
+		assert.True(t, file.Binary)
+		assert.Equal(t, "img.png", file.Path())
+		assert.Empty(t, file.Hunks)
+	})
+
+	t.Run("quoted path", func(t *testing.T) {
+		assert.Equal(t, "späce.txt", files[7].OldPath)
+		assert.Equal(t, "späce.txt", files[7].NewPath)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		assert.Equal(t, testDiff, FormatDiff(files))
+	})
+}
+
+func TestParseDiff_PathWithSpace(t *testing.T) {
+	diff := "diff --git a/foo bar.txt b/foo bar.txt\n" +
+		"index 1234567..89abcde 100644\n" +
+		"--- a/foo bar.txt\t\n" +
+		"+++ b/foo bar.txt\t\n" +
+		"@@ -1 +1 @@\n" +
+		"-a\n" +
+		"+b\n"
+
+	files := ParseDiff(diff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "foo bar.txt", files[0].OldPath)
+	assert.Equal(t, "foo bar.txt", files[0].Path())
+	assert.Equal(t, diff, FormatDiff(files))
+}
+
+func TestParseDiff_Empty(t *testing.T) {
+	assert.Empty(t, ParseDiff(""))
+	assert.Empty(t, FormatDiff(nil))
+}
+
+func TestFilterIgnoredFiles(t *testing.T) {
+	files := filterIgnoredFiles(ParseDiff(testDiff), []string{"*.txt", "img.png"})
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path())
+	}
+	assert.Equal(t, []string{"main.go", "b.go", "d.go", "run.sh"}, paths)
+}
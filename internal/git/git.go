@@ -123,6 +123,11 @@ func ShouldIgnoreFile(file string, ignorePatterns []string) bool {
 	return false
 }
 
+// parseableDiffArgs make git diff print a diff that ParseDiff can read whatever
+// the user configuration: no colors, no external diff tool, and the a/ and b/
+// prefixes even with diff.mnemonicPrefix or diff.noprefix set.
+var parseableDiffArgs = []string{"--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/"}
+
 // GetStagedDiffFiltered returns the git diff for staged changes, excluding files that match the patterns
 // specified in the config manager under the "file_ignore" key.
 //
@@ -137,60 +142,106 @@ func ShouldIgnoreFile(file string, ignorePatterns []string) bool {
 // The function will return an empty string if there are no staged files in the repository.
 // If the git command fails, it returns a detailed error message including the exit code.
 func (g *GitVCS) GetStagedDiffFiltered(repoPath string, cfgManager config.ManagerInterface) (string, error) {
-	// get staged files
-	stagedFiles, err := g.GetStagedFiles(repoPath)
+	cmd := exec.Command("git", append([]string{"diff", "--staged", "-U2"}, parseableDiffArgs...)...)
+	output, err := g.runCommand(cmd, repoPath)
 	if err != nil {
 		return "", err
 	}
-	debug.Printf("Staged files: %v", stagedFiles)
 
-	// get ignore patterns
-	var ignorePatterns []string
-	if patterns, ok := cfgManager.Get("file_ignore"); ok {
-		if patternList, ok := patterns.([]interface{}); ok {
-			for _, p := range patternList {
-				if str, ok := p.(string); ok {
-					ignorePatterns = append(ignorePatterns, str)
-				}
-			}
-		}
-	}
+	ignorePatterns := getIgnorePatterns(cfgManager)
 	debug.Printf("Ignore patterns: %v", ignorePatterns)
 
 	// if no ignore patterns, return the diff as is
 	if len(ignorePatterns) == 0 {
-		cmd := exec.Command("git", "diff", "--staged", "-U2")
-		return g.runCommand(cmd, repoPath)
+		return output, nil
 	}
 
-	// filter out ignored files
-	var excludeFiles []string
-	for _, file := range stagedFiles {
-		if ShouldIgnoreFile(file, ignorePatterns) {
-			// git diff --staged -U2 -- :!file
-			excludeFiles = append(excludeFiles, ":!"+file)
-		}
-	}
-	debug.Printf("Files to exclude: %v", excludeFiles)
-
 	// return if all staged files are ignored
-	if len(excludeFiles) == len(stagedFiles) {
+	files := filterIgnoredFiles(ParseDiff(output), ignorePatterns)
+	if len(files) == 0 {
 		return "", gptcometerrors.NoStagedChangesError()
 	}
+	return FormatDiff(files), nil
+}
 
-	// if there are no ignored files, return the diff directly
-	if len(excludeFiles) == 0 {
-		cmd := exec.Command("git", "diff", "--staged", "-U2")
-		return g.runCommand(cmd, repoPath)
+// GetCommitDiff returns the git diff of a commit against its parent, excluding files
+// that match the file_ignore patterns. Merge commits are compared with their first
+// parent, which is what they bring into the branch, and root commits with the empty tree.
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - commit: The commit to diff, like "HEAD" or a hash
+//   - staged: Whether to include the staged changes, as if the commit were amended
+//   - cfgManager: The config manager to use for retrieving ignore patterns
+//
+// Returns:
+//   - string: The filtered diff output, empty if all changed files are ignored
+//   - error: An error if the commit doesn't exist or the git command fails
+func (g *GitVCS) GetCommitDiff(repoPath, commit string, staged bool, cfgManager config.ManagerInterface) (string, error) {
+	base, err := g.getParentOrEmptyTree(repoPath, commit)
+	if err != nil {
+		return "", err
 	}
 
-	// git diff --staged -U2 -- :!file1 :!file2
-	args := []string{"diff", "--staged", "-U2", "--"}
-	args = append(args, excludeFiles...)
-	debug.Printf("Diff command: git %v", args)
+	// git diff <parent> <commit>, or git diff --staged <parent> for the index
+	args := []string{"diff", "-U2", base, commit}
+	if staged {
+		args = []string{"diff", "-U2", "--staged", base}
+	}
+	output, err := g.runCommand(exec.Command("git", append(args, parseableDiffArgs...)...), repoPath)
+	if err != nil {
+		return "", err
+	}
 
-	cmd := exec.Command("git", args...)
-	return g.runCommand(cmd, repoPath)
+	return FormatDiff(filterIgnoredFiles(ParseDiff(output), getIgnorePatterns(cfgManager))), nil
+}
+
+// getParentOrEmptyTree returns the first parent of commit, or the empty tree if
+// it is a root commit
+func (g *GitVCS) getParentOrEmptyTree(repoPath, commit string) (string, error) {
+	// Prints the commit followed by its parents
+	cmd := exec.Command("git", "rev-list", "--parents", "-n", "1", commit, "--")
+	output, err := g.runCommand(cmd, repoPath)
+	if err != nil {
+		return "", err
+	}
+	if hashes := strings.Fields(output); len(hashes) > 1 {
+		return hashes[1], nil
+	}
+
+	// The hash of the empty tree depends on the hash algorithm of the repository
+	cmd = exec.Command("git", "hash-object", "-t", "tree", "--stdin")
+	output, err = g.runCommand(cmd, repoPath)
+	return strings.TrimSpace(output), err
+}
+
+// getIgnorePatterns returns the file_ignore patterns of the config
+func getIgnorePatterns(cfgManager config.ManagerInterface) []string {
+	var ignorePatterns []string
+	if patterns, ok := cfgManager.Get("file_ignore"); ok {
+		if patternList, ok := patterns.([]interface{}); ok {
+			for _, p := range patternList {
+				if str, ok := p.(string); ok {
+					ignorePatterns = append(ignorePatterns, str)
+				}
+			}
+		}
+	}
+	return ignorePatterns
+}
+
+// filterIgnoredFiles returns the files whose path doesn't match any of the
+// ignore patterns. The old path of renamed files is not checked.
+func filterIgnoredFiles(files []FileDiff, ignorePatterns []string) []FileDiff {
+	var kept []FileDiff
+	for _, file := range files {
+		if ShouldIgnoreFile(file.Path(), ignorePatterns) {
+			debug.Printf("Ignoring file: %s", file.Path())
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept
 }
 
 // GetCurrentBranch returns the name of the current branch in the git repository
@@ -296,6 +347,27 @@ func (g *GitVCS) CreateCommit(repoPath string, message string, noVerify bool) er
 	return err
 }
 
+// AmendCommit replaces the last commit with one that has the given message and
+// the staged changes, like 'git commit --amend -m <message>'.
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - message: The commit message
+//   - noVerify: Whether to skip git hooks verification
+//
+// Returns:
+//   - error: An error if the git command fails or if there are issues accessing the repository
+func (g *GitVCS) AmendCommit(repoPath string, message string, noVerify bool) error {
+	args := []string{"commit", "--amend", "-m", message}
+	if noVerify {
+		args = append(args, "--no-verify")
+	}
+	debug.Printf("Amending commit with args: %v", args)
+	cmd := exec.Command("git", args...)
+	_, err := g.runCommand(cmd, repoPath)
+	return err
+}
+
 // runCommand executes a given git command in the specified repository path and returns its output.
 // It captures both stdout and stderr, returning the stdout output as a string if successful.
 // If the command fails, it returns an error that includes both the original error and stderr output.
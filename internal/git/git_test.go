@@ -2,7 +2,9 @@ package git
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/belingud/gptcomet/internal/testutils"
@@ -162,3 +164,163 @@ func TestNewVCS(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCommitDiff(t *testing.T) {
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	defer cleanup()
+	g := &GitVCS{}
+
+	noIgnore := &testutils.MockConfigManager{}
+	noIgnore.On("Get", "file_ignore").Return(nil, false)
+	ignoreLock := &testutils.MockConfigManager{}
+	ignoreLock.On("Get", "file_ignore").Return([]interface{}{"*.lock"}, true)
+
+	commitFile := func(name, content, message string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", name))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "commit", "-m", message))
+	}
+
+	t.Run("root commit", func(t *testing.T) {
+		commitFile("root.txt", "root content\n", "root")
+
+		diff, err := g.GetCommitDiff(repoPath, "HEAD", false, noIgnore)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "+root content")
+	})
+
+	t.Run("commit against its parent", func(t *testing.T) {
+		commitFile("second.txt", "second content\n", "second")
+
+		diff, err := g.GetCommitDiff(repoPath, "HEAD", false, noIgnore)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "+second content")
+		assert.NotContains(t, diff, "root content")
+	})
+
+	t.Run("with staged changes", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, "staged.txt"), []byte("staged content\n"), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "staged.txt"))
+
+		diff, err := g.GetCommitDiff(repoPath, "HEAD", true, noIgnore)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "+second content")
+		assert.Contains(t, diff, "+staged content")
+
+		diff, err = g.GetCommitDiff(repoPath, "HEAD", false, noIgnore)
+		require.NoError(t, err)
+		assert.NotContains(t, diff, "staged content")
+
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "commit", "-m", "staged"))
+	})
+
+	t.Run("ignored files", func(t *testing.T) {
+		commitFile("deps.lock", "locked\n", "lock")
+
+		diff, err := g.GetCommitDiff(repoPath, "HEAD", false, ignoreLock)
+		require.NoError(t, err)
+		assert.Empty(t, diff)
+	})
+
+	t.Run("merge commit against its first parent", func(t *testing.T) {
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "-b", "feature"))
+		commitFile("feature.txt", "feature content\n", "feature")
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "master"))
+		commitFile("main.txt", "main content\n", "main")
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "merge", "--no-ff", "-m", "merge feature", "feature"))
+
+		diff, err := g.GetCommitDiff(repoPath, "HEAD", false, noIgnore)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "+feature content")
+		assert.NotContains(t, diff, "main content")
+	})
+
+	t.Run("unknown commit", func(t *testing.T) {
+		_, err := g.GetCommitDiff(repoPath, "does-not-exist", false, noIgnore)
+		assert.Error(t, err)
+	})
+}
+
+func TestAmendCommit(t *testing.T) {
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	defer cleanup()
+	g := &GitVCS{}
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("a\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "a.txt"))
+	require.NoError(t, g.CreateCommit(repoPath, "first message", false))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "b.txt"), []byte("b\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "b.txt"))
+	require.NoError(t, g.AmendCommit(repoPath, "amended message", false))
+
+	info, err := g.GetCommitInfo(repoPath, "")
+	require.NoError(t, err)
+	assert.Contains(t, info, "amended message")
+	assert.Contains(t, info, "b.txt")
+
+	count, err := g.runCommand(exec.Command("git", "rev-list", "--count", "HEAD"), repoPath)
+	require.NoError(t, err)
+	assert.Equal(t, "1", strings.TrimSpace(count))
+}
+
+func TestGetStagedDiffFiltered(t *testing.T) {
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	defer cleanup()
+	g := &GitVCS{}
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "main.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "go.sum"), []byte("sum\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "."))
+
+	cfg := &testutils.MockConfigManager{}
+	cfg.On("Get", "file_ignore").Return([]interface{}{"go.sum"}, true)
+	diff, err := g.GetStagedDiffFiltered(repoPath, cfg)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+++ b/main.go")
+	assert.NotContains(t, diff, "go.sum")
+
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "reset", "-q", "main.go"))
+	_, err = g.GetStagedDiffFiltered(repoPath, cfg)
+	assert.Error(t, err)
+}
+
+func TestGetStagedDiffFiltered_Paths(t *testing.T) {
+	cfg := &testutils.MockConfigManager{}
+	cfg.On("Get", "file_ignore").Return([]interface{}{"go.sum"}, true)
+	g := &GitVCS{}
+
+	t.Run("path with a space", func(t *testing.T) {
+		repoPath, cleanup := testutils.TestGitRepo(t)
+		defer cleanup()
+
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, "foo bar.txt"), []byte("a\n"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, "go.sum"), []byte("sum\n"), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "."))
+
+		diff, err := g.GetStagedDiffFiltered(repoPath, cfg)
+		require.NoError(t, err)
+		files := ParseDiff(diff)
+		require.Len(t, files, 1)
+		assert.Equal(t, "foo bar.txt", files[0].Path())
+	})
+
+	t.Run("mnemonic prefix", func(t *testing.T) {
+		repoPath, cleanup := testutils.TestGitRepo(t)
+		defer cleanup()
+
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "config", "diff.mnemonicPrefix", "true"))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "config", "color.ui", "always"))
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, "main.go"), []byte("package main\n"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, "go.sum"), []byte("sum\n"), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "."))
+
+		diff, err := g.GetStagedDiffFiltered(repoPath, cfg)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "+++ b/main.go")
+		assert.NotContains(t, diff, "go.sum")
+		files := ParseDiff(diff)
+		require.Len(t, files, 1)
+		assert.Equal(t, "main.go", files[0].Path())
+	})
+}
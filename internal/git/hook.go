@@ -0,0 +1,131 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// PrepareCommitMsgHook is the name of the hook that fills in the commit message
+	PrepareCommitMsgHook = "prepare-commit-msg"
+
+	// hookMarker identifies the hooks installed by gptcomet
+	hookMarker = "# Installed by gptcomet"
+	// chainedHookSuffix is added to the name of a hook that existed before
+	// gptcomet installed its own, which runs it first
+	chainedHookSuffix = ".pre-gptcomet"
+)
+
+// hookScript is the hook installed by InstallHook. It runs the previous hook
+// first, then the command, whose failure never fails the hook.
+const hookScript = `#!/bin/sh
+%s, remove it with: gmsg hook uninstall
+
+previous="$(dirname "$0")/%s"
+if [ -x "$previous" ]; then
+	"$previous" "$@" || exit $?
+fi
+
+%s "$@" || true
+`
+
+// GetHooksDir returns the absolute path of the hooks directory of the
+// repository, which respects the core.hooksPath setting.
+func (g *GitVCS) GetHooksDir(repoPath string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	output, err := g.runCommand(cmd, repoPath)
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(output)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return filepath.Abs(dir)
+}
+
+// GetCommentChar returns the character that starts the comment lines of commit
+// messages, as set by core.commentChar: "#" by default, or "auto" if git picks
+// one that no line of the message starts with.
+func (g *GitVCS) GetCommentChar(repoPath string) string {
+	cmd := exec.Command("git", "config", "core.commentChar")
+	output, err := g.runCommand(cmd, repoPath)
+	if char := strings.TrimSpace(output); err == nil && char != "" {
+		return char
+	}
+	return "#"
+}
+
+// InstallHook installs the hook name in hooksDir, which runs command with the
+// arguments of the hook. An existing hook that was not installed by gptcomet is
+// kept and run before command. Installing the hook again updates it.
+//
+// It returns the path of the previous hook that is chained, or "" if there is none.
+func InstallHook(hooksDir, name, command string) (string, error) {
+	path := filepath.Join(hooksDir, name)
+	chained := path + chainedHookSuffix
+
+	if data, err := os.ReadFile(path); err == nil && !isGptcometHook(data) {
+		if _, err := os.Stat(chained); err == nil {
+			return "", fmt.Errorf("can't chain the existing %s hook, %s already exists", name, chained)
+		}
+		if err := os.Rename(path, chained); err != nil {
+			return "", fmt.Errorf("failed to keep the existing %s hook: %w", name, err)
+		}
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read the existing %s hook: %w", name, err)
+	}
+
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	script := fmt.Sprintf(hookScript, hookMarker, filepath.Base(chained), command)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write the %s hook: %w", name, err)
+	}
+
+	if _, err := os.Stat(chained); err == nil {
+		return chained, nil
+	}
+	return "", nil
+}
+
+// UninstallHook removes the hook name installed by InstallHook from hooksDir,
+// and puts back the hook it chained, if any.
+//
+// It returns the path of the restored hook, or "" if there was none.
+func UninstallHook(hooksDir, name string) (string, error) {
+	path := filepath.Join(hooksDir, name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no %s hook is installed in %s", name, hooksDir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the %s hook: %w", name, err)
+	}
+	if !isGptcometHook(data) {
+		return "", fmt.Errorf("the %s hook in %s was not installed by gptcomet", name, hooksDir)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove the %s hook: %w", name, err)
+	}
+
+	chained := path + chainedHookSuffix
+	if _, err := os.Stat(chained); err != nil {
+		return "", nil
+	}
+	if err := os.Rename(chained, path); err != nil {
+		return "", fmt.Errorf("failed to restore the previous %s hook: %w", name, err)
+	}
+	return path, nil
+}
+
+// isGptcometHook reports whether the hook script was installed by gptcomet
+func isGptcometHook(script []byte) bool {
+	return strings.Contains(string(script), hookMarker)
+}
@@ -0,0 +1,157 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScript(t *testing.T, path, script string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+}
+
+func TestInstallHook(t *testing.T) {
+	t.Run("new hook", func(t *testing.T) {
+		hooksDir := filepath.Join(t.TempDir(), "hooks")
+
+		chained, err := InstallHook(hooksDir, PrepareCommitMsgHook, "'/usr/bin/gmsg' hook run")
+		require.NoError(t, err)
+		assert.Empty(t, chained)
+
+		data, err := os.ReadFile(filepath.Join(hooksDir, PrepareCommitMsgHook))
+		require.NoError(t, err)
+		assert.True(t, isGptcometHook(data))
+		assert.Contains(t, string(data), `'/usr/bin/gmsg' hook run "$@" || true`)
+	})
+
+	t.Run("chains the existing hook", func(t *testing.T) {
+		hooksDir := t.TempDir()
+		path := filepath.Join(hooksDir, PrepareCommitMsgHook)
+		writeScript(t, path, "echo previous")
+
+		chained, err := InstallHook(hooksDir, PrepareCommitMsgHook, "gmsg hook run")
+		require.NoError(t, err)
+		assert.Equal(t, path+chainedHookSuffix, chained)
+
+		data, err := os.ReadFile(chained)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "echo previous")
+
+		// Installing again updates the hook and keeps the chained one
+		chained, err = InstallHook(hooksDir, PrepareCommitMsgHook, "gmsg --config x hook run")
+		require.NoError(t, err)
+		assert.Equal(t, path+chainedHookSuffix, chained)
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "gmsg --config x hook run")
+	})
+
+	t.Run("refuses to overwrite a chained hook", func(t *testing.T) {
+		hooksDir := t.TempDir()
+		path := filepath.Join(hooksDir, PrepareCommitMsgHook)
+		writeScript(t, path, "echo new")
+		writeScript(t, path+chainedHookSuffix, "echo old")
+
+		_, err := InstallHook(hooksDir, PrepareCommitMsgHook, "gmsg hook run")
+		assert.Error(t, err)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "echo new")
+	})
+}
+
+func TestUninstallHook(t *testing.T) {
+	t.Run("restores the chained hook", func(t *testing.T) {
+		hooksDir := t.TempDir()
+		path := filepath.Join(hooksDir, PrepareCommitMsgHook)
+		writeScript(t, path, "echo previous")
+		_, err := InstallHook(hooksDir, PrepareCommitMsgHook, "gmsg hook run")
+		require.NoError(t, err)
+
+		restored, err := UninstallHook(hooksDir, PrepareCommitMsgHook)
+		require.NoError(t, err)
+		assert.Equal(t, path, restored)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "echo previous")
+		assert.NoFileExists(t, path+chainedHookSuffix)
+	})
+
+	t.Run("removes the hook", func(t *testing.T) {
+		hooksDir := t.TempDir()
+		_, err := InstallHook(hooksDir, PrepareCommitMsgHook, "gmsg hook run")
+		require.NoError(t, err)
+
+		restored, err := UninstallHook(hooksDir, PrepareCommitMsgHook)
+		require.NoError(t, err)
+		assert.Empty(t, restored)
+		assert.NoFileExists(t, filepath.Join(hooksDir, PrepareCommitMsgHook))
+	})
+
+	t.Run("keeps other hooks", func(t *testing.T) {
+		hooksDir := t.TempDir()
+		path := filepath.Join(hooksDir, PrepareCommitMsgHook)
+		writeScript(t, path, "echo other")
+
+		_, err := UninstallHook(hooksDir, PrepareCommitMsgHook)
+		assert.Error(t, err)
+		assert.FileExists(t, path)
+	})
+
+	t.Run("no hook", func(t *testing.T) {
+		_, err := UninstallHook(t.TempDir(), PrepareCommitMsgHook)
+		assert.Error(t, err)
+	})
+}
+
+func TestHookInRepository(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	defer cleanup()
+
+	g := &GitVCS{}
+	hooksDir, err := g.GetHooksDir(repoPath)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repoPath, ".git", "hooks"), hooksDir)
+
+	// The previous hook adds a trailer, the command writes the message on top
+	writeScript(t, filepath.Join(hooksDir, PrepareCommitMsgHook), `echo "Signed-off-by: Test User" >> "$1"`)
+	generator := filepath.Join(t.TempDir(), "generate.sh")
+	writeScript(t, generator, `printf 'feat: generated\n\n' | cat - "$1" > "$1.tmp" && mv "$1.tmp" "$1"`)
+	_, err = InstallHook(hooksDir, PrepareCommitMsgHook, generator)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("content\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "file.txt"))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "-c", "core.editor=true", "commit"))
+
+	msg, err := g.runCommand(exec.Command("git", "log", "-1", "--format=%B"), repoPath)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: generated\n\nSigned-off-by: Test User", strings.TrimSpace(msg))
+}
+
+func TestGetCommentChar(t *testing.T) {
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	defer cleanup()
+	g := &GitVCS{}
+
+	assert.Equal(t, "#", g.GetCommentChar(repoPath))
+
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "config", "core.commentChar", ";"))
+	assert.Equal(t, ";", g.GetCommentChar(repoPath))
+}
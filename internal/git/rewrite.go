@@ -0,0 +1,288 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RewritePlan describes the commits to rewrite when changing the messages of
+// a range of commits on the current branch
+type RewritePlan struct {
+	// Branch is the current branch, which is updated to the rewritten history
+	Branch string
+	// Head is the commit the branch points to
+	Head string
+	// Commits are the commits of the range, oldest first
+	Commits []string
+	// Chain are the commits from the start of the range up to Head, oldest
+	// first. The descendants of a reworded commit get new hashes too.
+	Chain []string
+	// Pushed are the commits of Chain that are reachable from a remote-tracking branch
+	Pushed []string
+	// Signed are the commits of Chain with a signature or a merged signed tag,
+	// which rewriting drops
+	Signed []string
+}
+
+// commitObject is the content of a commit, as stored by git
+type commitObject struct {
+	tree        string
+	parents     []string
+	authorName  string
+	authorEmail string
+	// authorDate is in the raw format of git, "<unix time> <time zone>"
+	authorDate string
+	// encoding is the encoding of message, empty for UTF-8
+	encoding string
+	// signed is set if the commit has a signature or merges a signed tag
+	signed  bool
+	message string
+}
+
+// PlanRewrite returns the commits to rewrite to change the messages of the
+// commits in revRange, like "origin/main..HEAD". A single revision means the
+// commits after it up to HEAD, like for git rebase. The range must be on the
+// current branch.
+func (g *GitVCS) PlanRewrite(repoPath, revRange string) (*RewritePlan, error) {
+	if strings.Contains(revRange, "...") {
+		return nil, fmt.Errorf("symmetric range %q is not supported, use <base>..<commit>", revRange)
+	}
+	if !strings.Contains(revRange, "..") && !strings.HasPrefix(revRange, "^") {
+		revRange += "..HEAD"
+	}
+
+	branch, err := g.GetCurrentBranch(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if branch == "HEAD" {
+		return nil, fmt.Errorf("HEAD is detached, check out a branch to rewrite its commits")
+	}
+	head, err := g.GetLastCommitHash(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	head = strings.TrimSpace(head)
+
+	commits, err := g.revList(repoPath, revRange)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits in range %s", revRange)
+	}
+
+	// The excluded side of the range, like ^origin/main
+	output, err := g.runCommand(exec.Command("git", "rev-parse", revRange), repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var excluded []string
+	for _, rev := range strings.Fields(output) {
+		if strings.HasPrefix(rev, "^") {
+			excluded = append(excluded, rev)
+		}
+	}
+
+	chain, err := g.revList(repoPath, append([]string{head}, excluded...)...)
+	if err != nil {
+		return nil, err
+	}
+	inChain := make(map[string]bool, len(chain))
+	for _, commit := range chain {
+		inChain[commit] = true
+	}
+	for _, commit := range commits {
+		if !inChain[commit] {
+			return nil, fmt.Errorf("commit %s of range %s is not on branch %s", ShortHash(commit), revRange, branch)
+		}
+	}
+
+	unpushed, err := g.revList(repoPath, append(append([]string{head}, excluded...), "--not", "--remotes")...)
+	if err != nil {
+		return nil, err
+	}
+	isUnpushed := make(map[string]bool, len(unpushed))
+	for _, commit := range unpushed {
+		isUnpushed[commit] = true
+	}
+	var pushed []string
+	for _, commit := range chain {
+		if !isUnpushed[commit] {
+			pushed = append(pushed, commit)
+		}
+	}
+
+	var signed []string
+	for _, commit := range chain {
+		obj, err := g.readCommit(repoPath, commit)
+		if err != nil {
+			return nil, err
+		}
+		if obj.signed {
+			signed = append(signed, commit)
+		}
+	}
+
+	return &RewritePlan{
+		Branch:  branch,
+		Head:    head,
+		Commits: commits,
+		Chain:   chain,
+		Pushed:  pushed,
+		Signed:  signed,
+	}, nil
+}
+
+// RewriteMessages rewrites the commits of plan with the new messages, keyed by
+// commit hash, and points the branch of plan to the rewritten history. Trees,
+// authors, author dates and the encodings of kept messages are kept, the
+// descendants of a reworded commit are recreated on top of it, and commits
+// before the first reworded one are kept as they are. Signatures are dropped. It doesn't touch the index or the working tree, which match the
+// rewritten history because only messages change.
+//
+// It returns the new hash of the branch head.
+func (g *GitVCS) RewriteMessages(repoPath string, plan *RewritePlan, messages map[string]string) (string, error) {
+	rewritten := make(map[string]string)
+	for _, commit := range plan.Chain {
+		obj, err := g.readCommit(repoPath, commit)
+		if err != nil {
+			return "", err
+		}
+
+		parentsChanged := false
+		parents := make([]string, len(obj.parents))
+		for i, parent := range obj.parents {
+			parents[i] = parent
+			if newParent, ok := rewritten[parent]; ok {
+				parents[i] = newParent
+				parentsChanged = true
+			}
+		}
+
+		message, reword := messages[commit]
+		if !reword && !parentsChanged {
+			continue
+		}
+		if reword {
+			// New messages are UTF-8
+			obj.encoding = ""
+		} else {
+			message = obj.message
+		}
+
+		newCommit, err := g.commitTree(repoPath, obj, parents, message)
+		if err != nil {
+			return "", err
+		}
+		rewritten[commit] = newCommit
+	}
+
+	newHead, ok := rewritten[plan.Head]
+	if !ok {
+		return plan.Head, nil
+	}
+
+	// Only update the branch if it still points to the planned head
+	cmd := exec.Command("git", "update-ref", "-m", "gptcomet: reword", "refs/heads/"+plan.Branch, newHead, plan.Head)
+	if _, err := g.runCommand(cmd, repoPath); err != nil {
+		return "", err
+	}
+	return newHead, nil
+}
+
+// GetCommitMessage returns the full message of commit
+func (g *GitVCS) GetCommitMessage(repoPath, commit string) (string, error) {
+	obj, err := g.readCommit(repoPath, commit)
+	if err != nil {
+		return "", err
+	}
+	return obj.message, nil
+}
+
+// revList returns the commits selected by the rev-list arguments, oldest first
+func (g *GitVCS) revList(repoPath string, args ...string) ([]string, error) {
+	args = append([]string{"rev-list", "--reverse", "--topo-order"}, args...)
+	output, err := g.runCommand(exec.Command("git", append(args, "--")...), repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+// readCommit reads the raw commit object of commit
+func (g *GitVCS) readCommit(repoPath, commit string) (*commitObject, error) {
+	output, err := g.runCommand(exec.Command("git", "cat-file", "commit", commit), repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	header, message, _ := strings.Cut(output, "\n\n")
+	obj := &commitObject{message: message}
+	for _, line := range strings.Split(header, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			obj.tree = value
+		case "parent":
+			obj.parents = append(obj.parents, value)
+		case "author":
+			// Name <email> 1700000000 +0100
+			name, rest, _ := strings.Cut(value, " <")
+			email, date, _ := strings.Cut(rest, "> ")
+			obj.authorName, obj.authorEmail, obj.authorDate = name, email, date
+		case "encoding":
+			obj.encoding = value
+		case "gpgsig", "gpgsig-sha256", "mergetag":
+			obj.signed = true
+		}
+	}
+	if obj.tree == "" {
+		return nil, fmt.Errorf("failed to read commit %s", ShortHash(commit))
+	}
+	return obj, nil
+}
+
+// commitTree creates a commit with the tree, author and message encoding of
+// obj, and returns its hash
+func (g *GitVCS) commitTree(repoPath string, obj *commitObject, parents []string, message string) (string, error) {
+	var args []string
+	if obj.encoding != "" {
+		// Records the encoding header, the message is passed through as is
+		args = append(args, "-c", "i18n.commitEncoding="+obj.encoding)
+	}
+	args = append(args, "commit-tree", obj.tree)
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-F", "-")
+
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+obj.authorName,
+		"GIT_AUTHOR_EMAIL="+obj.authorEmail,
+		"GIT_AUTHOR_DATE="+obj.authorDate,
+	)
+	output, err := g.runCommand(cmd, repoPath)
+	return strings.TrimSpace(output), err
+}
+
+// ShortHash shortens a commit hash for output
+func ShortHash(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// FirstLine returns the first line of a commit message
+func FirstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return line
+}
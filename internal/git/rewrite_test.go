@@ -0,0 +1,214 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRewriteRepo creates a repository with the commits "commit 1" to "commit n"
+func setupRewriteRepo(t *testing.T, n int) (*GitVCS, string) {
+	t.Helper()
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	t.Cleanup(cleanup)
+
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(name+"\n"), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", name))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "commit", "-m", fmt.Sprintf("commit %d", i),
+			"--date", fmt.Sprintf("2024-01-0%d 12:00:00 +0100", i)))
+	}
+	return &GitVCS{}, repoPath
+}
+
+func gitOutput(t *testing.T, g *GitVCS, repoPath string, args ...string) string {
+	t.Helper()
+	output, err := g.runCommand(exec.Command("git", args...), repoPath)
+	require.NoError(t, err)
+	return strings.TrimSpace(output)
+}
+
+func TestPlanRewrite(t *testing.T) {
+	g, repoPath := setupRewriteRepo(t, 4)
+	hashes := strings.Fields(gitOutput(t, g, repoPath, "rev-list", "--reverse", "HEAD"))
+
+	t.Run("range up to HEAD", func(t *testing.T) {
+		plan, err := g.PlanRewrite(repoPath, "HEAD~2..HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, "master", plan.Branch)
+		assert.Equal(t, hashes[3], plan.Head)
+		assert.Equal(t, hashes[2:], plan.Commits)
+		assert.Equal(t, hashes[2:], plan.Chain)
+		assert.Empty(t, plan.Pushed)
+	})
+
+	t.Run("single revision", func(t *testing.T) {
+		plan, err := g.PlanRewrite(repoPath, "HEAD~3")
+		require.NoError(t, err)
+		assert.Equal(t, hashes[1:], plan.Commits)
+	})
+
+	t.Run("range before HEAD", func(t *testing.T) {
+		plan, err := g.PlanRewrite(repoPath, "HEAD~3..HEAD~2")
+		require.NoError(t, err)
+		assert.Equal(t, hashes[1:2], plan.Commits)
+		assert.Equal(t, hashes[1:], plan.Chain)
+	})
+
+	t.Run("pushed commits", func(t *testing.T) {
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "update-ref", "refs/remotes/origin/master", hashes[2]))
+		defer func() {
+			_ = testutils.RunGitCommand(t, repoPath, "update-ref", "-d", "refs/remotes/origin/master")
+		}()
+
+		plan, err := g.PlanRewrite(repoPath, "HEAD~3")
+		require.NoError(t, err)
+		assert.Equal(t, hashes[1:3], plan.Pushed)
+
+		plan, err = g.PlanRewrite(repoPath, "origin/master..HEAD")
+		require.NoError(t, err)
+		assert.Empty(t, plan.Pushed)
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		_, err := g.PlanRewrite(repoPath, "HEAD..HEAD")
+		assert.Error(t, err)
+	})
+
+	t.Run("symmetric range", func(t *testing.T) {
+		_, err := g.PlanRewrite(repoPath, "HEAD~2...HEAD")
+		assert.Error(t, err)
+	})
+
+	t.Run("range not on the branch", func(t *testing.T) {
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "-b", "other", "HEAD~2"))
+		defer func() {
+			require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "master"))
+		}()
+
+		_, err := g.PlanRewrite(repoPath, "HEAD~1..master")
+		assert.ErrorContains(t, err, "not on branch other")
+	})
+
+	t.Run("detached HEAD", func(t *testing.T) {
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "--detach"))
+		defer func() {
+			require.NoError(t, testutils.RunGitCommand(t, repoPath, "checkout", "-q", "master"))
+		}()
+
+		_, err := g.PlanRewrite(repoPath, "HEAD~1")
+		assert.ErrorContains(t, err, "detached")
+	})
+}
+
+func TestRewriteMessages(t *testing.T) {
+	t.Run("rewords and recreates descendants", func(t *testing.T) {
+		g, repoPath := setupRewriteRepo(t, 4)
+		before := strings.Fields(gitOutput(t, g, repoPath, "rev-list", "--reverse", "HEAD"))
+		treeBefore := gitOutput(t, g, repoPath, "rev-parse", "HEAD^{tree}")
+
+		plan, err := g.PlanRewrite(repoPath, "HEAD~3..HEAD~2")
+		require.NoError(t, err)
+
+		newHead, err := g.RewriteMessages(repoPath, plan, map[string]string{before[1]: "feat: add file 2\n\nDetails."})
+		require.NoError(t, err)
+		assert.Equal(t, newHead, gitOutput(t, g, repoPath, "rev-parse", "HEAD"))
+		assert.Equal(t, treeBefore, gitOutput(t, g, repoPath, "rev-parse", "HEAD^{tree}"))
+
+		after := strings.Fields(gitOutput(t, g, repoPath, "rev-list", "--reverse", "HEAD"))
+		require.Len(t, after, 4)
+		assert.Equal(t, before[0], after[0], "commits before the range are kept")
+		for i := 1; i < 4; i++ {
+			assert.NotEqual(t, before[i], after[i])
+		}
+
+		subjects := gitOutput(t, g, repoPath, "log", "--reverse", "--format=%s")
+		assert.Equal(t, "commit 1\nfeat: add file 2\ncommit 3\ncommit 4", subjects)
+
+		msg, err := g.GetCommitMessage(repoPath, after[1])
+		require.NoError(t, err)
+		assert.Equal(t, "feat: add file 2\n\nDetails.\n", msg)
+
+		// Authors and author dates are kept
+		assert.Equal(t,
+			gitOutput(t, g, repoPath, "log", "--format=%an %ae %ad", "--date=raw", before[3], "-1"),
+			gitOutput(t, g, repoPath, "log", "--format=%an %ae %ad", "--date=raw", after[3], "-1"))
+	})
+
+	t.Run("nothing to reword", func(t *testing.T) {
+		g, repoPath := setupRewriteRepo(t, 2)
+		head := gitOutput(t, g, repoPath, "rev-parse", "HEAD")
+
+		plan, err := g.PlanRewrite(repoPath, "HEAD~1")
+		require.NoError(t, err)
+
+		newHead, err := g.RewriteMessages(repoPath, plan, nil)
+		require.NoError(t, err)
+		assert.Equal(t, head, newHead)
+		assert.Equal(t, head, gitOutput(t, g, repoPath, "rev-parse", "HEAD"))
+	})
+
+	t.Run("branch moved since the plan", func(t *testing.T) {
+		g, repoPath := setupRewriteRepo(t, 2)
+		plan, err := g.PlanRewrite(repoPath, "HEAD~1")
+		require.NoError(t, err)
+
+		require.NoError(t, testutils.RunGitCommand(t, repoPath, "commit", "--allow-empty", "-m", "commit 3"))
+
+		_, err = g.RewriteMessages(repoPath, plan, map[string]string{plan.Commits[0]: "feat: reworded"})
+		assert.Error(t, err)
+		assert.Equal(t, "commit 3", gitOutput(t, g, repoPath, "log", "-1", "--format=%s"))
+	})
+}
+
+func TestRewriteMessagesEncoding(t *testing.T) {
+	g, repoPath := setupRewriteRepo(t, 1)
+	for _, subject := range []string{"caf\xe9 2", "caf\xe9 3"} {
+		msgFile := filepath.Join(t.TempDir(), "message")
+		require.NoError(t, os.WriteFile(msgFile, []byte(subject+"\n"), 0o644))
+		require.NoError(t, testutils.RunGitCommand(t, repoPath,
+			"-c", "i18n.commitEncoding=ISO-8859-1", "commit", "--allow-empty", "-F", msgFile))
+	}
+
+	plan, err := g.PlanRewrite(repoPath, "HEAD~2..HEAD~1")
+	require.NoError(t, err)
+	_, err = g.RewriteMessages(repoPath, plan, map[string]string{plan.Commits[0]: "feat: café"})
+	require.NoError(t, err)
+
+	// The kept message keeps its encoding, the new one is UTF-8
+	assert.Contains(t, gitOutput(t, g, repoPath, "cat-file", "commit", "HEAD"), "\nencoding ISO-8859-1\n")
+	assert.NotContains(t, gitOutput(t, g, repoPath, "cat-file", "commit", "HEAD~1"), "\nencoding ")
+	assert.Equal(t, "commit 1\nfeat: café\ncafé 3", gitOutput(t, g, repoPath, "log", "--reverse", "--format=%s"))
+}
+
+func TestRewriteSignedCommits(t *testing.T) {
+	g, repoPath := setupRewriteRepo(t, 3)
+
+	// Sign HEAD with a fake signature, the rewrite doesn't check it
+	raw := gitOutput(t, g, repoPath, "cat-file", "commit", "HEAD")
+	header, message, _ := strings.Cut(raw, "\n\n")
+	signed := header + "\ngpgsig -----BEGIN PGP SIGNATURE-----\n \n fake\n -----END PGP SIGNATURE-----\n\n" + message + "\n"
+	cmd := exec.Command("git", "hash-object", "-t", "commit", "-w", "--stdin")
+	cmd.Stdin = strings.NewReader(signed)
+	signedHead, err := g.runCommand(cmd, repoPath)
+	require.NoError(t, err)
+	signedHead = strings.TrimSpace(signedHead)
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "update-ref", "HEAD", signedHead))
+
+	plan, err := g.PlanRewrite(repoPath, "HEAD~2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{signedHead}, plan.Signed)
+
+	_, err = g.RewriteMessages(repoPath, plan, map[string]string{plan.Commits[0]: "feat: add file 2"})
+	require.NoError(t, err)
+	assert.NotContains(t, gitOutput(t, g, repoPath, "cat-file", "commit", "HEAD"), "gpgsig")
+	assert.Equal(t, "commit 3", gitOutput(t, g, repoPath, "log", "-1", "--format=%s"))
+}
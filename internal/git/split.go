@@ -0,0 +1,240 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/config"
+	"github.com/belingud/gptcomet/internal/debug"
+)
+
+// SplitChange is a part of the staged changes that can be committed on its
+// own: a hunk of a modified text file, or a whole file when it is added,
+// deleted, renamed, copied, binary or changes mode, which can't be split.
+type SplitChange struct {
+	// ID numbers the changes from 1, in the order of the diff
+	ID int
+	// Path is the path of the file in the index
+	Path string
+	// Header is the hunk header, like "@@ -1,3 +1,4 @@ func main()", or a
+	// description of the change for whole files, like "new file"
+	Header string
+	// Binary reports whether the file is binary, its Diff is then a binary patch
+	Binary bool
+	// Ignored reports whether the file matches the file_ignore patterns
+	Ignored bool
+	// Diff is the hunk, or the diff of the whole file
+	Diff string
+
+	// fileHeader is the header of the file diff, which every patch of one of
+	// its hunks starts with
+	fileHeader string
+}
+
+// SplitCommit is a commit made of some of the staged changes
+type SplitCommit struct {
+	Message string
+	// Changes are the IDs of the changes in the commit
+	Changes []int
+}
+
+// GetSplitChanges returns the staged changes split into hunks and whole files,
+// which can be committed separately with CommitSplit. Changes of files that
+// match the file_ignore patterns are marked as ignored.
+func (g *GitVCS) GetSplitChanges(repoPath string, cfgManager config.ManagerInterface) ([]SplitChange, error) {
+	// Binary patches and fixed prefixes, so that every part can be applied again
+	cmd := exec.Command("git", append([]string{"diff", "--staged", "--binary"}, parseableDiffArgs...)...)
+	output, err := g.runCommand(cmd, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := parseSplitChanges(output)
+	ignorePatterns := getIgnorePatterns(cfgManager)
+	for i := range changes {
+		changes[i].Ignored = ShouldIgnoreFile(changes[i].Path, ignorePatterns)
+	}
+	return changes, nil
+}
+
+// parseSplitChanges splits a git diff into hunks of modified files and whole
+// files for the other changes
+func parseSplitChanges(diff string) []SplitChange {
+	var changes []SplitChange
+	for _, file := range ParseDiff(diff) {
+		if kind := wholeFileKind(&file); kind != "" {
+			changes = append(changes, SplitChange{
+				ID:         len(changes) + 1,
+				Path:       file.Path(),
+				Header:     kind,
+				Binary:     file.Binary,
+				Diff:       file.String(),
+				fileHeader: file.String(),
+			})
+			continue
+		}
+
+		for _, hunk := range file.Hunks {
+			changes = append(changes, SplitChange{
+				ID:         len(changes) + 1,
+				Path:       file.Path(),
+				Header:     hunk.Header(),
+				Diff:       hunk.Text,
+				fileHeader: file.Header,
+			})
+		}
+	}
+	return changes
+}
+
+// wholeFileKind describes the change of a file whose hunks can't be committed
+// separately, or returns "" for a plain modification
+func wholeFileKind(file *FileDiff) string {
+	switch {
+	case file.Status == FileAdded:
+		return "new file"
+	case file.Status == FileDeleted:
+		return "deleted file"
+	case file.Status == FileRenamed:
+		return "renamed file"
+	case file.Status == FileCopied:
+		return "copied file"
+	case file.ModeChanged():
+		return "mode change"
+	case file.Binary:
+		return "binary file"
+	case len(file.Hunks) == 0:
+		return "changed"
+	}
+	return ""
+}
+
+// buildSplitPatch returns a patch with the changes of ids, each file header
+// written once before its hunks
+func buildSplitPatch(changes []SplitChange, ids map[int]bool) string {
+	var patch strings.Builder
+	lastHeader := ""
+	for _, change := range changes {
+		if !ids[change.ID] {
+			continue
+		}
+		if change.fileHeader == change.Diff {
+			patch.WriteString(change.Diff)
+			lastHeader = ""
+			continue
+		}
+		if change.fileHeader != lastHeader {
+			patch.WriteString(change.fileHeader)
+			lastHeader = change.fileHeader
+		}
+		patch.WriteString(change.Diff)
+	}
+	return patch.String()
+}
+
+// CommitSplit commits the staged changes as the given commits, in order. The
+// index is emptied of the staged changes, then the changes of every commit are
+// staged with git apply --cached and committed. Changes that are in none of the
+// commits are staged again at the end. The working tree isn't touched.
+//
+// If anything fails, the branch and the index are restored to where they were
+// and the error is returned.
+//
+// It returns the hashes of the new commits.
+func (g *GitVCS) CommitSplit(repoPath string, changes []SplitChange, commits []SplitCommit, noVerify bool) ([]string, error) {
+	originalTree, err := g.runCommand(exec.Command("git", "write-tree"), repoPath)
+	if err != nil {
+		return nil, err
+	}
+	originalTree = strings.TrimSpace(originalTree)
+	// Empty on a branch without commits yet
+	originalHead, _ := g.runCommand(exec.Command("git", "rev-parse", "--verify", "-q", "HEAD"), repoPath)
+	originalHead = strings.TrimSpace(originalHead)
+
+	hashes, err := g.commitSplit(repoPath, changes, commits, noVerify, originalTree)
+	if err == nil {
+		return hashes, nil
+	}
+
+	if rollbackErr := g.restoreSplit(repoPath, originalHead, originalTree); rollbackErr != nil {
+		return nil, fmt.Errorf("%w, and restoring the branch and the index failed: %v", err, rollbackErr)
+	}
+	return nil, fmt.Errorf("%w (the branch and the staged changes were restored)", err)
+}
+
+// commitSplit does the work of CommitSplit, without restoring anything on failure
+func (g *GitVCS) commitSplit(repoPath string, changes []SplitChange, commits []SplitCommit, noVerify bool, originalTree string) ([]string, error) {
+	if _, err := g.runCommand(exec.Command("git", "reset", "-q"), repoPath); err != nil {
+		return nil, err
+	}
+
+	committed := make(map[int]bool)
+	var hashes []string
+	for i, commit := range commits {
+		ids := make(map[int]bool, len(commit.Changes))
+		for _, id := range commit.Changes {
+			ids[id] = true
+			committed[id] = true
+		}
+		if err := g.applyCached(repoPath, buildSplitPatch(changes, ids)); err != nil {
+			return nil, fmt.Errorf("failed to stage the changes of commit %d: %w", i+1, err)
+		}
+		if err := g.CreateCommit(repoPath, commit.Message, noVerify); err != nil {
+			return nil, fmt.Errorf("failed to create commit %d: %w", i+1, err)
+		}
+		hash, err := g.GetLastCommitHash(repoPath)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, strings.TrimSpace(hash))
+	}
+
+	rest := make(map[int]bool)
+	for _, change := range changes {
+		if !committed[change.ID] {
+			rest[change.ID] = true
+		}
+	}
+	if len(rest) > 0 {
+		if err := g.applyCached(repoPath, buildSplitPatch(changes, rest)); err != nil {
+			return nil, fmt.Errorf("failed to stage the changes left out of the commits again: %w", err)
+		}
+	}
+
+	// The commits and the changes left staged must add up to the original index
+	tree, err := g.runCommand(exec.Command("git", "write-tree"), repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(tree) != originalTree {
+		return nil, fmt.Errorf("the split commits don't add up to the staged changes")
+	}
+	return hashes, nil
+}
+
+// applyCached applies patch to the index
+func (g *GitVCS) applyCached(repoPath, patch string) error {
+	if patch == "" {
+		return nil
+	}
+	debug.Printf("Applying patch to the index:\n%s", patch)
+	cmd := exec.Command("git", "apply", "--cached", "--whitespace=nowarn", "-")
+	cmd.Stdin = strings.NewReader(patch)
+	_, err := g.runCommand(cmd, repoPath)
+	return err
+}
+
+// restoreSplit points the branch back to head, or deletes it if head is "",
+// and restores the index to tree
+func (g *GitVCS) restoreSplit(repoPath, head, tree string) error {
+	cmd := exec.Command("git", "update-ref", "-m", "gptcomet: split rollback", "HEAD", head)
+	if head == "" {
+		cmd = exec.Command("git", "update-ref", "-d", "HEAD")
+	}
+	if _, err := g.runCommand(cmd, repoPath); err != nil {
+		return err
+	}
+	_, err := g.runCommand(exec.Command("git", "read-tree", tree), repoPath)
+	return err
+}
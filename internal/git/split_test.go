@@ -0,0 +1,249 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// numberedLines returns the lines "line 1" to "line n"
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return lines
+}
+
+func writeLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+}
+
+// setupSplitRepo creates a repository with a committed file of 30 lines and
+// stages two distant changes to it, a new file and a deleted file
+func setupSplitRepo(t *testing.T) (*GitVCS, string) {
+	t.Helper()
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	t.Cleanup(cleanup)
+
+	lines := numberedLines(30)
+	writeLines(t, filepath.Join(repoPath, "main.txt"), lines)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "old.txt"), []byte("old\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "."))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "commit", "-m", "initial"))
+
+	lines[2] = "changed near the top"
+	lines[27] = "changed near the bottom"
+	writeLines(t, filepath.Join(repoPath, "main.txt"), lines)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("new\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "main.txt", "new.txt"))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "rm", "-q", "old.txt"))
+	return &GitVCS{}, repoPath
+}
+
+func TestGetSplitChanges(t *testing.T) {
+	g, repoPath := setupSplitRepo(t)
+	cfg := &testutils.MockConfigManager{}
+	cfg.On("Get", "file_ignore").Return([]interface{}{"new.txt"}, true)
+
+	changes, err := g.GetSplitChanges(repoPath, cfg)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+
+	assert.Equal(t, 1, changes[0].ID)
+	assert.Equal(t, "main.txt", changes[0].Path)
+	assert.True(t, strings.HasPrefix(changes[0].Header, "@@ -1,"))
+	assert.Contains(t, changes[0].Diff, "+changed near the top")
+	assert.NotContains(t, changes[0].Diff, "diff --git")
+
+	assert.Equal(t, "main.txt", changes[1].Path)
+	assert.Contains(t, changes[1].Diff, "+changed near the bottom")
+
+	assert.Equal(t, "new.txt", changes[2].Path)
+	assert.Equal(t, "new file", changes[2].Header)
+	assert.True(t, changes[2].Ignored)
+
+	assert.Equal(t, "old.txt", changes[3].Path)
+	assert.Equal(t, "deleted file", changes[3].Header)
+	assert.False(t, changes[3].Ignored)
+}
+
+func TestParseSplitChanges(t *testing.T) {
+	diff := `diff --git a/img.png b/img.png
+index 1234567..89abcde 100644
+Binary files a/img.png and b/img.png differ
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/a.go b/b.go
+similarity index 90%
+rename from a.go
+rename to b.go
+index 1234567..89abcde 100644
+--- a/a.go
++++ b/b.go
+@@ -1 +1 @@
+-package a
++package b
+`
+	changes := parseSplitChanges(diff)
+	require.Len(t, changes, 3)
+	assert.Equal(t, "img.png", changes[0].Path)
+	assert.Equal(t, "binary file", changes[0].Header)
+	assert.True(t, changes[0].Binary)
+	assert.Equal(t, "run.sh", changes[1].Path)
+	assert.Equal(t, "mode change", changes[1].Header)
+	assert.Equal(t, "b.go", changes[2].Path)
+	assert.Equal(t, "renamed file", changes[2].Header)
+	assert.True(t, strings.HasPrefix(changes[2].Diff, "diff --git a/a.go b/b.go\n"))
+}
+
+func TestBuildSplitPatch(t *testing.T) {
+	diff := `diff --git a/a.txt b/a.txt
+index 1234567..89abcde 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-a
++b
+@@ -10 +10 @@
+-c
++d
+`
+	changes := parseSplitChanges(diff)
+	require.Len(t, changes, 2)
+
+	assert.Equal(t, diff, buildSplitPatch(changes, map[int]bool{1: true, 2: true}))
+	assert.Equal(t, `diff --git a/a.txt b/a.txt
+index 1234567..89abcde 100644
+--- a/a.txt
++++ b/a.txt
+@@ -10 +10 @@
+-c
++d
+`, buildSplitPatch(changes, map[int]bool{2: true}))
+	assert.Empty(t, buildSplitPatch(changes, nil))
+}
+
+func TestCommitSplit(t *testing.T) {
+	g, repoPath := setupSplitRepo(t)
+	cfg := &testutils.MockConfigManager{}
+	cfg.On("Get", "file_ignore").Return(nil, false)
+	changes, err := g.GetSplitChanges(repoPath, cfg)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+	originalTree := gitOutput(t, g, repoPath, "write-tree")
+
+	// The bottom hunk first, and the deleted file left staged
+	hashes, err := g.CommitSplit(repoPath, changes, []SplitCommit{
+		{Message: "change the bottom", Changes: []int{2}},
+		{Message: "change the top\n\nand add a file", Changes: []int{1, 3}},
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, hashes, 2)
+
+	assert.Equal(t, hashes[1], gitOutput(t, g, repoPath, "rev-parse", "HEAD"))
+	assert.Equal(t, hashes[0], gitOutput(t, g, repoPath, "rev-parse", "HEAD~1"))
+	assert.Equal(t, "change the bottom", gitOutput(t, g, repoPath, "log", "-1", "--format=%B", "HEAD~1"))
+	assert.Equal(t, "main.txt", gitOutput(t, g, repoPath, "show", "--name-only", "--format=", "HEAD~1"))
+	assert.Equal(t, "main.txt\nnew.txt", gitOutput(t, g, repoPath, "show", "--name-only", "--format=", "HEAD"))
+
+	// The first commit has the bottom change only
+	first := gitOutput(t, g, repoPath, "show", "HEAD~1:main.txt")
+	assert.Contains(t, first, "changed near the bottom")
+	assert.NotContains(t, first, "changed near the top")
+
+	// The index is unchanged, with the deletion still staged
+	assert.Equal(t, originalTree, gitOutput(t, g, repoPath, "write-tree"))
+	assert.Equal(t, "D\told.txt", gitOutput(t, g, repoPath, "diff", "--staged", "--name-status"))
+}
+
+func TestCommitSplit_RollsBack(t *testing.T) {
+	g, repoPath := setupSplitRepo(t)
+	cfg := &testutils.MockConfigManager{}
+	cfg.On("Get", "file_ignore").Return(nil, false)
+	changes, err := g.GetSplitChanges(repoPath, cfg)
+	require.NoError(t, err)
+	head := gitOutput(t, g, repoPath, "rev-parse", "HEAD")
+	originalTree := gitOutput(t, g, repoPath, "write-tree")
+
+	// The second commit is empty, so committing it fails after the first one
+	_, err = g.CommitSplit(repoPath, changes, []SplitCommit{
+		{Message: "first", Changes: []int{1, 2}},
+		{Message: "empty", Changes: nil},
+	}, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create commit 2")
+	assert.Contains(t, err.Error(), "restored")
+
+	assert.Equal(t, head, gitOutput(t, g, repoPath, "rev-parse", "HEAD"))
+	assert.Equal(t, originalTree, gitOutput(t, g, repoPath, "write-tree"))
+}
+
+func TestCommitSplit_NoCommitsYet(t *testing.T) {
+	repoPath, cleanup := testutils.TestGitRepo(t)
+	defer cleanup()
+	g := &GitVCS{}
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("a\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "b.txt"), []byte("b\n"), 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "."))
+	cfg := &testutils.MockConfigManager{}
+	cfg.On("Get", "file_ignore").Return(nil, false)
+	changes, err := g.GetSplitChanges(repoPath, cfg)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	originalTree := gitOutput(t, g, repoPath, "write-tree")
+
+	t.Run("rolls back to no commits", func(t *testing.T) {
+		_, err := g.CommitSplit(repoPath, changes, []SplitCommit{
+			{Message: "add a", Changes: []int{1}},
+			{Message: "empty"},
+		}, false)
+		require.Error(t, err)
+		_, err = g.runCommand(exec.Command("git", "rev-parse", "--verify", "-q", "HEAD"), repoPath)
+		assert.Error(t, err)
+		assert.Equal(t, originalTree, gitOutput(t, g, repoPath, "write-tree"))
+	})
+
+	t.Run("commits", func(t *testing.T) {
+		hashes, err := g.CommitSplit(repoPath, changes, []SplitCommit{
+			{Message: "add a", Changes: []int{1}},
+			{Message: "add b", Changes: []int{2}},
+		}, false)
+		require.NoError(t, err)
+		assert.Len(t, hashes, 2)
+		assert.Equal(t, "add b\nadd a", gitOutput(t, g, repoPath, "log", "--format=%s"))
+	})
+}
+
+func TestCommitSplit_BinaryFile(t *testing.T) {
+	g, repoPath := setupSplitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "data.bin"), []byte{0, 1, 2, 0, 255, '\n'}, 0o644))
+	require.NoError(t, testutils.RunGitCommand(t, repoPath, "add", "data.bin"))
+	cfg := &testutils.MockConfigManager{}
+	cfg.On("Get", "file_ignore").Return(nil, false)
+	changes, err := g.GetSplitChanges(repoPath, cfg)
+	require.NoError(t, err)
+	require.Len(t, changes, 5)
+	assert.Equal(t, "data.bin", changes[0].Path)
+	assert.Equal(t, "new file", changes[0].Header)
+	assert.True(t, changes[0].Binary)
+	assert.False(t, changes[1].Binary)
+
+	_, err = g.CommitSplit(repoPath, changes, []SplitCommit{
+		{Message: "add data", Changes: []int{1}},
+		{Message: "the rest", Changes: []int{2, 3, 4, 5}},
+	}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "data.bin", gitOutput(t, g, repoPath, "show", "--name-only", "--format=", "HEAD~1"))
+	assert.Empty(t, gitOutput(t, g, repoPath, "diff", "--staged"))
+}
@@ -97,6 +97,17 @@ func (s *SVNVCS) GetStagedDiffFiltered(repoPath string, cfgManager config.Manage
 	return s.runCommand(cmd, repoPath)
 }
 
+// GetCommitDiff returns the diff of the revision commit, like 'svn diff -c <commit>'.
+// SVN commits can't be amended, so the staged changes can't be included.
+// The file_ignore patterns are not applied, like in GetStagedDiffFiltered.
+func (s *SVNVCS) GetCommitDiff(repoPath, commit string, staged bool, cfgManager config.ManagerInterface) (string, error) {
+	if staged {
+		return "", gptcometerrors.VCSUnsupportedError("SVN", "amending commits")
+	}
+	cmd := exec.Command("svn", "diff", "-c", strings.TrimSpace(commit))
+	return s.runCommand(cmd, repoPath)
+}
+
 // GetCurrentBranch returns the name of the current branch in the SVN repository
 // at the specified path. It runs the "svn info --show-item url" command to get the
 // URL of the current branch, and extracts the branch name from it.
@@ -184,6 +195,11 @@ func (s *SVNVCS) CreateCommit(repoPath, message string, noVerify bool) error {
 	return err
 }
 
+// AmendCommit always fails, SVN commits can't be amended.
+func (s *SVNVCS) AmendCommit(repoPath, message string, noVerify bool) error {
+	return gptcometerrors.VCSUnsupportedError("SVN", "amending commits")
+}
+
 // runCommand executes a given SVN command in the specified repository path and returns its output.
 // It captures both stdout and stderr, returning the stdout output as a string if successful.
 // If the command fails, it returns an error that includes both the original error and stderr output.
@@ -16,10 +16,12 @@ type VCS interface {
 	HasStagedChanges(repoPath string) (bool, error)
 	GetStagedFiles(repoPath string) ([]string, error)
 	GetStagedDiffFiltered(repoPath string, cfgManager config.ManagerInterface) (string, error)
+	GetCommitDiff(repoPath, commit string, staged bool, cfgManager config.ManagerInterface) (string, error)
 	GetCurrentBranch(repoPath string) (string, error)
 	GetCommitInfo(repoPath, commitHash string) (string, error)
 	GetLastCommitHash(repoPath string) (string, error)
 	CreateCommit(repoPath, message string, noVerify bool) error
+	AmendCommit(repoPath, message string, noVerify bool) error
 }
 
 // NewVCS creates a new VCS object based on the given type.
@@ -63,12 +63,7 @@ func (a *AI21LLM) BuildHeaders() map[string]string {
 	return headers
 }
 
-func (a *AI21LLM) FormatMessages(message string) (interface{}, error) {
-	messages := []interface{}{types.Message{
-		Role:    "user",
-		Content: message,
-	}}
-
+func (a *AI21LLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	payload := map[string]interface{}{
 		"model":      a.Config.Model,
 		"messages":   messages,
@@ -84,6 +79,16 @@ func (a *AI21LLM) FormatMessages(message string) (interface{}, error) {
 }
 
 // MakeRequest implements the LLM interface for AI21
-func (a *AI21LLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return a.BaseLLM.MakeRequest(ctx, client, a, message, stream)
+func (a *AI21LLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return a.BaseLLM.MakeRequest(ctx, client, a, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (a *AI21LLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return a.BaseLLM.MakeStreamRequest(ctx, client, a, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (a *AI21LLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return a.BaseLLM.ListModels(ctx, client, a)
 }
@@ -1,7 +1,9 @@
 package llm
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/belingud/gptcomet/pkg/config"
@@ -88,3 +90,14 @@ func (a *AzureLLM) BuildHeaders() map[string]string {
 	}
 	return headers
 }
+
+// MakeStreamRequest makes a streaming request to the API
+func (a *AzureLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return a.BaseLLM.MakeStreamRequest(ctx, client, a, messages, callback)
+}
+
+// ListModels lists the models available to the Azure OpenAI resource
+func (a *AzureLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	url := a.modelsURL("models?api-version=" + a.Config.APIVersion)
+	return fetchModels(ctx, client, a, url, "data.#.id")
+}
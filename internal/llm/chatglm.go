@@ -54,6 +54,16 @@ func (c *ChatGLMLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // MakeRequest makes a request to the ChatGLM API
-func (c *ChatGLMLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return c.BaseLLM.MakeRequest(ctx, client, c, message, stream)
+func (c *ChatGLMLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return c.BaseLLM.MakeRequest(ctx, client, c, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (c *ChatGLMLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return c.BaseLLM.MakeStreamRequest(ctx, client, c, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (c *ChatGLMLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return c.BaseLLM.ListModels(ctx, client, c)
 }
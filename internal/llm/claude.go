@@ -75,23 +75,34 @@ func (c *ClaudeLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 	}
 }
 
-// FormatMessages formats messages for Claude API
-func (c *ClaudeLLM) FormatMessages(message string) (interface{}, error) {
-	messages := []map[string]interface{}{}
-	messages = append(messages, map[string]interface{}{
-		"role":    "user",
-		"content": message,
-	})
+// FormatMessages formats messages for Claude API.
+// Claude takes system messages in the top-level "system" field, not in "messages".
+func (c *ClaudeLLM) FormatMessages(messages []types.Message) (interface{}, error) {
+	var system []string
+	chat := []map[string]interface{}{}
+	for _, msg := range messages {
+		if msg.Role == types.RoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		chat = append(chat, map[string]interface{}{
+			"role":    msg.Role,
+			"content": msg.Content,
+		})
+	}
 
 	payload := map[string]interface{}{
 		"model":             c.Config.Model,
-		"messages":          messages,
+		"messages":          chat,
 		"max_tokens":        c.Config.MaxTokens,
 		"temperature":       c.Config.Temperature,
 		"top_p":             c.Config.TopP,
 		"frequency_penalty": c.Config.FrequencyPenalty,
 		"presence_penalty":  c.Config.PresencePenalty,
 	}
+	if len(system) > 0 {
+		payload["system"] = strings.Join(system, "\n\n")
+	}
 
 	return payload, nil
 }
@@ -105,6 +116,33 @@ func (c *ClaudeLLM) BuildURL() string {
 	)
 }
 
+// BuildStreamURL returns the messages endpoint, Claude streams from the same URL
+func (c *ClaudeLLM) BuildStreamURL() string {
+	return c.BuildURL()
+}
+
+// PrepareStreamPayload sets the "stream" flag
+func (c *ClaudeLLM) PrepareStreamPayload(payload map[string]interface{}) {
+	payload["stream"] = true
+}
+
+// ParseStreamChunk extracts the text of a Claude "content_block_delta" event.
+// Other events (message_start, ping, content_block_stop...) carry no text.
+func (c *ClaudeLLM) ParseStreamChunk(data []byte) (string, error) {
+	switch gjson.GetBytes(data, "type").String() {
+	case "content_block_delta":
+		return gjson.GetBytes(data, c.Config.StreamAnswerPath).String(), nil
+	case "error":
+		return "", fmt.Errorf("stream error: %s", gjson.GetBytes(data, "error.message").String())
+	}
+	return "", nil
+}
+
+// IsStreamDone reports whether the chunk is the "message_stop" event
+func (c *ClaudeLLM) IsStreamDone(data []byte) bool {
+	return gjson.GetBytes(data, "type").String() == "message_stop"
+}
+
 // BuildHeaders builds request headers
 func (c *ClaudeLLM) BuildHeaders() map[string]string {
 	headers := map[string]string{
@@ -128,21 +166,42 @@ func (c *ClaudeLLM) ParseResponse(response []byte) (string, error) {
 	return strings.TrimSpace(text), nil
 }
 
-// GetUsage returns usage information for the provider
-func (c *ClaudeLLM) GetUsage(data []byte) (string, error) {
+// GetUsage returns usage information for the provider.
+// Anthropic reports cache reads and writes apart from input_tokens, they are
+// added to the prompt tokens. A streamed response reports the input tokens in
+// the message_start event and the output tokens in the message_delta events,
+// which readStream merges.
+func (c *ClaudeLLM) GetUsage(data []byte) (*types.Usage, error) {
 	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", nil
+	if !usage.IsObject() {
+		usage = gjson.GetBytes(data, "message.usage")
+	}
+	if !usage.IsObject() {
+		return nil, nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> input tokens: %d, output tokens: %d",
-		usage.Get("input_tokens").Int(),
-		usage.Get("output_tokens").Int(),
-	), nil
+	cached := int(usage.Get("cache_read_input_tokens").Int())
+	prompt := int(usage.Get("input_tokens").Int()) + cached + int(usage.Get("cache_creation_input_tokens").Int())
+	completion := int(usage.Get("output_tokens").Int())
+	return &types.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+		CachedTokens:     cached,
+	}, nil
 }
 
 // MakeRequest makes a request to the API
-func (c *ClaudeLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return c.BaseLLM.MakeRequest(ctx, client, c, message, stream)
+func (c *ClaudeLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return c.BaseLLM.MakeRequest(ctx, client, c, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (c *ClaudeLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return c.BaseLLM.MakeStreamRequest(ctx, client, c, messages, callback)
+}
+
+// ListModels lists the models available from the Anthropic API
+func (c *ClaudeLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return fetchModels(ctx, client, c, c.modelsURL("models?limit=1000"), "data.#.id")
 }
@@ -204,3 +204,82 @@ func TestClaudeLLM_BuildHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestClaudeLLM_ParseStreamChunk(t *testing.T) {
+	llm := NewClaudeLLM(&types.ClientConfig{})
+
+	tests := []struct {
+		name     string
+		data     string
+		want     string
+		wantErr  bool
+		wantDone bool
+	}{
+		{
+			name: "text delta",
+			data: `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+			want: "Hello",
+		},
+		{
+			name: "message start",
+			data: `{"type":"message_start","message":{"id":"msg_1"}}`,
+			want: "",
+		},
+		{
+			name:    "error event",
+			data:    `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			wantErr: true,
+		},
+		{
+			name:     "message stop",
+			data:     `{"type":"message_stop"}`,
+			want:     "",
+			wantDone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llm.ParseStreamChunk([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStreamChunk() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStreamChunk() = %q, want %q", got, tt.want)
+			}
+			if done := llm.IsStreamDone([]byte(tt.data)); done != tt.wantDone {
+				t.Errorf("IsStreamDone() = %v, want %v", done, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestClaudeLLM_FormatMessages(t *testing.T) {
+	llm := NewClaudeLLM(&types.ClientConfig{MaxTokens: 1024})
+
+	got, err := llm.FormatMessages([]types.Message{
+		{Role: types.RoleSystem, Content: "You are a helpful assistant"},
+		{Role: types.RoleUser, Content: "first"},
+		{Role: types.RoleAssistant, Content: "answer"},
+		{Role: types.RoleUser, Content: "second"},
+	})
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+
+	payload := got.(map[string]interface{})
+	if payload["system"] != "You are a helpful assistant" {
+		t.Errorf("system = %v, want %q", payload["system"], "You are a helpful assistant")
+	}
+
+	messages := payload["messages"].([]map[string]interface{})
+	if len(messages) != 3 {
+		t.Fatalf("len(messages) = %d, want 3", len(messages))
+	}
+	wantRoles := []string{"user", "assistant", "user"}
+	for i, msg := range messages {
+		if msg["role"] != wantRoles[i] {
+			t.Errorf("messages[%d].role = %v, want %s", i, msg["role"], wantRoles[i])
+		}
+	}
+}
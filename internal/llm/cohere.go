@@ -2,8 +2,8 @@ package llm
 
 import (
 	"context"
-	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
@@ -60,41 +60,85 @@ func (c *CohereLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 	}
 }
 
-// FormatMessages formats messages for Cohere API
-func (c *CohereLLM) FormatMessages(message string) (interface{}, error) {
-
-	messages := []map[string]string{
-		{
-			"role":    "user",
-			"content": message,
-		},
-	}
-
+// FormatMessages formats messages for Cohere API.
+//
+// The v2 API takes the whole conversation in "messages". The v1 API takes the
+// last user message in "message", the earlier turns in "chat_history" and the
+// system messages in "preamble".
+func (c *CohereLLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	payload := map[string]interface{}{
-		"messages":    messages,
 		"model":       c.Config.Model,
 		"max_tokens":  c.Config.MaxTokens,
 		"temperature": c.Config.Temperature,
 	}
 
+	if !strings.Contains(c.Config.APIBase, "/v1") {
+		payload["messages"] = messages
+		return payload, nil
+	}
+
+	var preamble []string
+	history := []map[string]string{}
+	message := ""
+	for i, msg := range messages {
+		switch {
+		case msg.Role == types.RoleSystem:
+			preamble = append(preamble, msg.Content)
+		case i == len(messages)-1 && msg.Role == types.RoleUser:
+			message = msg.Content
+		case msg.Role == types.RoleAssistant:
+			history = append(history, map[string]string{"role": "CHATBOT", "message": msg.Content})
+		default:
+			history = append(history, map[string]string{"role": "USER", "message": msg.Content})
+		}
+	}
+
+	payload["message"] = message
+	if len(history) > 0 {
+		payload["chat_history"] = history
+	}
+	if len(preamble) > 0 {
+		payload["preamble"] = strings.Join(preamble, "\n\n")
+	}
+
 	return payload, nil
 }
 
-// GetUsage returns usage information for the provider
-func (c *CohereLLM) GetUsage(response []byte) (string, error) {
-	usage := gjson.GetBytes(response, "usage")
-	if !usage.Exists() {
-		return "", nil
+// GetUsage returns usage information for the provider.
+// The v2 API reports the counts in usage.tokens, the v1 API in meta.billed_units.
+func (c *CohereLLM) GetUsage(response []byte) (*types.Usage, error) {
+	var usage gjson.Result
+	for _, path := range []string{"usage.tokens", "usage", "meta.billed_units"} {
+		if usage = gjson.GetBytes(response, path); usage.Get("input_tokens").Exists() {
+			break
+		}
+	}
+	if !usage.Get("input_tokens").Exists() {
+		return nil, nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> input: %d, output: %d",
-		usage.Get("input_tokens").Int(),
-		usage.Get("output_tokens").Int(),
-	), nil
+	prompt := int(usage.Get("input_tokens").Int())
+	completion := int(usage.Get("output_tokens").Int())
+	return &types.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}, nil
 }
 
 // MakeRequest implements the LLM interface for Cohere
-func (c *CohereLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return c.BaseLLM.MakeRequest(ctx, client, c, message, stream)
+func (c *CohereLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return c.BaseLLM.MakeRequest(ctx, client, c, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (c *CohereLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return c.BaseLLM.MakeStreamRequest(ctx, client, c, messages, callback)
+}
+
+// ListModels lists the models available from the Cohere API, which are only
+// listed by the v1 API
+func (c *CohereLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	url := strings.TrimSuffix(strings.TrimSuffix(c.Config.APIBase, "/"), "/v2") + "/v1/models?endpoint=chat"
+	return fetchModels(ctx, client, c, url, "models.#.name")
 }
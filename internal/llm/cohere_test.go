@@ -86,3 +86,56 @@ func TestCohereLLM_GetRequiredConfig(t *testing.T) {
 		t.Errorf("GetRequiredConfig() model default value = %v, want %v", got["model"].DefaultValue, "command-r-plus")
 	}
 }
+
+func TestCohereLLM_FormatMessages(t *testing.T) {
+	conversation := []types.Message{
+		{Role: types.RoleSystem, Content: "Be brief"},
+		{Role: types.RoleUser, Content: "first"},
+		{Role: types.RoleAssistant, Content: "answer"},
+		{Role: types.RoleUser, Content: "second"},
+	}
+
+	t.Run("v2 messages", func(t *testing.T) {
+		llm := NewCohereLLM(&types.ClientConfig{})
+		got, err := llm.FormatMessages(conversation)
+		if err != nil {
+			t.Fatalf("FormatMessages() error = %v", err)
+		}
+		payload := got.(map[string]interface{})
+		messages := payload["messages"].([]types.Message)
+		if len(messages) != 4 {
+			t.Errorf("len(messages) = %d, want 4", len(messages))
+		}
+		if _, ok := payload["chat_history"]; ok {
+			t.Errorf("v2 payload should not contain chat_history")
+		}
+	})
+
+	t.Run("v1 chat history", func(t *testing.T) {
+		llm := NewCohereLLM(&types.ClientConfig{APIBase: "https://api.cohere.com/v1"})
+		got, err := llm.FormatMessages(conversation)
+		if err != nil {
+			t.Fatalf("FormatMessages() error = %v", err)
+		}
+		payload := got.(map[string]interface{})
+		if payload["message"] != "second" {
+			t.Errorf("message = %v, want %q", payload["message"], "second")
+		}
+		if payload["preamble"] != "Be brief" {
+			t.Errorf("preamble = %v, want %q", payload["preamble"], "Be brief")
+		}
+		history := payload["chat_history"].([]map[string]string)
+		want := []map[string]string{
+			{"role": "USER", "message": "first"},
+			{"role": "CHATBOT", "message": "answer"},
+		}
+		if len(history) != len(want) {
+			t.Fatalf("len(chat_history) = %d, want %d", len(history), len(want))
+		}
+		for i := range want {
+			if history[i]["role"] != want[i]["role"] || history[i]["message"] != want[i]["message"] {
+				t.Errorf("chat_history[%d] = %v, want %v", i, history[i], want[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/belingud/gptcomet/pkg/config"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// DeclarativeLLM implements the LLM interface for an OpenAI-compatible provider
+// declared in the custom_providers section of the config file
+type DeclarativeLLM struct {
+	*BaseLLM
+	definition types.ProviderDefinition
+}
+
+// NewDeclarativeLLM creates a new DeclarativeLLM from its definition. Values of
+// the config take precedence over the defaults of the definition.
+func NewDeclarativeLLM(definition types.ProviderDefinition, config *types.ClientConfig) *DeclarativeLLM {
+	BuildStandardConfig(config, definition.APIBase, definition.DefaultModel, definition.CompletionPath, definition.AnswerPath)
+	if config.StreamAnswerPath == "" {
+		config.StreamAnswerPath = definition.StreamAnswerPath
+	}
+	return &DeclarativeLLM{
+		BaseLLM:    NewBaseLLM(config),
+		definition: definition,
+	}
+}
+
+func (d *DeclarativeLLM) Name() string {
+	return d.definition.Name
+}
+
+// GetRequiredConfig returns provider-specific configuration requirements,
+// with the API base and model of the definition as defaults
+func (d *DeclarativeLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
+	requirements := map[string]config.ConfigRequirement{
+		"api_base": {
+			DefaultValue:  d.definition.APIBase,
+			PromptMessage: fmt.Sprintf("Enter %s API base", d.definition.Name),
+		},
+		"model": {
+			DefaultValue:  d.definition.DefaultModel,
+			PromptMessage: "Enter model name",
+		},
+		"api_key": {
+			DefaultValue:  "",
+			PromptMessage: "Enter API key",
+		},
+		"max_tokens": {
+			DefaultValue:  "1024",
+			PromptMessage: "Enter max tokens",
+		},
+	}
+	if d.definition.AuthStyle == types.AuthStyleNone {
+		delete(requirements, "api_key")
+	}
+	return requirements
+}
+
+// BuildHeaders builds request headers, sending the API key the way the
+// definition declares
+func (d *DeclarativeLLM) BuildHeaders() map[string]string {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if d.Config.APIKey != "" {
+		switch d.definition.AuthStyle {
+		case types.AuthStyleNone:
+		case types.AuthStyleHeader:
+			header := d.definition.AuthHeader
+			if header == "" {
+				header = "x-api-key"
+			}
+			headers[header] = d.Config.APIKey
+		default:
+			header := d.definition.AuthHeader
+			if header == "" {
+				header = "Authorization"
+			}
+			headers[header] = fmt.Sprintf("Bearer %s", d.Config.APIKey)
+		}
+	}
+	maps.Copy(headers, d.Config.ExtraHeaders)
+	return headers
+}
+
+// GetUsage returns the token usage of a response from the usage paths of the
+// definition, or of an OpenAI-compatible response when it declares none
+func (d *DeclarativeLLM) GetUsage(data []byte) (*types.Usage, error) {
+	paths := d.definition.UsagePaths
+	if paths.IsEmpty() {
+		return d.BaseLLM.GetUsage(data)
+	}
+
+	read := func(path string) (int, bool) {
+		if path == "" {
+			return 0, false
+		}
+		result := gjson.GetBytes(data, path)
+		return int(result.Int()), result.Exists()
+	}
+
+	usage := &types.Usage{}
+	found := false
+	for _, field := range []struct {
+		path  string
+		value *int
+	}{
+		{paths.PromptTokens, &usage.PromptTokens},
+		{paths.CompletionTokens, &usage.CompletionTokens},
+		{paths.TotalTokens, &usage.TotalTokens},
+		{paths.CachedTokens, &usage.CachedTokens},
+		{paths.ReasoningTokens, &usage.ReasoningTokens},
+	} {
+		if value, ok := read(field.path); ok {
+			*field.value = value
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	return usage, nil
+}
+
+// MakeRequest makes a request to the API
+func (d *DeclarativeLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return d.BaseLLM.MakeRequest(ctx, client, d, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (d *DeclarativeLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return d.BaseLLM.MakeStreamRequest(ctx, client, d, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (d *DeclarativeLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return d.BaseLLM.ListModels(ctx, client, d)
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+func TestNewDeclarativeLLM(t *testing.T) {
+	definition := types.ProviderDefinition{
+		Name:             "myvendor",
+		APIBase:          "https://api.myvendor.com/v1",
+		DefaultModel:     "my-model",
+		CompletionPath:   "v2/generate",
+		AnswerPath:       "output.text",
+		StreamAnswerPath: "delta.text",
+	}
+
+	t.Run("defaults of the definition", func(t *testing.T) {
+		got := NewDeclarativeLLM(definition, &types.ClientConfig{})
+		if got.Name() != "myvendor" {
+			t.Errorf("Name() = %v, want myvendor", got.Name())
+		}
+		if got.Config.APIBase != "https://api.myvendor.com/v1" || got.Config.Model != "my-model" {
+			t.Errorf("Config = %v %v, want the api_base and model of the definition", got.Config.APIBase, got.Config.Model)
+		}
+		if got.Config.AnswerPath != "output.text" || got.Config.StreamAnswerPath != "delta.text" {
+			t.Errorf("Config paths = %v %v, want the paths of the definition", got.Config.AnswerPath, got.Config.StreamAnswerPath)
+		}
+		if url := got.BuildURL(); url != "https://api.myvendor.com/v1/v2/generate" {
+			t.Errorf("BuildURL() = %v", url)
+		}
+	})
+
+	t.Run("config overrides the definition", func(t *testing.T) {
+		got := NewDeclarativeLLM(definition, &types.ClientConfig{APIBase: "https://proxy.example.com", Model: "other"})
+		if got.Config.APIBase != "https://proxy.example.com" || got.Config.Model != "other" {
+			t.Errorf("Config = %v %v, want the values of the config", got.Config.APIBase, got.Config.Model)
+		}
+	})
+
+	t.Run("OpenAI-compatible paths by default", func(t *testing.T) {
+		got := NewDeclarativeLLM(types.ProviderDefinition{Name: "plain", APIBase: "https://api.plain.com/v1"}, &types.ClientConfig{})
+		if got.Config.AnswerPath != "choices.0.message.content" || got.Config.StreamAnswerPath != "choices.0.delta.content" {
+			t.Errorf("Config paths = %v %v, want the OpenAI-compatible paths", got.Config.AnswerPath, got.Config.StreamAnswerPath)
+		}
+	})
+}
+
+func TestDeclarativeLLM_BuildHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition types.ProviderDefinition
+		want       map[string]string
+	}{
+		{
+			name:       "bearer",
+			definition: types.ProviderDefinition{AuthStyle: types.AuthStyleBearer},
+			want:       map[string]string{"Content-Type": "application/json", "Authorization": "Bearer key", "X-Extra": "1"},
+		},
+		{
+			name:       "bearer in a custom header",
+			definition: types.ProviderDefinition{AuthStyle: types.AuthStyleBearer, AuthHeader: "X-Token"},
+			want:       map[string]string{"Content-Type": "application/json", "X-Token": "Bearer key", "X-Extra": "1"},
+		},
+		{
+			name:       "plain key in a header",
+			definition: types.ProviderDefinition{AuthStyle: types.AuthStyleHeader, AuthHeader: "api-key"},
+			want:       map[string]string{"Content-Type": "application/json", "api-key": "key", "X-Extra": "1"},
+		},
+		{
+			name:       "no auth",
+			definition: types.ProviderDefinition{AuthStyle: types.AuthStyleNone},
+			want:       map[string]string{"Content-Type": "application/json", "X-Extra": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewDeclarativeLLM(tt.definition, &types.ClientConfig{APIKey: "key", ExtraHeaders: map[string]string{"X-Extra": "1"}})
+			if got := provider.BuildHeaders(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeclarativeLLM_GetUsage(t *testing.T) {
+	data := []byte(`{"usage": {"prompt_tokens": 1, "completion_tokens": 2, "total_tokens": 3}, "meta": {"input_tokens": 10, "output_tokens": 5}}`)
+
+	openAI := NewDeclarativeLLM(types.ProviderDefinition{Name: "a"}, &types.ClientConfig{})
+	got, err := openAI.GetUsage(data)
+	if err != nil || !reflect.DeepEqual(got, &types.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}) {
+		t.Errorf("GetUsage() = %v, %v, want the OpenAI-compatible usage", got, err)
+	}
+
+	custom := NewDeclarativeLLM(types.ProviderDefinition{Name: "b", UsagePaths: types.UsagePaths{
+		PromptTokens:     "meta.input_tokens",
+		CompletionTokens: "meta.output_tokens",
+	}}, &types.ClientConfig{})
+	got, err = custom.GetUsage(data)
+	if err != nil || !reflect.DeepEqual(got, &types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}) {
+		t.Errorf("GetUsage() = %v, %v, want the usage at the declared paths", got, err)
+	}
+
+	got, err = custom.GetUsage([]byte(`{"choices": []}`))
+	if err != nil || got != nil {
+		t.Errorf("GetUsage() = %v, %v, want nil without usage", got, err)
+	}
+}
+
+func TestDeclarativeLLM_MakeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/generate" {
+			t.Errorf("request path = %v, want /v1/generate", r.URL.Path)
+		}
+		if got := r.Header.Get("x-api-key"); got != "key" {
+			t.Errorf("x-api-key = %v, want key", got)
+		}
+		fmt.Fprint(w, `{"output": {"text": "feat: declarative provider"}}`)
+	}))
+	defer server.Close()
+
+	provider := NewDeclarativeLLM(types.ProviderDefinition{
+		Name:           "myvendor",
+		APIBase:        server.URL + "/v1",
+		AuthStyle:      types.AuthStyleHeader,
+		CompletionPath: "generate",
+		AnswerPath:     "output.text",
+	}, &types.ClientConfig{APIKey: "key"})
+
+	got, err := provider.MakeRequest(context.Background(), server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, false)
+	if err != nil {
+		t.Fatalf("MakeRequest() unexpected error: %v", err)
+	}
+	if got != "feat: declarative provider" {
+		t.Errorf("MakeRequest() = %v", got)
+	}
+}
@@ -153,7 +153,7 @@ func TestDeepSeekLLM_MakeRequestLogsEmptyContentWarning(t *testing.T) {
 		AnswerPath:     "choices.0.message.content",
 	})
 
-	got, err := llm.MakeRequest(context.Background(), client, "private prompt and diff", false)
+	got, err := llm.MakeRequest(context.Background(), client, []types.Message{{Role: types.RoleUser, Content: "private prompt and diff"}}, false)
 	if err != nil {
 		t.Fatalf("MakeRequest() error = %v", err)
 	}
@@ -195,7 +195,7 @@ func TestDeepSeekLLM_MakeRequestDoesNotLogWarningForNormalContent(t *testing.T)
 		AnswerPath: "choices.0.message.content",
 	})
 
-	got, err := llm.MakeRequest(context.Background(), client, "private prompt and diff", false)
+	got, err := llm.MakeRequest(context.Background(), client, []types.Message{{Role: types.RoleUser, Content: "private prompt and diff"}}, false)
 	if err != nil {
 		t.Fatalf("MakeRequest() error = %v", err)
 	}
@@ -224,7 +224,7 @@ func TestDeepSeekLLM_MakeRequestPreservesParseErrorForMissingAnswerPath(t *testi
 		AnswerPath: "choices.0.message.content",
 	})
 
-	got, err := llm.MakeRequest(context.Background(), client, "private prompt and diff", false)
+	got, err := llm.MakeRequest(context.Background(), client, []types.Message{{Role: types.RoleUser, Content: "private prompt and diff"}}, false)
 	if err == nil {
 		t.Fatal("MakeRequest() error = nil, want parse error")
 	}
@@ -0,0 +1,304 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/internal/logger"
+	"github.com/belingud/gptcomet/pkg/config"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// DefaultExecAnswerPath is the path of the answer in the output of the command,
+	// and of the content in every stream chunk
+	DefaultExecAnswerPath = "content"
+)
+
+// Environment variables passed to the command of the exec provider, in
+// addition to the environment of gptcomet
+const (
+	ExecEnvAPIKey  = "GPTCOMET_API_KEY"
+	ExecEnvAPIBase = "GPTCOMET_API_BASE"
+	ExecEnvModel   = "GPTCOMET_MODEL"
+)
+
+// ExecLLM implements the LLM interface with an external executable, so
+// proprietary endpoints can be used without changing gptcomet.
+//
+// The command receives the request as a JSON object on stdin, with the model,
+// messages and generation settings in the OpenAI-compatible format, plus
+// "stream" and the extra body of the config. It writes a JSON object with the
+// answer at the answer path ("content" by default) and an optional OpenAI-style
+// "usage" object to stdout, or plain text, which is used as the answer as is.
+// When streaming, it writes one JSON chunk per line instead, with the content at
+// the stream answer path, and may end the stream with {"done": true}. An
+// "error" field fails the request. The API key, API base and model are also
+// passed in the GPTCOMET_API_KEY, GPTCOMET_API_BASE and GPTCOMET_MODEL
+// environment variables.
+type ExecLLM struct {
+	*BaseLLM
+}
+
+// NewExecLLM creates a new ExecLLM
+func NewExecLLM(config *types.ClientConfig) *ExecLLM {
+	SetDefaultAnswerPath(config, DefaultExecAnswerPath)
+	if config.StreamAnswerPath == "" {
+		config.StreamAnswerPath = DefaultExecAnswerPath
+	}
+	return &ExecLLM{
+		BaseLLM: NewBaseLLM(config),
+	}
+}
+
+func (e *ExecLLM) Name() string {
+	return "exec"
+}
+
+// GetRequiredConfig returns provider-specific configuration requirements
+func (e *ExecLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
+	return map[string]config.ConfigRequirement{
+		"command": {
+			DefaultValue:  "",
+			PromptMessage: "Enter the path of the provider command",
+		},
+		"model": {
+			DefaultValue:  "",
+			PromptMessage: "Enter model name",
+		},
+		"max_tokens": {
+			DefaultValue:  "1024",
+			PromptMessage: "Enter max tokens",
+		},
+	}
+}
+
+// BuildURL returns the command, there is no URL to send requests to
+func (e *ExecLLM) BuildURL() string {
+	return e.Config.Command
+}
+
+// BuildHeaders returns no headers, the command handles authentication
+func (e *ExecLLM) BuildHeaders() map[string]string {
+	return map[string]string{}
+}
+
+// MakeRequest runs the command with the request on stdin and parses its output
+func (e *ExecLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	if stream {
+		var content strings.Builder
+		err := e.MakeStreamRequest(ctx, client, messages, func(chunk *types.CompletionResponse) error {
+			content.WriteString(chunk.Content)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(content.String()), nil
+	}
+
+	reqBody, err := e.requestBody(messages, false)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	cmd, err := e.command(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(reqBody)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	logger.Info("Running provider command %s...", e.Config.Command)
+	if err := cmd.Run(); err != nil {
+		return "", e.commandError(ctx, err, &stderr)
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	recordResponse(ctx, Response{Body: output})
+	if !json.Valid(output) || !gjson.ParseBytes(output).IsObject() {
+		// Simple commands may print the answer as plain text
+		return string(output), nil
+	}
+	if errMsg := gjson.GetBytes(output, "error"); errMsg.Exists() {
+		return "", e.commandError(ctx, fmt.Errorf("command reported an error: %s", errorText(errMsg)), nil)
+	}
+
+	usage, err := e.GetUsage(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to get usage: %w", err)
+	}
+	recordUsage(ctx, usage)
+
+	return e.ParseResponse(output)
+}
+
+// MakeStreamRequest runs the command with the request on stdin and calls
+// callback with the content of every chunk it writes to stdout.
+//
+// The timeout of the config applies until the command writes its first chunk.
+// After it, the command is stopped when it writes nothing for longer than the
+// stream idle timeout of the config.
+func (e *ExecLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) (err error) {
+	reqBody, err := e.requestBody(messages, true)
+	if err != nil {
+		return err
+	}
+
+	timeout := time.Duration(e.Config.Timeout) * time.Second
+	idleTimeout := time.Duration(e.Config.StreamIdleTimeout) * time.Second
+	ctx, idle := newIdleWatchdog(ctx, timeout, idleTimeout)
+	defer func() {
+		if err != nil && idle.expiredBeforeData() {
+			err = gptErrors.StreamTimeoutError(e.Name(), timeout)
+		} else if err != nil && idle.expired() {
+			err = gptErrors.StreamIdleTimeoutError(e.Name(), idleTimeout)
+		}
+		idle.stop()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd, err := e.command(ctx)
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(reqBody)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return e.commandError(ctx, err, nil)
+	}
+
+	logger.Info("Running provider command %s for streaming...", e.Config.Command)
+	if err := cmd.Start(); err != nil {
+		return e.commandError(ctx, err, &stderr)
+	}
+
+	body, recordStream := teeStream(ctx, Response{}, idle.reader(stdout))
+	usage, readErr := readStream(body, e, e, callback)
+	recordStream()
+	recordUsage(ctx, usage)
+	if readErr != nil {
+		cancel()
+		cmd.Wait()
+		return readErr
+	}
+
+	// Let the command finish writing after the last chunk, so it doesn't block
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return e.commandError(ctx, err, &stderr)
+	}
+	return nil
+}
+
+// BuildStreamURL returns the command, see BuildURL
+func (e *ExecLLM) BuildStreamURL() string {
+	return e.BuildURL()
+}
+
+// PrepareStreamPayload sets the "stream" flag
+func (e *ExecLLM) PrepareStreamPayload(payload map[string]interface{}) {
+	payload["stream"] = true
+}
+
+// ParseStreamChunk extracts the content at the stream answer path, failing on
+// chunks with an "error" field
+func (e *ExecLLM) ParseStreamChunk(data []byte) (string, error) {
+	if errMsg := gjson.GetBytes(data, "error"); errMsg.Exists() {
+		return "", fmt.Errorf("stream error: %s", errorText(errMsg))
+	}
+	return gjson.GetBytes(data, e.Config.StreamAnswerPath).String(), nil
+}
+
+// IsStreamDone reports whether the chunk has "done": true
+func (e *ExecLLM) IsStreamDone(data []byte) bool {
+	return gjson.GetBytes(data, "done").Bool()
+}
+
+// requestBody builds the JSON request written to the stdin of the command
+func (e *ExecLLM) requestBody(messages []types.Message, stream bool) ([]byte, error) {
+	payload, err := e.FormatMessages(messages)
+	if err != nil {
+		return nil, gptErrors.MessageFormattingError(err)
+	}
+	if payloadMap, ok := payload.(map[string]interface{}); ok {
+		payloadMap["stream"] = stream
+		// Merge extra body
+		maps.Copy(payloadMap, e.Config.ExtraBody)
+	}
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, gptErrors.RequestMarshalingError(err)
+	}
+	return reqBody, nil
+}
+
+// withTimeout returns a copy of ctx that is done when the timeout of the
+// config passes, and the function that releases it
+func (e *ExecLLM) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.Config.Timeout > 0 {
+		return context.WithTimeout(ctx, time.Duration(e.Config.Timeout)*time.Second)
+	}
+	return context.WithCancel(ctx)
+}
+
+// command creates the command of the config, which is stopped when ctx is done
+func (e *ExecLLM) command(ctx context.Context) (*exec.Cmd, error) {
+	if e.Config.Command == "" {
+		return nil, gptErrors.ProviderCommandError(e.Name(), e.Config.Command, errors.New("no command configured"))
+	}
+
+	cmd := exec.CommandContext(ctx, e.Config.Command, e.Config.CommandArgs...)
+	cmd.Env = append(os.Environ(),
+		ExecEnvAPIKey+"="+e.Config.APIKey,
+		ExecEnvAPIBase+"="+e.Config.APIBase,
+		ExecEnvModel+"="+e.Config.Model,
+	)
+	return cmd, nil
+}
+
+// commandError wraps a failure of the command with what it wrote to stderr.
+// When ctx is done, its error is returned instead, so cancelled and timed out
+// requests are not retried.
+func (e *ExecLLM) commandError(ctx context.Context, err error, stderr *bytes.Buffer) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if stderr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+	}
+	return gptErrors.ProviderCommandError(e.Name(), e.Config.Command, err)
+}
+
+// errorText returns the message of an "error" field, which may be a string or
+// an object with a message
+func errorText(errMsg gjson.Result) string {
+	if message := errMsg.Get("message"); message.Exists() {
+		return message.String()
+	}
+	return errMsg.String()
+}
@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+// TestExecHelperProcess is not a real test, it is the provider command run by
+// the exec tests. The mode is the last argument.
+func TestExecHelperProcess(t *testing.T) {
+	if os.Getenv("GPTCOMET_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	input, _ := io.ReadAll(os.Stdin)
+	var request map[string]interface{}
+	if err := json.Unmarshal(input, &request); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid request:", err)
+		os.Exit(2)
+	}
+	messages := request["messages"].([]interface{})
+	last := messages[len(messages)-1].(map[string]interface{})["content"]
+
+	switch os.Args[len(os.Args)-1] {
+	case "json":
+		fmt.Printf(`{"content": "echo: %s (%s, %s)", "usage": {"prompt_tokens": 3, "completion_tokens": 2}}`,
+			last, request["model"], os.Getenv(ExecEnvAPIKey))
+	case "text":
+		fmt.Printf("echo: %s\n", last)
+	case "stream":
+		if request["stream"] != true {
+			fmt.Fprintln(os.Stderr, "expected a streaming request")
+			os.Exit(2)
+		}
+		fmt.Println(`{"content": "feat: "}`)
+		fmt.Println(`{"content": "stream"}`)
+		fmt.Println(`{"done": true, "usage": {"prompt_tokens": 3, "completion_tokens": 2}}`)
+	case "slow-stream":
+		// Thinks before the first chunk, then streams slowly
+		time.Sleep(1500 * time.Millisecond)
+		for _, content := range []string{"feat: ", "slow", " stream"} {
+			fmt.Printf("{\"content\": %q}\n", content)
+			time.Sleep(500 * time.Millisecond)
+		}
+	case "error":
+		fmt.Println(`{"error": {"message": "gateway rejected the token"}}`)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "cannot reach gateway")
+		os.Exit(1)
+	}
+}
+
+func newHelperExecLLM(t *testing.T, mode string) *ExecLLM {
+	t.Setenv("GPTCOMET_WANT_HELPER_PROCESS", "1")
+	return NewExecLLM(&types.ClientConfig{
+		Command:     os.Args[0],
+		CommandArgs: []string{"-test.run=TestExecHelperProcess", "--", mode},
+		Model:       "internal-model",
+		APIKey:      "secret",
+	})
+}
+
+func TestExecLLM_MakeRequest(t *testing.T) {
+	messages := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	t.Run("json output", func(t *testing.T) {
+		var recorded []types.Usage
+		ctx := WithUsageRecorder(context.Background(), func(usage types.Usage) {
+			recorded = append(recorded, usage)
+		})
+
+		got, err := newHelperExecLLM(t, "json").MakeRequest(ctx, nil, messages, false)
+		if err != nil {
+			t.Fatalf("MakeRequest() unexpected error: %v", err)
+		}
+		if got != "echo: hi (internal-model, secret)" {
+			t.Errorf("MakeRequest() = %v", got)
+		}
+		if len(recorded) != 1 || recorded[0].TotalTokens != 5 {
+			t.Errorf("recorded usage = %v, want 5 total tokens", recorded)
+		}
+	})
+
+	t.Run("plain text output", func(t *testing.T) {
+		got, err := newHelperExecLLM(t, "text").MakeRequest(context.Background(), nil, messages, false)
+		if err != nil {
+			t.Fatalf("MakeRequest() unexpected error: %v", err)
+		}
+		if got != "echo: hi" {
+			t.Errorf("MakeRequest() = %v", got)
+		}
+	})
+
+	t.Run("error field", func(t *testing.T) {
+		_, err := newHelperExecLLM(t, "error").MakeRequest(context.Background(), nil, messages, false)
+		if err == nil || !strings.Contains(err.Error(), "gateway rejected the token") {
+			t.Errorf("MakeRequest() error = %v, want the reported error", err)
+		}
+	})
+
+	t.Run("failing command", func(t *testing.T) {
+		_, err := newHelperExecLLM(t, "fail").MakeRequest(context.Background(), nil, messages, false)
+		if !gptErrors.HasTitle(err, gptErrors.ErrTitleProviderCommand) || !strings.Contains(err.Error(), "cannot reach gateway") {
+			t.Errorf("MakeRequest() error = %v, want a provider command error with stderr", err)
+		}
+	})
+
+	t.Run("no command", func(t *testing.T) {
+		_, err := NewExecLLM(&types.ClientConfig{}).MakeRequest(context.Background(), nil, messages, false)
+		if !gptErrors.HasTitle(err, gptErrors.ErrTitleProviderCommand) {
+			t.Errorf("MakeRequest() error = %v, want a provider command error", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := newHelperExecLLM(t, "json").MakeRequest(ctx, nil, messages, false)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("MakeRequest() error = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestExecLLM_MakeStreamRequest(t *testing.T) {
+	messages := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	var chunks []string
+	err := newHelperExecLLM(t, "stream").MakeStreamRequest(context.Background(), nil, messages, func(chunk *types.CompletionResponse) error {
+		chunks = append(chunks, chunk.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("MakeStreamRequest() unexpected error: %v", err)
+	}
+	if strings.Join(chunks, "|") != "feat: |stream" {
+		t.Errorf("chunks = %q", chunks)
+	}
+
+	got, err := newHelperExecLLM(t, "stream").MakeRequest(context.Background(), nil, messages, true)
+	if err != nil || got != "feat: stream" {
+		t.Errorf("MakeRequest(stream) = %v, %v", got, err)
+	}
+}
+
+func TestExecLLM_MakeStreamRequestTimeouts(t *testing.T) {
+	messages := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	t.Run("wait for the first chunk isn't idle", func(t *testing.T) {
+		provider := newHelperExecLLM(t, "slow-stream")
+		provider.Config.Timeout = 2
+		provider.Config.StreamIdleTimeout = 1
+
+		got, err := provider.MakeRequest(context.Background(), nil, messages, true)
+		if err != nil || got != "feat: slow stream" {
+			t.Errorf("MakeRequest(stream) = %v, %v", got, err)
+		}
+	})
+
+	t.Run("no chunk within the timeout", func(t *testing.T) {
+		provider := newHelperExecLLM(t, "slow-stream")
+		provider.Config.Timeout = 1
+
+		_, err := provider.MakeRequest(context.Background(), nil, messages, true)
+		if !gptErrors.HasTitle(err, gptErrors.ErrTitleStreamTimeout) {
+			t.Fatalf("MakeRequest(stream) error = %v, want a stream timeout error", err)
+		}
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/tidwall/gjson"
 
 	"github.com/belingud/gptcomet/internal/debug"
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
 )
@@ -76,13 +77,8 @@ func (g *GeminiLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // FormatMessages formats messages for Gemini API
-func (g *GeminiLLM) FormatMessages(message string) (interface{}, error) {
-	var contents []map[string]interface{}
-
-	contents = append(contents, map[string]interface{}{
-		"role":  "user",
-		"parts": []map[string]string{{"text": message}},
-	})
+func (g *GeminiLLM) FormatMessages(messages []types.Message) (interface{}, error) {
+	contents, systemInstruction := formatGoogleContents(messages)
 
 	payload := map[string]interface{}{
 		"contents": contents,
@@ -90,6 +86,9 @@ func (g *GeminiLLM) FormatMessages(message string) (interface{}, error) {
 			"maxOutputTokens": g.Config.MaxTokens,
 		},
 	}
+	if systemInstruction != nil {
+		payload["systemInstruction"] = systemInstruction
+	}
 
 	if g.Config.Temperature > 0 {
 		payload["generationConfig"].(map[string]interface{})["temperature"] = g.Config.Temperature
@@ -108,6 +107,35 @@ func (g *GeminiLLM) FormatMessages(message string) (interface{}, error) {
 	return payload, nil
 }
 
+// formatGoogleContents converts messages to Gemini and Vertex AI contents.
+// Assistant messages use the "model" role, and system messages are joined
+// into a system instruction, which is nil when there are none.
+func formatGoogleContents(messages []types.Message) ([]map[string]interface{}, map[string]interface{}) {
+	var system []string
+	contents := []map[string]interface{}{}
+	for _, msg := range messages {
+		role := msg.Role
+		switch role {
+		case types.RoleSystem:
+			system = append(system, msg.Content)
+			continue
+		case types.RoleAssistant:
+			role = "model"
+		}
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": []map[string]string{{"text": msg.Content}},
+		})
+	}
+
+	if len(system) == 0 {
+		return contents, nil
+	}
+	return contents, map[string]interface{}{
+		"parts": []map[string]string{{"text": strings.Join(system, "\n\n")}},
+	}
+}
+
 // BuildURL builds the API URL
 func (g *GeminiLLM) BuildURL() string {
 	return fmt.Sprintf("%s/%s:generateContent?key=%s", strings.TrimSuffix(g.Config.APIBase, "/"), g.Config.Model, g.Config.APIKey)
@@ -118,6 +146,19 @@ func (g *GeminiLLM) BuildStreamURL() string {
 	return fmt.Sprintf("%s/%s:streamGenerateContent?alt=sse&key=%s", strings.TrimSuffix(g.Config.APIBase, "/"), g.Config.Model, g.Config.APIKey)
 }
 
+// PrepareStreamPayload leaves the payload unchanged, Gemini selects streaming by URL
+func (g *GeminiLLM) PrepareStreamPayload(payload map[string]interface{}) {}
+
+// ParseStreamChunk extracts the text of a Gemini stream chunk
+func (g *GeminiLLM) ParseStreamChunk(data []byte) (string, error) {
+	return parseGoogleStreamChunk(data, g.Config.StreamAnswerPath)
+}
+
+// IsStreamDone reports whether the chunk carries a finish reason
+func (g *GeminiLLM) IsStreamDone(data []byte) bool {
+	return isGoogleStreamDone(data)
+}
+
 // BuildHeaders builds request headers
 func (g *GeminiLLM) BuildHeaders() map[string]string {
 	return map[string]string{
@@ -126,30 +167,39 @@ func (g *GeminiLLM) BuildHeaders() map[string]string {
 }
 
 // GetUsage returns usage information for the provider
-func (g *GeminiLLM) GetUsage(data []byte) (string, error) {
-	usage := gjson.GetBytes(data, "usageMetadata")
-	if !usage.Exists() {
-		return "", nil
+func (g *GeminiLLM) GetUsage(data []byte) (*types.Usage, error) {
+	return parseGoogleUsage(gjson.GetBytes(data, "usageMetadata")), nil
+}
+
+// parseGoogleUsage converts the usageMetadata object of Gemini and Vertex AI.
+// Thinking tokens are reported apart from the candidates and added to the completion tokens.
+func parseGoogleUsage(usage gjson.Result) *types.Usage {
+	if !usage.IsObject() {
+		return nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> promptTokenCount: %d, candidatesTokenCount: %d, totalTokenCount: %d",
-		usage.Get("promptTokenCount").Int(),
-		usage.Get("candidatesTokenCount").Int(),
-		usage.Get("totalTokenCount").Int(),
-	), nil
+	reasoning := int(usage.Get("thoughtsTokenCount").Int())
+	result := &types.Usage{
+		PromptTokens:     int(usage.Get("promptTokenCount").Int()),
+		CompletionTokens: int(usage.Get("candidatesTokenCount").Int()) + reasoning,
+		TotalTokens:      int(usage.Get("totalTokenCount").Int()),
+		CachedTokens:     int(usage.Get("cachedContentTokenCount").Int()),
+		ReasoningTokens:  reasoning,
+	}
+	if result.TotalTokens == 0 {
+		result.TotalTokens = result.PromptTokens + result.CompletionTokens
+	}
+	return result
 }
 
 // MakeRequest makes a request to the API
-func (g *GeminiLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	var url string
+func (g *GeminiLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 	if stream {
-		url = g.BuildStreamURL()
-	} else {
-		url = g.BuildURL()
+		return g.collectStream(ctx, client, g, messages)
 	}
+	url := g.BuildURL()
 	headers := g.BuildHeaders()
-	payload, err := g.FormatMessages(message)
+	payload, err := g.FormatMessages(messages)
 	if err != nil {
 		return "", fmt.Errorf("failed to format messages: %w", err)
 	}
@@ -180,20 +230,39 @@ func (g *GeminiLLM) MakeRequest(ctx context.Context, client *http.Client, messag
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %s", err)
 	}
+	recordResponse(ctx, Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody})
 
 	debug.Printf("Response: %s", string(respBody))
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return "", gptErrors.ResponseStatusError(g.Name(), resp.StatusCode, resp.Header, string(respBody))
 	}
 
 	usage, err := g.GetUsage(respBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to get usage: %w", err)
 	}
-	if usage != "" {
-		fmt.Printf("%s\n", usage)
-	}
+	recordUsage(ctx, usage)
 
 	return g.ParseResponse(respBody)
 }
+
+// MakeStreamRequest makes a streaming request to the API
+func (g *GeminiLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return g.BaseLLM.MakeStreamRequest(ctx, client, g, messages, callback)
+}
+
+// ListModels lists the models of the Gemini API that can generate content.
+// The API base already ends with "models", and the names of the API start
+// with "models/", which BuildURL adds itself.
+func (g *GeminiLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	url := fmt.Sprintf("%s?pageSize=1000&key=%s", strings.TrimSuffix(g.Config.APIBase, "/"), g.Config.APIKey)
+	models, err := fetchModels(ctx, client, g, url, `models.#(supportedGenerationMethods.#(=="generateContent"))#.name`)
+	if err != nil {
+		return nil, err
+	}
+	for i, model := range models {
+		models[i] = strings.TrimPrefix(model, "models/")
+	}
+	return models, nil
+}
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/gptcomet/pkg/types"
@@ -152,7 +153,7 @@ func TestGeminiLLM_FormatMessages(t *testing.T) {
 	})
 
 	message := "test message"
-	got, err := llm.FormatMessages(message)
+	got, err := llm.FormatMessages([]types.Message{{Role: types.RoleUser, Content: message}})
 	if err != nil {
 		t.Errorf("FormatMessages() error = %v", err)
 		return
@@ -193,7 +194,9 @@ func TestGeminiLLM_GetUsage(t *testing.T) {
 		"usageMetadata": {
 			"promptTokenCount": 10,
 			"candidatesTokenCount": 20,
-			"totalTokenCount": 30
+			"thoughtsTokenCount": 5,
+			"cachedContentTokenCount": 4,
+			"totalTokenCount": 35
 		}
 	}`)
 
@@ -203,8 +206,42 @@ func TestGeminiLLM_GetUsage(t *testing.T) {
 		return
 	}
 
-	expected := "Token usage> promptTokenCount: 10, candidatesTokenCount: 20, totalTokenCount: 30"
-	if usage != expected {
-		t.Errorf("GetUsage() = %v, want %v", usage, expected)
+	expected := &types.Usage{PromptTokens: 10, CompletionTokens: 25, TotalTokens: 35, CachedTokens: 4, ReasoningTokens: 5}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("GetUsage() = %+v, want %+v", usage, expected)
+	}
+}
+
+func TestGeminiLLM_FormatMessagesConversation(t *testing.T) {
+	llm := NewGeminiLLM(&types.ClientConfig{MaxTokens: 1024})
+
+	got, err := llm.FormatMessages([]types.Message{
+		{Role: types.RoleSystem, Content: "Be brief"},
+		{Role: types.RoleUser, Content: "first"},
+		{Role: types.RoleAssistant, Content: "answer"},
+		{Role: types.RoleUser, Content: "second"},
+	})
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+
+	payload := got.(map[string]interface{})
+	instruction, ok := payload["systemInstruction"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("FormatMessages() missing systemInstruction")
+	}
+	if parts := instruction["parts"].([]map[string]string); parts[0]["text"] != "Be brief" {
+		t.Errorf("systemInstruction text = %v, want %q", parts[0]["text"], "Be brief")
+	}
+
+	contents := payload["contents"].([]map[string]interface{})
+	wantRoles := []string{"user", "model", "user"}
+	if len(contents) != len(wantRoles) {
+		t.Fatalf("len(contents) = %d, want %d", len(contents), len(wantRoles))
+	}
+	for i, content := range contents {
+		if content["role"] != wantRoles[i] {
+			t.Errorf("contents[%d].role = %v, want %s", i, content["role"], wantRoles[i])
+		}
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"github.com/belingud/gptcomet/internal/debug"
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/tidwall/gjson"
@@ -69,13 +70,7 @@ func (g *GroqLLM) BuildHeaders() map[string]string {
 	return headers
 }
 
-func (g *GroqLLM) FormatMessages(message string) (interface{}, error) {
-	messages := []types.Message{}
-	messages = append(messages, types.Message{
-		Role:    "user",
-		Content: message,
-	})
-
+func (g *GroqLLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	payload := map[string]interface{}{
 		"model":                 g.Config.Model,
 		"messages":              messages,
@@ -96,36 +91,32 @@ func (g *GroqLLM) FormatMessages(message string) (interface{}, error) {
 // The function takes the following parameters:
 //   - data: the response data from the provider
 //
-// The function returns a string describing the token usage and an error if the
-// usage information is not found.
-func (g *GroqLLM) GetUsage(data []byte) (string, error) {
+// The function returns the token usage and an error if the usage information
+// is not found. Streamed chunks carry the usage in the "x_groq" object.
+func (g *GroqLLM) GetUsage(data []byte) (*types.Usage, error) {
 	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", fmt.Errorf("usage not found")
+	if !usage.IsObject() {
+		usage = gjson.GetBytes(data, "x_groq.usage")
+	}
+	if !usage.IsObject() {
+		return nil, fmt.Errorf("usage not found")
 	}
 
-	return fmt.Sprintf(
-		"Token usage> prompt tokens: %d, completion tokens: %d, total tokens: %d",
-		usage.Get("prompt_tokens").Int(),
-		usage.Get("completion_tokens").Int(),
-		usage.Get("total_tokens").Int(),
-	), nil
+	return parseOpenAIUsage(usage), nil
 }
 
 // MakeRequest makes a request to the API
-func (g *GroqLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+func (g *GroqLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	if stream {
+		return g.collectStream(ctx, client, g, messages)
+	}
 	url := g.BuildURL()
 	debug.Printf("🔗 URL: %s", url)
 	headers := g.BuildHeaders()
-	payload, err := g.FormatMessages(message)
+	payload, err := g.FormatMessages(messages)
 	if err != nil {
 		return "", fmt.Errorf("failed to format messages: %w", err)
 	}
-	if stream {
-		if payloadMap, ok := payload.(map[string]interface{}); ok {
-			payloadMap["stream"] = true
-		}
-	}
 
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
@@ -164,18 +155,27 @@ func (g *GroqLLM) MakeRequest(ctx context.Context, client *http.Client, message
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
+	recordResponse(ctx, Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody})
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return "", gptErrors.ResponseStatusError(g.Name(), resp.StatusCode, resp.Header, string(respBody))
 	}
 
 	usage, err := g.GetUsage(respBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to get usage: %w", err)
 	}
-	if usage != "" {
-		fmt.Printf("%s\n", usage)
-	}
+	recordUsage(ctx, usage)
 
 	return g.ParseResponse(respBody)
 }
+
+// MakeStreamRequest makes a streaming request to the API
+func (g *GroqLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return g.BaseLLM.MakeStreamRequest(ctx, client, g, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (g *GroqLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return g.BaseLLM.ListModels(ctx, client, g)
+}
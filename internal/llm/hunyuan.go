@@ -49,6 +49,16 @@ func (h *HunyuanLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // MakeRequest makes a request to the API
-func (h *HunyuanLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return h.OpenAILLM.MakeRequest(ctx, client, message, stream)
+func (h *HunyuanLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return h.OpenAILLM.MakeRequest(ctx, client, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (h *HunyuanLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return h.BaseLLM.MakeStreamRequest(ctx, client, h, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (h *HunyuanLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return h.BaseLLM.ListModels(ctx, client, h)
 }
@@ -54,6 +54,16 @@ func (k *KimiLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // MakeRequest makes a request to the Kimi API
-func (k *KimiLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return k.BaseLLM.MakeRequest(ctx, client, k, message, stream)
+func (k *KimiLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return k.BaseLLM.MakeRequest(ctx, client, k, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (k *KimiLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return k.BaseLLM.MakeStreamRequest(ctx, client, k, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (k *KimiLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return k.BaseLLM.ListModels(ctx, client, k)
 }
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,16 +10,22 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"maps"
 
+	"github.com/belingud/gptcomet/internal/constants"
 	"github.com/belingud/gptcomet/internal/debug"
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/internal/logger"
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/tidwall/gjson"
 )
 
+// StreamCallback is called with every content chunk of a streaming response
+type StreamCallback func(chunk *types.CompletionResponse) error
+
 // LLM is the interface that all LLM providers must implement
 type LLM interface {
 	// Name returns the name of the provider
@@ -29,14 +36,19 @@ type LLM interface {
 	// GetRequiredConfig returns provider-specific configuration requirements
 	GetRequiredConfig() map[string]config.ConfigRequirement
 
-	// FormatMessages formats messages for the provider's API
-	FormatMessages(message string) (interface{}, error)
+	// FormatMessages formats the conversation for the provider's API.
+	// Messages carry the system, user and assistant roles in conversation order.
+	FormatMessages(messages []types.Message) (interface{}, error)
 
 	// MakeRequest makes a request to the API
-	MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error)
+	MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error)
+
+	// MakeStreamRequest makes a streaming request to the API and calls callback for each chunk
+	MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error
 
-	// GetUsage returns usage information for the provider
-	GetUsage(data []byte) (string, error)
+	// GetUsage returns the token usage of a response, or nil if the
+	// response doesn't report it
+	GetUsage(data []byte) (*types.Usage, error)
 
 	// BuildHeaders builds request headers
 	BuildHeaders() map[string]string
@@ -108,13 +120,7 @@ func (b *BaseLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 //
 // This is a default implementation which should be overridden by the
 // provider if it needs to format the messages differently.
-func (b *BaseLLM) FormatMessages(message string) (interface{}, error) {
-	messages := []types.Message{}
-	messages = append(messages, types.Message{
-		Role:    "user",
-		Content: message,
-	})
-
+func (b *BaseLLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	payload := map[string]interface{}{
 		"model":                 b.Config.Model,
 		"messages":              messages,
@@ -172,29 +178,12 @@ func (b *BaseLLM) ParseResponse(response []byte) (string, error) {
 	return strings.TrimSpace(text), nil
 }
 
-// GetUsage returns a string representing the token usage of the response.
-// It tries to extract the usage information from the response data using the
-// following field names: "prompt_tokens", "completion_tokens", and "total_tokens".
-// If the information is not found, it returns an empty string.
-func (b *BaseLLM) GetUsage(data []byte) (string, error) {
-	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", nil
-	}
-
-	var promptTokens, completionTokens, totalTokens int64
-
-	// Try different field names used by different providers
-	promptTokens = usage.Get("prompt_tokens").Int()
-	completionTokens = usage.Get("completion_tokens").Int()
-	totalTokens = usage.Get("total_tokens").Int()
-
-	return fmt.Sprintf(
-		"Token usage> prompt: %d, completion: %d, total: %d",
-		promptTokens,
-		completionTokens,
-		totalTokens,
-	), nil
+// GetUsage returns the token usage of an OpenAI-compatible response.
+// It reads the "usage" object with the "prompt_tokens", "completion_tokens" and
+// "total_tokens" fields, plus the cached and reasoning token details when present.
+// If the usage object is not found, it returns nil.
+func (b *BaseLLM) GetUsage(data []byte) (*types.Usage, error) {
+	return parseOpenAIUsage(gjson.GetBytes(data, "usage")), nil
 }
 
 // MakeRequest makes a request to the provider's API, formats the response, and
@@ -206,24 +195,23 @@ func (b *BaseLLM) GetUsage(data []byte) (string, error) {
 //   - ctx: the context for the request
 //   - client: the HTTP client to use for the request
 //   - provider: the provider to make the request to
-//   - message: the message to send to the provider
-//   - history: the message history to send to the provider
+//   - messages: the conversation to send, with the system, user and assistant messages in order
+//   - stream: whether to stream the response; streamed chunks are joined before returning
 //
 // The function returns the response from the provider as a string, or an error
 // if the request fails.
-func (b *BaseLLM) MakeRequest(ctx context.Context, client *http.Client, provider LLM, message string, stream bool) (string, error) {
+func (b *BaseLLM) MakeRequest(ctx context.Context, client *http.Client, provider LLM, messages []types.Message, stream bool) (string, error) {
+	if stream {
+		return b.collectStream(ctx, client, provider, messages)
+	}
+
 	url := provider.BuildURL()
 	debug.Printf("🔗 URL: %s", url)
 	headers := provider.BuildHeaders()
-	payload, err := provider.FormatMessages(message)
+	payload, err := provider.FormatMessages(messages)
 	if err != nil {
 		return "", fmt.Errorf("failed to format messages: %w", err)
 	}
-	if stream {
-		if payloadMap, ok := payload.(map[string]interface{}); ok {
-			payloadMap["stream"] = true
-		}
-	}
 
 	// Merge extra body
 	if payloadMap, ok := payload.(map[string]interface{}); ok && len(b.Config.ExtraBody) > 0 {
@@ -253,46 +241,225 @@ func (b *BaseLLM) MakeRequest(ctx context.Context, client *http.Client, provider
 	}
 	defer resp.Body.Close()
 
-	// If not streaming, read the entire response body
-	if !stream {
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", fmt.Errorf("failed to read response: %s", err)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %s", err)
+	}
+	recordResponse(ctx, Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody})
+
+	if resp.StatusCode != http.StatusOK {
+		return "", gptErrors.ResponseStatusError(provider.Name(), resp.StatusCode, resp.Header, string(respBody))
+	}
+
+	usage, err := provider.GetUsage(respBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to get usage: %w", err)
+	}
+	recordUsage(ctx, usage)
+
+	content, err := provider.ParseResponse(respBody)
+	if err != nil {
+		return "", err
+	}
+	b.warnIfDeepSeekEmptyResponse(provider, url, resp.StatusCode, respBody, content)
+	return content, nil
+}
+
+// MakeStreamRequest makes a streaming request to the provider's API and calls
+// callback with every content chunk as soon as it arrives.
+//
+// The response body is read incrementally. Both Server-Sent Events ("data:"
+// lines, terminated by "[DONE]") and newline-delimited JSON (Ollama) are
+// supported. The stream URL, payload changes, chunk parsing and end-of-stream
+// detection come from the provider's StreamingProvider implementation, or the
+// OpenAI-compatible default when it has none.
+//
+// The timeout of client applies until the first data of the stream arrives.
+// After it, the request is aborted when the provider sends no data for longer
+// than the stream idle timeout of the config, so a long stream that keeps
+// sending data isn't cut off.
+func (b *BaseLLM) MakeStreamRequest(ctx context.Context, client *http.Client, provider LLM, messages []types.Message, callback StreamCallback) (err error) {
+	payload, err := provider.FormatMessages(messages)
+	if err != nil {
+		return gptErrors.MessageFormattingError(err)
+	}
+
+	streaming := b.streamingFor(provider)
+	url := streaming.BuildStreamURL()
+	if payloadMap, ok := payload.(map[string]interface{}); ok {
+		streaming.PrepareStreamPayload(payloadMap)
+		// Merge extra body
+		if len(b.Config.ExtraBody) > 0 {
+			maps.Copy(payloadMap, b.Config.ExtraBody)
 		}
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return gptErrors.RequestMarshalingError(err)
+	}
+
+	logger.Debug("Request URL: %s", sanitizeRequestURLForLogging(url))
+
+	// The timeout of the client only covers the wait for the first data, a
+	// stream that keeps sending data may take longer
+	timeout := client.Timeout
+	streamClient := *client
+	streamClient.Timeout = 0
+	idleTimeout := time.Duration(b.Config.StreamIdleTimeout) * time.Second
+	ctx, idle := newIdleWatchdog(ctx, timeout, idleTimeout)
+	defer func() {
+		if err != nil && idle.expiredBeforeData() {
+			err = gptErrors.StreamTimeoutError(provider.Name(), timeout)
+		} else if err != nil && idle.expired() {
+			err = gptErrors.StreamIdleTimeoutError(provider.Name(), idleTimeout)
 		}
+		idle.stop()
+	}()
 
-		usage, err := provider.GetUsage(respBody)
-		if err != nil {
-			return "", fmt.Errorf("failed to get usage: %w", err)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+	if err != nil {
+		return gptErrors.RequestCreationError(err)
+	}
+
+	for k, v := range provider.BuildHeaders() {
+		req.Header.Set(k, v)
+	}
+
+	logger.Info("Sending streaming request to %s...", provider.Name())
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return gptErrors.RequestExecutionError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		recordResponse(ctx, Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody, Stream: true})
+		return gptErrors.ResponseStatusError(provider.Name(), resp.StatusCode, resp.Header, string(respBody))
+	}
+
+	logger.Debug("Request succeeded, processing streaming response")
+	body, recordStream := teeStream(ctx, Response{StatusCode: resp.StatusCode, Header: resp.Header}, idle.reader(resp.Body))
+	usage, err := readStream(body, provider, streaming, callback)
+	recordStream()
+	recordUsage(ctx, usage)
+	return err
+}
+
+// readStream reads a streaming response body line by line and passes every
+// non-empty content chunk to callback. It stops at the SSE "[DONE]" marker,
+// at a chunk the streaming protocol reports as the last one, or at the end
+// of the body.
+//
+// It returns the usage merged from the chunks that report one, as providers
+// send the usage of the whole response with the last chunks, or in parts:
+// Claude reports the input tokens at the start and the output tokens at the end.
+func readStream(body io.Reader, provider LLM, streaming StreamingProvider, callback StreamCallback) (usage *types.Usage, err error) {
+	scanner := bufio.NewScanner(body)
+	// Allow chunks larger than the default 64KB token size
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		data, ok := parseStreamLine(scanner.Text())
+		if !ok {
+			continue
 		}
-		if usage != "" {
-			fmt.Printf("%s\n", usage)
+		if data == constants.SSEDone {
+			return usage, nil
 		}
 
-		content, err := provider.ParseResponse(respBody)
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &raw); err != nil {
+			logger.Debug("Skipping non-JSON stream line: %s", data)
+			continue
+		}
+
+		content, err := streaming.ParseStreamChunk([]byte(data))
 		if err != nil {
-			return "", err
+			return usage, gptErrors.WrapError(err, "Response Parsing Failed", "Error occurred while parsing streaming response")
+		}
+
+		if chunkUsage, err := provider.GetUsage([]byte(data)); err == nil && chunkUsage != nil {
+			usage = mergeStreamUsage(usage, chunkUsage)
+		}
+
+		if content != "" {
+			if err := callback(&types.CompletionResponse{Content: content, Raw: raw}); err != nil {
+				return usage, gptErrors.CallbackError(err)
+			}
+		}
+
+		if streaming.IsStreamDone([]byte(data)) {
+			logger.Debug("Stream finished")
+			return usage, nil
 		}
-		b.warnIfDeepSeekEmptyResponse(provider, url, resp.StatusCode, respBody, content)
-		return content, nil
 	}
 
-	// For streaming, we'll return the entire response body as a string
-	// In a real implementation, this would parse the SSE stream and call a callback
-	// But for now, we'll just read the entire response to maintain compatibility
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %s", err)
+	if err := scanner.Err(); err != nil {
+		return usage, gptErrors.WrapError(err, "Response Reading Failed", "Error occurred while reading streaming response")
 	}
+	return usage, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+// mergeStreamUsage merges the usage of a stream chunk into the usage of the
+// previous chunks. The counts the chunk reports replace the previous ones, the
+// counts it leaves at zero are kept.
+func mergeStreamUsage(usage, chunk *types.Usage) *types.Usage {
+	if usage == nil {
+		return chunk
+	}
+	merged := *usage
+	if chunk.PromptTokens > 0 {
+		merged.PromptTokens = chunk.PromptTokens
+	}
+	if chunk.CompletionTokens > 0 {
+		merged.CompletionTokens = chunk.CompletionTokens
+	}
+	if chunk.CachedTokens > 0 {
+		merged.CachedTokens = chunk.CachedTokens
+	}
+	if chunk.ReasoningTokens > 0 {
+		merged.ReasoningTokens = chunk.ReasoningTokens
+	}
+	merged.TotalTokens = max(chunk.TotalTokens, merged.PromptTokens+merged.CompletionTokens)
+	return &merged
+}
+
+// parseStreamLine extracts the JSON payload from a single stream line.
+//
+// SSE lines carry the payload after a "data:" prefix (with or without a
+// trailing space); other SSE fields such as "event:" and comments are skipped.
+// Lines without any SSE field are treated as NDJSON and returned as-is.
+// The second return value is false when the line holds no payload.
+func parseStreamLine(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, ":") {
+		return "", false
+	}
+	if strings.HasPrefix(line, "data:") {
+		return strings.TrimSpace(strings.TrimPrefix(line, "data:")), true
+	}
+	for _, field := range []string{"event:", "id:", "retry:"} {
+		if strings.HasPrefix(line, field) {
+			return "", false
+		}
 	}
+	return line, true
+}
 
-	return provider.ParseResponse(respBody)
+// collectStream makes a streaming request and returns the joined content of all chunks.
+func (b *BaseLLM) collectStream(ctx context.Context, client *http.Client, provider LLM, messages []types.Message) (string, error) {
+	var content strings.Builder
+	err := b.MakeStreamRequest(ctx, client, provider, messages, func(chunk *types.CompletionResponse) error {
+		content.WriteString(chunk.Content)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(content.String()), nil
 }
 
 func (b *BaseLLM) warnIfDeepSeekEmptyResponse(provider LLM, requestURL string, statusCode int, respBody []byte, content string) {
@@ -357,6 +524,11 @@ func (d *DefaultLLM) Name() string {
 }
 
 // MakeRequest implements the LLM interface for DefaultLLM.
-func (d *DefaultLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return d.BaseLLM.MakeRequest(ctx, client, d, message, stream)
+func (d *DefaultLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return d.BaseLLM.MakeRequest(ctx, client, d, messages, stream)
+}
+
+// MakeStreamRequest implements the LLM interface for DefaultLLM.
+func (d *DefaultLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return d.BaseLLM.MakeStreamRequest(ctx, client, d, messages, callback)
 }
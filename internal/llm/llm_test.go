@@ -1,8 +1,17 @@
 package llm
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/pkg/types"
 )
 
@@ -68,7 +77,7 @@ func TestBaseLLM_FormatMessages(t *testing.T) {
 
 	message := "test message"
 
-	got, err := llm.FormatMessages(message)
+	got, err := llm.FormatMessages([]types.Message{{Role: types.RoleUser, Content: message}})
 	if err != nil {
 		t.Errorf("FormatMessages() error = %v", err)
 		return
@@ -244,7 +253,7 @@ func TestBaseLLM_GetUsage(t *testing.T) {
 	tests := []struct {
 		name    string
 		data    []byte
-		want    string
+		want    *types.Usage
 		wantErr bool
 	}{
 		{
@@ -256,13 +265,13 @@ func TestBaseLLM_GetUsage(t *testing.T) {
 					"total_tokens": 30
 				}
 			}`),
-			want:    "Token usage> prompt: 10, completion: 20, total: 30",
+			want:    &types.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
 			wantErr: false,
 		},
 		{
 			name:    "no usage info",
 			data:    []byte(`{}`),
-			want:    "",
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -275,9 +284,145 @@ func TestBaseLLM_GetUsage(t *testing.T) {
 				t.Errorf("GetUsage() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("GetUsage() = %s, want %s", got, tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestBaseLLM_MakeStreamRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   func(config *types.ClientConfig) LLM
+		body       string
+		status     int
+		wantPath   string
+		wantChunks []string
+		wantErr    string
+	}{
+		{
+			name:     "openai sse",
+			provider: func(config *types.ClientConfig) LLM { return NewOpenAILLM(config) },
+			body: "data: {\"choices\":[{\"delta\":{\"content\":\"hello\"}}]}\n\n" +
+				": keep-alive\n\n" +
+				"data:{\"choices\":[{\"delta\":{\"content\":\" world\"}}]}\n\n" +
+				"data: [DONE]\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"ignored\"}}]}\n\n",
+			status:     http.StatusOK,
+			wantPath:   "/chat/completions",
+			wantChunks: []string{"hello", " world"},
+		},
+		{
+			name:     "claude sse with event lines",
+			provider: func(config *types.ClientConfig) LLM { return NewClaudeLLM(config) },
+			body: "event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+			status:     http.StatusOK,
+			wantPath:   "/messages",
+			wantChunks: []string{"Hi"},
+		},
+		{
+			name:     "ollama ndjson",
+			provider: func(config *types.ClientConfig) LLM { return NewOllamaLLM(config) },
+			body: "{\"response\":\"fix\",\"done\":false}\n" +
+				"{\"response\":\": bug\",\"done\":false}\n" +
+				"{\"response\":\"\",\"done\":true}\n",
+			status:     http.StatusOK,
+			wantPath:   "/generate",
+			wantChunks: []string{"fix", ": bug"},
+		},
+		{
+			name:       "gemini stream url",
+			provider:   func(config *types.ClientConfig) LLM { return NewGeminiLLM(config) },
+			body:       "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"ok\"}]}}]}\n\n",
+			status:     http.StatusOK,
+			wantPath:   "/gemini-2.0-flash:streamGenerateContent",
+			wantChunks: []string{"ok"},
+		},
+		{
+			name:     "error status",
+			provider: func(config *types.ClientConfig) LLM { return NewOpenAILLM(config) },
+			body:     `{"error":"bad request"}`,
+			status:   http.StatusBadRequest,
+			wantErr:  "status code 400",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			provider := tt.provider(&types.ClientConfig{APIBase: server.URL})
+			var chunks []string
+			err := provider.MakeStreamRequest(context.Background(), server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, func(chunk *types.CompletionResponse) error {
+				chunks = append(chunks, chunk.Content)
+				return nil
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("MakeStreamRequest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MakeStreamRequest() unexpected error: %v", err)
+			}
+			if !strings.HasSuffix(gotPath, tt.wantPath) {
+				t.Errorf("request path = %s, want suffix %s", gotPath, tt.wantPath)
+			}
+			if strings.Join(chunks, "|") != strings.Join(tt.wantChunks, "|") {
+				t.Errorf("chunks = %q, want %q", chunks, tt.wantChunks)
+			}
+		})
+	}
+}
+
+func TestBaseLLM_MakeRequestStreamCollectsChunks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"feat: \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"stream\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL})
+	got, err := provider.MakeRequest(context.Background(), server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, true)
+	if err != nil {
+		t.Fatalf("MakeRequest() unexpected error: %v", err)
+	}
+	if got != "feat: stream" {
+		t.Errorf("MakeRequest() = %q, want %q", got, "feat: stream")
+	}
+}
+
+func TestBaseLLM_MakeRequestStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error": {"message": "slow down"}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL})
+	_, err := provider.MakeRequest(context.Background(), server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, false)
+
+	var gptErr *gptErrors.GPTCometError
+	if !errors.As(err, &gptErr) || gptErr.Title != gptErrors.ErrTitleAPIRequest {
+		t.Fatalf("MakeRequest() error = %v, want a rate limit error", err)
+	}
+	if code, ok := gptErrors.StatusCode(err); !ok || code != http.StatusTooManyRequests {
+		t.Errorf("StatusCode() = %d, %v, want 429, true", code, ok)
+	}
+	if wait, ok := gptErrors.RetryAfter(err); !ok || wait != 7*time.Second {
+		t.Errorf("RetryAfter() = %v, %v, want 7s, true", wait, ok)
+	}
+}
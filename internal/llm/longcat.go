@@ -53,6 +53,16 @@ func (l *LongCatLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // MakeRequest makes a request to the LongCat API
-func (l *LongCatLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return l.BaseLLM.MakeRequest(ctx, client, l, message, stream)
+func (l *LongCatLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return l.BaseLLM.MakeRequest(ctx, client, l, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (l *LongCatLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return l.BaseLLM.MakeStreamRequest(ctx, client, l, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (l *LongCatLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return l.BaseLLM.ListModels(ctx, client, l)
 }
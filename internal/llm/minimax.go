@@ -58,6 +58,16 @@ func (d *MinimaxLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // MakeRequest makes a request to the Minimax API
-func (d *MinimaxLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return d.BaseLLM.MakeRequest(ctx, client, d, message, stream)
+func (d *MinimaxLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return d.BaseLLM.MakeRequest(ctx, client, d, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (d *MinimaxLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return d.BaseLLM.MakeStreamRequest(ctx, client, d, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (d *MinimaxLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return d.BaseLLM.ListModels(ctx, client, d)
 }
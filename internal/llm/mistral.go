@@ -54,13 +54,7 @@ func (m *MistralLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // FormatMessages formats messages for Mistral API
-func (m *MistralLLM) FormatMessages(message string) (interface{}, error) {
-	messages := []types.Message{}
-	messages = append(messages, types.Message{
-		Role:    "user",
-		Content: message,
-	})
-
+func (m *MistralLLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	payload := map[string]interface{}{
 		"model":      m.Config.Model,
 		"messages":   messages,
@@ -83,6 +77,16 @@ func (m *MistralLLM) FormatMessages(message string) (interface{}, error) {
 }
 
 // MakeRequest makes a request to the Mistral API
-func (m *MistralLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return m.BaseLLM.MakeRequest(ctx, client, m, message, stream)
+func (m *MistralLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return m.BaseLLM.MakeRequest(ctx, client, m, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (m *MistralLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return m.BaseLLM.MakeStreamRequest(ctx, client, m, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (m *MistralLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return m.BaseLLM.ListModels(ctx, client, m)
 }
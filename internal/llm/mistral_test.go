@@ -180,7 +180,7 @@ func TestMistralLLM_FormatMessages(t *testing.T) {
 
 	message := "test message"
 
-	got, err := llm.FormatMessages(message)
+	got, err := llm.FormatMessages([]types.Message{{Role: types.RoleUser, Content: message}})
 	if err != nil {
 		t.Errorf("FormatMessages() error = %v", err)
 		return
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/internal/logger"
+	"github.com/tidwall/gjson"
+)
+
+// ModelLister is an optional interface for providers with an API that lists
+// the models available to the configured account.
+type ModelLister interface {
+	// ListModels returns the names of the available models, sorted
+	ListModels(ctx context.Context, client *http.Client) ([]string, error)
+}
+
+// ListModels returns the models available from provider, or a
+// ModelListingNotSupportedError when it doesn't implement ModelLister.
+func ListModels(ctx context.Context, client *http.Client, provider LLM) ([]string, error) {
+	lister, ok := provider.(ModelLister)
+	if !ok {
+		return nil, gptErrors.ModelListingNotSupportedError(provider.Name())
+	}
+	return lister.ListModels(ctx, client)
+}
+
+// ListModels lists the models of an OpenAI-compatible API, which returns them
+// in the "data" list of {api_base}/models
+func (b *BaseLLM) ListModels(ctx context.Context, client *http.Client, provider LLM) ([]string, error) {
+	return fetchModels(ctx, client, provider, b.modelsURL("models"), "data.#.id")
+}
+
+// modelsURL joins the API base and the path of the model-listing endpoint
+func (b *BaseLLM) modelsURL(path string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(b.Config.APIBase, "/"), strings.TrimPrefix(path, "/"))
+}
+
+// fetchModels sends a GET request to url with the headers of provider and
+// returns the sorted model names found at namesPath in the response.
+func fetchModels(ctx context.Context, client *http.Client, provider LLM, url, namesPath string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, gptErrors.RequestCreationError(err)
+	}
+	for k, v := range provider.BuildHeaders() {
+		if k == "Content-Type" {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	logger.Debug("Listing models from %s", sanitizeRequestURLForLogging(url))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, gptErrors.RequestExecutionError(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, gptErrors.ResponseStatusError(provider.Name(), resp.StatusCode, resp.Header, string(respBody))
+	}
+
+	result := gjson.GetBytes(respBody, namesPath)
+	if !result.IsArray() {
+		return nil, fmt.Errorf("failed to parse models: %s", string(respBody))
+	}
+
+	var models []string
+	for _, name := range result.Array() {
+		if name.String() != "" {
+			models = append(models, name.String())
+		}
+	}
+	slices.Sort(models)
+	return slices.Compact(models), nil
+}
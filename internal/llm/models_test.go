@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+func TestListModels(t *testing.T) {
+	tests := []struct {
+		name      string
+		newLLM    func(apiBase string) LLM
+		wantPath  string
+		wantQuery string
+		header    string
+		body      string
+		want      []string
+	}{
+		{
+			name: "openai",
+			newLLM: func(apiBase string) LLM {
+				return NewOpenAILLM(&types.ClientConfig{APIBase: apiBase, APIKey: "sk-test"})
+			},
+			wantPath: "/models",
+			header:   "Authorization",
+			body:     `{"object": "list", "data": [{"id": "gpt-4o"}, {"id": "gpt-4o-mini"}, {"id": "gpt-4o"}]}`,
+			want:     []string{"gpt-4o", "gpt-4o-mini"},
+		},
+		{
+			name: "deepseek",
+			newLLM: func(apiBase string) LLM {
+				return NewDeepSeekLLM(&types.ClientConfig{APIBase: apiBase, APIKey: "sk-test"})
+			},
+			wantPath: "/models",
+			header:   "Authorization",
+			body:     `{"data": [{"id": "deepseek-reasoner"}, {"id": "deepseek-chat"}]}`,
+			want:     []string{"deepseek-chat", "deepseek-reasoner"},
+		},
+		{
+			name: "claude",
+			newLLM: func(apiBase string) LLM {
+				return NewClaudeLLM(&types.ClientConfig{APIBase: apiBase, APIKey: "sk-test"})
+			},
+			wantPath:  "/models",
+			wantQuery: "limit=1000",
+			header:    "x-api-key",
+			body:      `{"data": [{"id": "claude-sonnet-4-0", "type": "model"}], "has_more": false}`,
+			want:      []string{"claude-sonnet-4-0"},
+		},
+		{
+			name:     "ollama",
+			newLLM:   func(apiBase string) LLM { return NewOllamaLLM(&types.ClientConfig{APIBase: apiBase}) },
+			wantPath: "/tags",
+			body:     `{"models": [{"name": "qwen3:8b"}, {"name": "llama3.2:latest"}]}`,
+			want:     []string{"llama3.2:latest", "qwen3:8b"},
+		},
+		{
+			name: "gemini",
+			newLLM: func(apiBase string) LLM {
+				return NewGeminiLLM(&types.ClientConfig{APIBase: apiBase + "/models", APIKey: "test-key"})
+			},
+			wantPath:  "/models",
+			wantQuery: "key=test-key&pageSize=1000",
+			body: `{"models": [
+				{"name": "models/gemini-2.5-flash", "supportedGenerationMethods": ["generateContent", "countTokens"]},
+				{"name": "models/text-embedding-004", "supportedGenerationMethods": ["embedContent"]}
+			]}`,
+			want: []string{"gemini-2.5-flash"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("method = %s, want GET", r.Method)
+				}
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.wantPath)
+				}
+				if tt.wantQuery != "" && r.URL.Query().Encode() != tt.wantQuery {
+					t.Errorf("query = %s, want %s", r.URL.Query().Encode(), tt.wantQuery)
+				}
+				if tt.header != "" && r.Header.Get(tt.header) == "" {
+					t.Errorf("header %s not set", tt.header)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			got, err := ListModels(context.Background(), server.Client(), tt.newLLM(server.URL))
+			if err != nil {
+				t.Fatalf("ListModels() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListModels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListModels_Errors(t *testing.T) {
+	t.Run("status error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"message": "invalid key"}}`))
+		}))
+		defer server.Close()
+
+		_, err := ListModels(context.Background(), server.Client(), NewOpenAILLM(&types.ClientConfig{APIBase: server.URL}))
+		if err == nil {
+			t.Fatal("ListModels() expected an error")
+		}
+		if !gptErrors.HasTitle(err, gptErrors.ErrTitleAPIAuth) {
+			t.Errorf("ListModels() error = %v, want an authentication error", err)
+		}
+	})
+
+	t.Run("unexpected response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result": "ok"}`))
+		}))
+		defer server.Close()
+
+		_, err := ListModels(context.Background(), server.Client(), NewOpenAILLM(&types.ClientConfig{APIBase: server.URL}))
+		if err == nil {
+			t.Error("ListModels() expected an error")
+		}
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		for _, provider := range []LLM{NewVertexLLM(&types.ClientConfig{}), NewExecLLM(&types.ClientConfig{})} {
+			_, err := ListModels(context.Background(), http.DefaultClient, provider)
+			if !gptErrors.HasTitle(err, gptErrors.ErrTitleModelListing) {
+				t.Errorf("ListModels(%s) error = %v, want a model listing error", provider.Name(), err)
+			}
+		}
+	})
+}
@@ -49,6 +49,16 @@ func (m *ModelScopeLLM) GetRequiredConfig() map[string]config.ConfigRequirement
 }
 
 // MakeRequest makes a request to the API
-func (m *ModelScopeLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return m.OpenAILLM.MakeRequest(ctx, client, message, stream)
+func (m *ModelScopeLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return m.OpenAILLM.MakeRequest(ctx, client, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (m *ModelScopeLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return m.BaseLLM.MakeStreamRequest(ctx, client, m, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (m *ModelScopeLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return m.BaseLLM.ListModels(ctx, client, m)
 }
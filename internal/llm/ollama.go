@@ -5,8 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
+	"github.com/tidwall/gjson"
+
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
 )
@@ -63,8 +68,10 @@ func (o *OllamaLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 	}
 }
 
-// FormatMessages formats messages for Ollama API
-func (o *OllamaLLM) FormatMessages(message string) (interface{}, error) {
+// FormatMessages formats messages for Ollama API.
+// The generate endpoint takes a single prompt, so system messages go to the
+// "system" field and earlier turns are written into the prompt as a transcript.
+func (o *OllamaLLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	options := map[string]interface{}{
 		"num_predict": o.Config.MaxTokens,
 	}
@@ -97,30 +104,77 @@ func (o *OllamaLLM) FormatMessages(message string) (interface{}, error) {
 		options["presence_penalty"] = o.Config.PresencePenalty
 	}
 
+	var system []string
+	var turns []types.Message
+	for _, msg := range messages {
+		if msg.Role == types.RoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		turns = append(turns, msg)
+	}
+
 	payload := map[string]interface{}{
 		"model":   o.Config.Model,
-		"prompt":  message,
+		"prompt":  formatOllamaPrompt(turns),
 		"options": options,
 	}
+	if len(system) > 0 {
+		payload["system"] = strings.Join(system, "\n\n")
+	}
 
 	return payload, nil
 }
 
-// GetUsage returns usage information for the provider
-func (o *OllamaLLM) GetUsage(data []byte) (string, error) {
-	return "", nil
+// formatOllamaPrompt returns the content of a single message as is, and
+// renders a multi-turn conversation as a role-labelled transcript.
+func formatOllamaPrompt(turns []types.Message) string {
+	if len(turns) == 1 {
+		return turns[0].Content
+	}
+	var prompt strings.Builder
+	for i, msg := range turns {
+		if i > 0 {
+			prompt.WriteString("\n\n")
+		}
+		role := "User"
+		if msg.Role == types.RoleAssistant {
+			role = "Assistant"
+		}
+		fmt.Fprintf(&prompt, "%s: %s", role, msg.Content)
+	}
+	return prompt.String()
+}
+
+// GetUsage returns usage information for the provider.
+// Ollama reports the counts in the final object of a response.
+func (o *OllamaLLM) GetUsage(data []byte) (*types.Usage, error) {
+	if !gjson.GetBytes(data, "done").Bool() {
+		return nil, nil
+	}
+
+	prompt := int(gjson.GetBytes(data, "prompt_eval_count").Int())
+	completion := int(gjson.GetBytes(data, "eval_count").Int())
+	return &types.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}, nil
 }
 
 // MakeRequest makes a request to the API
-func (o *OllamaLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	payload, err := o.FormatMessages(message)
+func (o *OllamaLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	if stream {
+		return o.collectStream(ctx, client, o, messages)
+	}
+
+	payload, err := o.FormatMessages(messages)
 	if err != nil {
 		return "", fmt.Errorf("failed to format messages: %w", err)
 	}
 
-	if stream {
-		payload.(map[string]interface{})["stream"] = true
-	}
+	// Ollama streams by default, ask for a single JSON object instead
+	payload.(map[string]interface{})["stream"] = false
 
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
@@ -143,20 +197,71 @@ func (o *OllamaLLM) MakeRequest(ctx context.Context, client *http.Client, messag
 	}
 	defer resp.Body.Close()
 
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	recordResponse(ctx, Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody})
+
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status: %s", resp.Status)
+		return "", gptErrors.ResponseStatusError(o.Name(), resp.StatusCode, resp.Header, string(respBody))
 	}
 
 	var result struct {
 		Response string `json:"response"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(respBody, &result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	usage, err := o.GetUsage(respBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to get usage: %w", err)
+	}
+	recordUsage(ctx, usage)
+
 	return result.Response, nil
 }
 
+// MakeStreamRequest makes a streaming request to the API.
+// Ollama responds with newline-delimited JSON objects instead of SSE.
+func (o *OllamaLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return o.BaseLLM.MakeStreamRequest(ctx, client, o, messages, callback)
+}
+
+// ListModels lists the models pulled to the Ollama server, from {api_base}/tags
+func (o *OllamaLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return fetchModels(ctx, client, o, o.modelsURL("tags"), "models.#.name")
+}
+
+// BuildStreamURL returns the generate endpoint, Ollama streams from the same URL
+func (o *OllamaLLM) BuildStreamURL() string {
+	return o.BuildURL()
+}
+
+// PrepareStreamPayload sets the "stream" flag explicitly, even though Ollama streams by default
+func (o *OllamaLLM) PrepareStreamPayload(payload map[string]interface{}) {
+	payload["stream"] = true
+}
+
+// ParseStreamChunk extracts the text of an Ollama NDJSON object.
+// Some models stream their reasoning in the "thinking" field instead.
+func (o *OllamaLLM) ParseStreamChunk(data []byte) (string, error) {
+	if errMsg := gjson.GetBytes(data, "error"); errMsg.Exists() {
+		return "", fmt.Errorf("stream error: %s", errMsg.String())
+	}
+	content := gjson.GetBytes(data, o.Config.StreamAnswerPath).String()
+	if content == "" {
+		content = gjson.GetBytes(data, "thinking").String()
+	}
+	return content, nil
+}
+
+// IsStreamDone reports whether the object is marked with "done": true
+func (o *OllamaLLM) IsStreamDone(data []byte) bool {
+	return gjson.GetBytes(data, "done").Bool()
+}
+
 // BuildHeaders builds request headers
 func (o *OllamaLLM) BuildHeaders() map[string]string {
 	headers := map[string]string{
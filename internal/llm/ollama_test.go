@@ -121,7 +121,7 @@ func TestOllamaLLM_FormatMessages(t *testing.T) {
 	})
 
 	message := "test message"
-	got, err := llm.FormatMessages(message)
+	got, err := llm.FormatMessages([]types.Message{{Role: types.RoleUser, Content: message}})
 	if err != nil {
 		t.Errorf("FormatMessages() error = %v", err)
 		return
@@ -222,3 +222,75 @@ func TestOllamaLLM_BuildHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestOllamaLLM_ParseStreamChunk(t *testing.T) {
+	llm := NewOllamaLLM(&types.ClientConfig{})
+
+	tests := []struct {
+		name     string
+		data     string
+		want     string
+		wantErr  bool
+		wantDone bool
+	}{
+		{
+			name: "response chunk",
+			data: `{"model":"llama2","response":"Hello","done":false}`,
+			want: "Hello",
+		},
+		{
+			name: "thinking chunk",
+			data: `{"model":"qwen3","response":"","thinking":"Let me see","done":false}`,
+			want: "Let me see",
+		},
+		{
+			name:    "error",
+			data:    `{"error":"model not found"}`,
+			wantErr: true,
+		},
+		{
+			name:     "final chunk",
+			data:     `{"model":"llama2","response":"","done":true}`,
+			want:     "",
+			wantDone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llm.ParseStreamChunk([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStreamChunk() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStreamChunk() = %q, want %q", got, tt.want)
+			}
+			if done := llm.IsStreamDone([]byte(tt.data)); done != tt.wantDone {
+				t.Errorf("IsStreamDone() = %v, want %v", done, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestOllamaLLM_FormatMessagesConversation(t *testing.T) {
+	llm := NewOllamaLLM(&types.ClientConfig{})
+
+	got, err := llm.FormatMessages([]types.Message{
+		{Role: types.RoleSystem, Content: "Be brief"},
+		{Role: types.RoleUser, Content: "first"},
+		{Role: types.RoleAssistant, Content: "answer"},
+		{Role: types.RoleUser, Content: "second"},
+	})
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+
+	payload := got.(map[string]interface{})
+	if payload["system"] != "Be brief" {
+		t.Errorf("system = %v, want %q", payload["system"], "Be brief")
+	}
+	wantPrompt := "User: first\n\nAssistant: answer\n\nUser: second"
+	if payload["prompt"] != wantPrompt {
+		t.Errorf("prompt = %q, want %q", payload["prompt"], wantPrompt)
+	}
+}
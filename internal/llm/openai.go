@@ -57,13 +57,7 @@ func (o *OpenAILLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // FormatMessages formats messages for OpenAI API
-func (o *OpenAILLM) FormatMessages(message string) (interface{}, error) {
-	messages := []types.Message{}
-	messages = append(messages, types.Message{
-		Role:    "user",
-		Content: message,
-	})
-
+func (o *OpenAILLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	payload := map[string]interface{}{
 		"model":    o.Config.Model,
 		"messages": messages,
@@ -106,6 +100,16 @@ func (o *OpenAILLM) ParseResponse(response []byte) (string, error) {
 }
 
 // MakeRequest makes a request to the API
-func (o *OpenAILLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return o.BaseLLM.MakeRequest(ctx, client, o, message, stream)
+func (o *OpenAILLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return o.BaseLLM.MakeRequest(ctx, client, o, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (o *OpenAILLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return o.BaseLLM.MakeStreamRequest(ctx, client, o, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (o *OpenAILLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return o.BaseLLM.ListModels(ctx, client, o)
 }
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/gptcomet/pkg/types"
@@ -194,7 +195,7 @@ func TestOpenAILLM_GetUsage(t *testing.T) {
 	tests := []struct {
 		name    string
 		data    []byte
-		want    string
+		want    *types.Usage
 		wantErr bool
 	}{
 		{
@@ -206,13 +207,13 @@ func TestOpenAILLM_GetUsage(t *testing.T) {
                     "total_tokens": 30
                 }
             }`),
-			want:    "Token usage> prompt: 10, completion: 20, total: 30",
+			want:    &types.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
 			wantErr: false,
 		},
 		{
 			name:    "no usage info",
 			data:    []byte(`{}`),
-			want:    "",
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -224,8 +225,8 @@ func TestOpenAILLM_GetUsage(t *testing.T) {
 				t.Errorf("GetUsage() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("GetUsage() = %s, want %s", got, tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
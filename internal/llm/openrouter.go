@@ -64,6 +64,16 @@ func (o *OpenRouterLLM) BuildHeaders() map[string]string {
 }
 
 // MakeRequest makes a request to the OpenRouter API
-func (o *OpenRouterLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return o.BaseLLM.MakeRequest(ctx, client, o, message, stream)
+func (o *OpenRouterLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return o.BaseLLM.MakeRequest(ctx, client, o, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (o *OpenRouterLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return o.BaseLLM.MakeStreamRequest(ctx, client, o, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (o *OpenRouterLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return o.BaseLLM.ListModels(ctx, client, o)
 }
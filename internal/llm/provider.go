@@ -1,6 +1,8 @@
 package llm
 
 import (
+	"fmt"
+
 	gptErrors "github.com/belingud/gptcomet/internal/errors"
 	"github.com/belingud/gptcomet/pkg/types"
 )
@@ -16,6 +18,8 @@ import (
 //   - LLM: An interface representing the created LLM provider instance.
 //   - error: An error if the provider creation fails, or nil if successful.
 //
+// If the config carries a provider definition, a DeclarativeLLM is created from it.
+// If the config sets a provider type, like exec, the provider of that type is created.
 // If the specified provider is not registered, it returns a DefaultLLM instance.
 // If the config parameter is nil, it returns an error.
 func NewProvider(providerName string, config *types.ClientConfig) (LLM, error) {
@@ -27,6 +31,12 @@ func NewProvider(providerName string, config *types.ClientConfig) (LLM, error) {
 			[]string{"Ensure valid configuration is provided"},
 		)
 	}
+	if config.Definition != nil {
+		return newDeclarativeProvider(config)
+	}
+	if config.Type != "" {
+		providerName = config.Type
+	}
 
 	constructor, ok := GetProviderConstructor(providerName)
 	if !ok {
@@ -36,7 +46,9 @@ func NewProvider(providerName string, config *types.ClientConfig) (LLM, error) {
 	return constructor(config), nil
 }
 
-// CreateProvider creates a new provider instance with given config
+// CreateProvider creates a new provider instance with given config.
+// Providers declared in the config file are built from their definition, and
+// providers with a type, like exec, as the built-in provider of that type.
 func CreateProvider(config *types.ClientConfig) (LLM, error) {
 	if config == nil {
 		return nil, gptErrors.NewValidationError(
@@ -46,8 +58,14 @@ func CreateProvider(config *types.ClientConfig) (LLM, error) {
 			[]string{"Ensure valid configuration is provided"},
 		)
 	}
+	if config.Definition != nil {
+		return newDeclarativeProvider(config)
+	}
 
 	providerName := config.Provider
+	if config.Type != "" {
+		providerName = config.Type
+	}
 	if providerName == "" {
 		providerName = "openai"
 	}
@@ -59,6 +77,21 @@ func CreateProvider(config *types.ClientConfig) (LLM, error) {
 	return constructor(config), nil
 }
 
+// newDeclarativeProvider creates a DeclarativeLLM from the definition of config.
+// Declared providers can't replace built-in providers.
+func newDeclarativeProvider(config *types.ClientConfig) (LLM, error) {
+	name := config.Definition.Name
+	if HasProvider(name) {
+		return nil, gptErrors.NewValidationError(
+			"Invalid Configuration",
+			fmt.Sprintf("Custom provider %s has the name of a built-in provider", name),
+			nil,
+			[]string{fmt.Sprintf("Rename custom_providers.%s, or remove it to use the built-in provider", name)},
+		)
+	}
+	return NewDeclarativeLLM(*config.Definition, config), nil
+}
+
 // init initializes the LLM providers by registering them with their respective constructors.
 // This function is automatically called when the package is imported.
 //
@@ -69,6 +102,7 @@ func CreateProvider(config *types.ClientConfig) (LLM, error) {
 // - Claude
 // - Cohere
 // - Deepseek
+// - Exec (an external executable)
 // - Gemini
 // - Kimi
 // - Mistral
@@ -116,6 +150,11 @@ func init() {
 		return NewDeepSeekLLM(config)
 	})
 
+	// Exec
+	RegisterProvider("exec", func(config *types.ClientConfig) LLM {
+		return NewExecLLM(config)
+	})
+
 	// Gemini
 	RegisterProvider("gemini", func(config *types.ClientConfig) LLM {
 		return NewGeminiLLM(config)
@@ -31,7 +31,7 @@ func (p *MockProvider) BuildURL() string {
 	return ""
 }
 
-func (p *MockProvider) FormatMessages(message string) (interface{}, error) {
+func (p *MockProvider) FormatMessages(messages []types.Message) (interface{}, error) {
 	return nil, nil
 }
 
@@ -39,14 +39,18 @@ func (p *MockProvider) ParseResponse(response []byte) (string, error) {
 	return "", nil
 }
 
-func (p *MockProvider) GetUsage(data []byte) (string, error) {
-	return "", nil
+func (p *MockProvider) GetUsage(data []byte) (*types.Usage, error) {
+	return nil, nil
 }
 
-func (p *MockProvider) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+func (p *MockProvider) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 	return "mock response", nil
 }
 
+func (p *MockProvider) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return nil
+}
+
 func (p *MockProvider) Chat(messages []types.Message) (string, error) {
 	return "mock response", nil
 }
@@ -60,7 +64,7 @@ type mockLLM struct {
 	name                  string
 	generateCommitMessage func(diff string, prompt string) (string, error)
 	translateMessage      func(prompt string, message string, lang string) (string, error)
-	makeRequest           func(ctx context.Context, client *http.Client, message string, stream bool) (string, error)
+	makeRequest           func(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error)
 }
 
 func (m *mockLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
@@ -75,7 +79,7 @@ func (m *mockLLM) BuildURL() string {
 	return ""
 }
 
-func (m *mockLLM) FormatMessages(message string) (interface{}, error) {
+func (m *mockLLM) FormatMessages(messages []types.Message) (interface{}, error) {
 	return nil, nil
 }
 
@@ -83,17 +87,21 @@ func (m *mockLLM) ParseResponse(response []byte) (string, error) {
 	return "", nil
 }
 
-func (m *mockLLM) GetUsage(data []byte) (string, error) {
-	return "", nil
+func (m *mockLLM) GetUsage(data []byte) (*types.Usage, error) {
+	return nil, nil
 }
 
-func (m *mockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
+func (m *mockLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
 	if m.makeRequest != nil {
-		return m.makeRequest(ctx, client, message, false)
+		return m.makeRequest(ctx, client, messages, false)
 	}
 	return "", nil
 }
 
+func (m *mockLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return nil
+}
+
 func (m *mockLLM) GenerateCommitMessage(diff string, prompt string) (string, error) {
 	if m.generateCommitMessage != nil {
 		return m.generateCommitMessage(diff, prompt)
@@ -283,12 +291,37 @@ func TestCreateProvider(t *testing.T) {
 			},
 			wantErr: false, // Unknown providers return the default (openai)
 		},
+		{
+			name: "Provider with type",
+			config: &types.ClientConfig{
+				Provider: "gateway",
+				Type:     "mock",
+			},
+			wantErr: false,
+		},
 		{
 			name:        "Nil config",
 			config:      nil,
 			wantErr:     true,
 			errContains: "Invalid Configuration",
 		},
+		{
+			name: "Declared provider",
+			config: &types.ClientConfig{
+				Provider:   "myvendor",
+				Definition: &types.ProviderDefinition{Name: "myvendor", APIBase: "https://api.myvendor.com/v1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Declared provider with built-in name",
+			config: &types.ClientConfig{
+				Provider:   "mock",
+				Definition: &types.ProviderDefinition{Name: "mock", APIBase: "https://api.mock.com/v1"},
+			},
+			wantErr:     true,
+			errContains: "built-in provider",
+		},
 	}
 
 	for _, tt := range tests {
@@ -305,6 +338,8 @@ func TestCreateProvider(t *testing.T) {
 			// For unknown provider, it returns the default "openai"
 			if tt.config.Provider == "unknown" {
 				assert.Equal(t, "openai", provider.Name())
+			} else if tt.config.Type != "" {
+				assert.Equal(t, tt.config.Type, provider.Name())
 			} else {
 				assert.Equal(t, tt.config.Provider, provider.Name())
 			}
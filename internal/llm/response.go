@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Response is the raw response to a request, as received from the provider
+type Response struct {
+	// StatusCode is the HTTP status code, or 0 for providers that don't use HTTP
+	StatusCode int
+	Header     http.Header
+	// Body is the response body, or all lines of a streamed response
+	Body   []byte
+	Stream bool
+}
+
+// ResponseRecorder receives the raw response of every request made with a
+// context returned by WithResponseRecorder.
+type ResponseRecorder func(resp Response)
+
+type responseRecorderKey struct{}
+
+// WithResponseRecorder returns a copy of ctx that passes the raw response of
+// every request to recorder, to diagnose the answer and usage paths.
+func WithResponseRecorder(ctx context.Context, recorder ResponseRecorder) context.Context {
+	return context.WithValue(ctx, responseRecorderKey{}, recorder)
+}
+
+// recordResponse passes resp to the recorder of ctx, if there is one
+func recordResponse(ctx context.Context, resp Response) {
+	if recorder, ok := ctx.Value(responseRecorderKey{}).(ResponseRecorder); ok {
+		recorder(resp)
+	}
+}
+
+// teeStream returns body and a function that records everything read from it
+// as resp, when ctx has a response recorder. Without one, body is returned as is.
+func teeStream(ctx context.Context, resp Response, body io.Reader) (io.Reader, func()) {
+	if _, ok := ctx.Value(responseRecorderKey{}).(ResponseRecorder); !ok {
+		return body, func() {}
+	}
+	var raw bytes.Buffer
+	return io.TeeReader(body, &raw), func() {
+		resp.Body = raw.Bytes()
+		resp.Stream = true
+		recordResponse(ctx, resp)
+	}
+}
+
+// ResolvesPath reports whether path finds a value in the response body. For
+// streams, it is enough that one chunk has a value at path.
+func ResolvesPath(resp Response, path string) bool {
+	for _, chunk := range responseChunks(resp) {
+		if gjson.GetBytes(chunk, path).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// answerKeys are the keys providers commonly put the generated text under
+var answerKeys = map[string]bool{
+	"content":        true,
+	"text":           true,
+	"response":       true,
+	"output":         true,
+	"answer":         true,
+	"message":        true,
+	"generated_text": true,
+	"result":         true,
+}
+
+// metadataKeys hold strings that are not the generated text
+var metadataKeys = map[string]bool{
+	"id":                 true,
+	"object":             true,
+	"model":              true,
+	"role":               true,
+	"type":               true,
+	"finish_reason":      true,
+	"stop_reason":        true,
+	"system_fingerprint": true,
+	"created_at":         true,
+	"done_reason":        true,
+}
+
+// SuggestAnswerPath returns the gjson path of the string in the response that
+// most likely holds the answer, or "" if the response has no strings. Strings
+// under keys such as "content" or "text" are preferred, then the longest ones.
+// For streams, the lengths of the strings at the same path of all chunks are
+// added up.
+func SuggestAnswerPath(resp Response) string {
+	candidates := make(map[string]*pathCandidate)
+	for _, chunk := range responseChunks(resp) {
+		collectStrings(gjson.ParseBytes(chunk), "", "", candidates)
+	}
+
+	best := ""
+	for path, candidate := range candidates {
+		if best == "" || candidate.score() > candidates[best].score() ||
+			(candidate.score() == candidates[best].score() && path < best) {
+			best = path
+		}
+	}
+	return best
+}
+
+// pathCandidate is a path with strings in a response
+type pathCandidate struct {
+	// key is the object key of the strings
+	key    string
+	length int
+}
+
+// score ranks the candidates, strings under answer keys first
+func (c *pathCandidate) score() int {
+	if answerKeys[c.key] {
+		return c.length + 1<<30
+	}
+	return c.length
+}
+
+// collectStrings adds the length of every non-empty string in value to
+// candidates, under its gjson path. key is the object key of value.
+func collectStrings(value gjson.Result, path, key string, candidates map[string]*pathCandidate) {
+	switch {
+	case value.IsObject():
+		value.ForEach(func(k, v gjson.Result) bool {
+			collectStrings(v, joinPath(path, escapePathKey(k.String())), k.String(), candidates)
+			return true
+		})
+	case value.IsArray():
+		for i, v := range value.Array() {
+			collectStrings(v, joinPath(path, strconv.Itoa(i)), key, candidates)
+		}
+	case value.Type == gjson.String && value.String() != "" && !metadataKeys[key]:
+		if candidates[path] == nil {
+			candidates[path] = &pathCandidate{key: key}
+		}
+		candidates[path].length += len(value.String())
+	}
+}
+
+// responseChunks returns the JSON payloads of resp, which is the body itself
+// unless it is a stream
+func responseChunks(resp Response) [][]byte {
+	if !resp.Stream {
+		return [][]byte{resp.Body}
+	}
+	var chunks [][]byte
+	for _, line := range strings.Split(string(resp.Body), "\n") {
+		if data, ok := parseStreamLine(line); ok && gjson.Valid(data) {
+			chunks = append(chunks, []byte(data))
+		}
+	}
+	return chunks
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// escapePathKey escapes the characters gjson treats specially in a key
+func escapePathKey(key string) string {
+	var sb strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`.*?|#@\`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
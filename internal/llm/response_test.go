@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"O\"}}]}\n\ndata: {\"choices\": [{\"delta\": {\"content\": \"K\"}}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"content": "OK"}}]}`))
+	}))
+	defer server.Close()
+
+	var recorded []Response
+	ctx := WithResponseRecorder(context.Background(), func(resp Response) {
+		recorded = append(recorded, resp)
+	})
+	messages := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	provider := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL})
+	if _, err := provider.MakeRequest(ctx, server.Client(), messages, false); err != nil {
+		t.Fatalf("MakeRequest() unexpected error: %v", err)
+	}
+
+	provider = NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, ExtraHeaders: map[string]string{"Accept": "text/event-stream"}})
+	err := provider.MakeStreamRequest(ctx, server.Client(), messages, func(*types.CompletionResponse) error { return nil })
+	if err != nil {
+		t.Fatalf("MakeStreamRequest() unexpected error: %v", err)
+	}
+
+	if len(recorded) != 2 {
+		t.Fatalf("recorded %d responses, want 2", len(recorded))
+	}
+	if recorded[0].StatusCode != http.StatusOK || recorded[0].Stream || string(recorded[0].Body) != `{"choices": [{"message": {"content": "OK"}}]}` {
+		t.Errorf("recorded response = %+v", recorded[0])
+	}
+	if !recorded[1].Stream || !ResolvesPath(recorded[1], "choices.0.delta.content") {
+		t.Errorf("recorded stream = %+v, want the stream chunks", recorded[1])
+	}
+}
+
+func TestResolvesPath(t *testing.T) {
+	resp := Response{Body: []byte(`{"output": {"text": "feat: add login"}}`)}
+	if !ResolvesPath(resp, "output.text") {
+		t.Error("ResolvesPath(output.text) = false, want true")
+	}
+	if ResolvesPath(resp, "choices.0.message.content") {
+		t.Error("ResolvesPath(choices.0.message.content) = true, want false")
+	}
+
+	stream := Response{Stream: true, Body: []byte("data: {\"id\": \"1\"}\n\ndata: {\"delta\": \"OK\"}\n\ndata: [DONE]\n")}
+	if !ResolvesPath(stream, "delta") {
+		t.Error("ResolvesPath(delta) = false for a stream, want true")
+	}
+}
+
+func TestSuggestAnswerPath(t *testing.T) {
+	tests := []struct {
+		name string
+		resp Response
+		want string
+	}{
+		{
+			name: "openai",
+			resp: Response{Body: []byte(`{"id": "chatcmpl-123456789", "model": "gpt-4o", "choices": [{"message": {"role": "assistant", "content": "OK"}, "finish_reason": "stop"}]}`)},
+			want: "choices.0.message.content",
+		},
+		{
+			name: "answer key preferred over longer strings",
+			resp: Response{Body: []byte(`{"result": {"output": {"text": "OK"}}, "request_trace": "a very long trace identifier string"}`)},
+			want: "result.output.text",
+		},
+		{
+			name: "longest string without answer keys",
+			resp: Response{Body: []byte(`{"data": {"reply": "feat: add login form", "status": "done"}}`)},
+			want: "data.reply",
+		},
+		{
+			name: "special characters are escaped",
+			resp: Response{Body: []byte(`{"v1.0": {"content": "OK"}}`)},
+			want: `v1\.0.content`,
+		},
+		{
+			name: "stream",
+			resp: Response{Stream: true, Body: []byte("data: {\"id\": \"abc\", \"choices\": [{\"delta\": {\"text\": \"fe\"}}]}\n\ndata: {\"id\": \"abc\", \"choices\": [{\"delta\": {\"text\": \"at: x\"}}]}\n\ndata: [DONE]\n")},
+			want: "choices.0.delta.text",
+		},
+		{
+			name: "no strings",
+			resp: Response{Body: []byte(`{"count": 1}`)},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SuggestAnswerPath(tt.resp)
+			if got != tt.want {
+				t.Errorf("SuggestAnswerPath() = %q, want %q", got, tt.want)
+			}
+			if got != "" && !ResolvesPath(tt.resp, got) {
+				t.Errorf("suggested path %q doesn't resolve", got)
+			}
+		})
+	}
+}
@@ -54,6 +54,16 @@ func (s *SambanovaLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // MakeRequest makes a request to the SambaNova API
-func (s *SambanovaLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return s.BaseLLM.MakeRequest(ctx, client, s, message, stream)
+func (s *SambanovaLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return s.BaseLLM.MakeRequest(ctx, client, s, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (s *SambanovaLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return s.BaseLLM.MakeStreamRequest(ctx, client, s, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (s *SambanovaLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return s.BaseLLM.ListModels(ctx, client, s)
 }
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/gptcomet/pkg/types"
@@ -193,7 +194,7 @@ func TestSiliconLLM_GetUsage(t *testing.T) {
 	tests := []struct {
 		name    string
 		data    []byte
-		want    string
+		want    *types.Usage
 		wantErr bool
 	}{
 		{
@@ -205,13 +206,13 @@ func TestSiliconLLM_GetUsage(t *testing.T) {
                     "total_tokens": 30
                 }
             }`),
-			want:    "Token usage> prompt: 10, completion: 20, total: 30",
+			want:    &types.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
 			wantErr: false,
 		},
 		{
 			name:    "no usage info",
 			data:    []byte(`{}`),
-			want:    "",
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -223,8 +224,8 @@ func TestSiliconLLM_GetUsage(t *testing.T) {
 				t.Errorf("GetUsage() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("GetUsage() = %s, want %s", got, tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
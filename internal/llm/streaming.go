@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// StreamingProvider is an optional interface for providers whose streaming
+// protocol differs from the OpenAI-compatible default, which posts to
+// BuildURL with "stream": true and reads the configured stream answer path
+// from every SSE chunk.
+type StreamingProvider interface {
+	// BuildStreamURL builds the API URL used for streaming requests
+	BuildStreamURL() string
+
+	// PrepareStreamPayload applies the payload changes required for streaming
+	PrepareStreamPayload(payload map[string]interface{})
+
+	// ParseStreamChunk extracts the content of a single stream chunk.
+	// An empty string means the chunk carries no content.
+	ParseStreamChunk(data []byte) (string, error)
+
+	// IsStreamDone reports whether the chunk marks the end of the stream
+	IsStreamDone(data []byte) bool
+}
+
+// defaultStreaming implements the OpenAI-compatible streaming protocol for
+// providers that don't implement StreamingProvider themselves.
+type defaultStreaming struct {
+	provider     LLM
+	answerPath   string
+	includeUsage bool
+}
+
+// BuildStreamURL returns the provider's regular API URL
+func (d *defaultStreaming) BuildStreamURL() string {
+	return d.provider.BuildURL()
+}
+
+// PrepareStreamPayload sets the "stream" flag, and asks for the usage in the
+// last chunk unless the provider's stream_usage is off
+func (d *defaultStreaming) PrepareStreamPayload(payload map[string]interface{}) {
+	payload["stream"] = true
+	if d.includeUsage {
+		payload["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+}
+
+// ParseStreamChunk extracts the content at the stream answer path
+func (d *defaultStreaming) ParseStreamChunk(data []byte) (string, error) {
+	return gjson.GetBytes(data, d.answerPath).String(), nil
+}
+
+// IsStreamDone always returns false, the stream ends with "[DONE]" or EOF
+func (d *defaultStreaming) IsStreamDone(data []byte) bool {
+	return false
+}
+
+// streamingFor returns the streaming protocol of provider, falling back to
+// the OpenAI-compatible default.
+func (b *BaseLLM) streamingFor(provider LLM) StreamingProvider {
+	if sp, ok := provider.(StreamingProvider); ok {
+		return sp
+	}
+	includeUsage := b.Config.StreamUsage == nil || *b.Config.StreamUsage
+	return &defaultStreaming{provider: provider, answerPath: b.Config.StreamAnswerPath, includeUsage: includeUsage}
+}
+
+// parseGoogleStreamChunk extracts the text of a Gemini or Vertex AI stream chunk,
+// surfacing errors reported inside the stream.
+func parseGoogleStreamChunk(data []byte, answerPath string) (string, error) {
+	if errMsg := gjson.GetBytes(data, "error.message"); errMsg.Exists() {
+		return "", fmt.Errorf("stream error: %s", errMsg.String())
+	}
+	return gjson.GetBytes(data, answerPath).String(), nil
+}
+
+// isGoogleStreamDone reports whether a Gemini or Vertex AI chunk is the last one.
+// The last chunk may still carry text, which is parsed before this check.
+func isGoogleStreamDone(data []byte) bool {
+	return gjson.GetBytes(data, "candidates.0.finishReason").String() != ""
+}
+
+// idleWatchdog aborts a streaming request when the provider sends no data
+// within the request timeout, or then sends no data for longer than the idle
+// timeout, so a stalled stream doesn't hang, while a long stream that keeps
+// sending data isn't cut off.
+type idleWatchdog struct {
+	timeout     time.Duration
+	idleTimeout time.Duration
+	timer       *time.Timer
+	cancel      context.CancelFunc
+	received    bool
+	timedOut    atomic.Bool
+	idled       atomic.Bool
+}
+
+// newIdleWatchdog returns a copy of ctx that is cancelled when no data is read
+// through the watchdog for timeout, counting from now, or for idleTimeout after
+// the first data. A timeout of zero or less disables that limit.
+func newIdleWatchdog(ctx context.Context, timeout, idleTimeout time.Duration) (context.Context, *idleWatchdog) {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &idleWatchdog{timeout: timeout, idleTimeout: idleTimeout, cancel: cancel}
+	w.start(timeout, &w.timedOut)
+	return ctx, w
+}
+
+// start replaces the timer of the watchdog with one that sets fired and
+// cancels the context after d, if d is positive
+func (w *idleWatchdog) start(d time.Duration, fired *atomic.Bool) {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if d > 0 {
+		w.timer = time.AfterFunc(d, func() {
+			fired.Store(true)
+			w.cancel()
+		})
+	}
+}
+
+// reader returns a reader that restarts the idle period whenever data is read from r
+func (w *idleWatchdog) reader(r io.Reader) io.Reader {
+	return &idleReader{r: r, watchdog: w}
+}
+
+// expired reports whether the request was aborted because it was idle for too
+// long after its first data
+func (w *idleWatchdog) expired() bool {
+	return w.idled.Load()
+}
+
+// expiredBeforeData reports whether the request was aborted because no data
+// came within the timeout
+func (w *idleWatchdog) expiredBeforeData() bool {
+	return w.timedOut.Load()
+}
+
+// stop stops the watchdog and releases its context
+func (w *idleWatchdog) stop() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.cancel()
+}
+
+// idleReader restarts the idle period of its watchdog on every read that returns data
+type idleReader struct {
+	r        io.Reader
+	watchdog *idleWatchdog
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		w := r.watchdog
+		if !w.received {
+			// The timeout covers the wait for the first data, the idle timeout the rest
+			w.received = true
+			w.start(w.idleTimeout, &w.idled)
+		} else if w.timer != nil {
+			w.timer.Reset(w.idleTimeout)
+		}
+	}
+	return n, err
+}
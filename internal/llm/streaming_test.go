@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gptErrors "github.com/belingud/gptcomet/internal/errors"
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+func TestStreamingProviderImplementations(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider LLM
+		want     bool
+	}{
+		{name: "gemini", provider: NewGeminiLLM(&types.ClientConfig{}), want: true},
+		{name: "vertex", provider: NewVertexLLM(&types.ClientConfig{}), want: true},
+		{name: "claude", provider: NewClaudeLLM(&types.ClientConfig{}), want: true},
+		{name: "ollama", provider: NewOllamaLLM(&types.ClientConfig{}), want: true},
+		{name: "openai", provider: NewOpenAILLM(&types.ClientConfig{}), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.provider.(StreamingProvider); ok != tt.want {
+				t.Errorf("%s implements StreamingProvider = %v, want %v", tt.name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultStreaming(t *testing.T) {
+	provider := NewOpenAILLM(&types.ClientConfig{APIBase: "https://api.openai.com/v1"})
+	streaming := provider.streamingFor(provider)
+
+	if got, want := streaming.BuildStreamURL(), "https://api.openai.com/v1/chat/completions"; got != want {
+		t.Errorf("BuildStreamURL() = %s, want %s", got, want)
+	}
+
+	payload := map[string]interface{}{}
+	streaming.PrepareStreamPayload(payload)
+	if payload["stream"] != true {
+		t.Errorf("PrepareStreamPayload() stream = %v, want true", payload["stream"])
+	}
+	wantOptions := map[string]interface{}{"include_usage": true}
+	if !reflect.DeepEqual(payload["stream_options"], wantOptions) {
+		t.Errorf("PrepareStreamPayload() stream_options = %v, want %v", payload["stream_options"], wantOptions)
+	}
+
+	streamUsage := false
+	provider = NewOpenAILLM(&types.ClientConfig{APIBase: "https://api.openai.com/v1", StreamUsage: &streamUsage})
+	payload = map[string]interface{}{}
+	provider.streamingFor(provider).PrepareStreamPayload(payload)
+	if _, ok := payload["stream_options"]; ok {
+		t.Errorf("PrepareStreamPayload() with stream_usage off sets stream_options = %v", payload["stream_options"])
+	}
+
+	data := []byte(`{"choices":[{"delta":{"content":"Hi"}}]}`)
+	got, err := streaming.ParseStreamChunk(data)
+	if err != nil {
+		t.Fatalf("ParseStreamChunk() error = %v", err)
+	}
+	if got != "Hi" {
+		t.Errorf("ParseStreamChunk() = %q, want %q", got, "Hi")
+	}
+	if streaming.IsStreamDone(data) {
+		t.Errorf("IsStreamDone() = true, want false")
+	}
+}
+
+func TestMakeStreamRequestIdleTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"feat\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		// Stall until the client gives up
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	provider := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, StreamIdleTimeout: 1})
+	var output strings.Builder
+	start := time.Now()
+	err := provider.MakeStreamRequest(context.Background(), server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, func(chunk *types.CompletionResponse) error {
+		output.WriteString(chunk.Content)
+		return nil
+	})
+
+	if !gptErrors.HasTitle(err, gptErrors.ErrTitleStreamIdle) {
+		t.Fatalf("MakeStreamRequest() error = %v, want a stream idle error", err)
+	}
+	if output.String() != "feat" {
+		t.Errorf("output = %q, want the chunk sent before the stall", output.String())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("MakeStreamRequest() took %v, want it to stop after the idle timeout", elapsed)
+	}
+}
+
+func TestMakeStreamRequestTimeouts(t *testing.T) {
+	// Thinks for 1.5s before the first chunk, then streams for 1.5s more
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		time.Sleep(1500 * time.Millisecond)
+		for _, word := range []string{"feat", ":", " slow"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+			w.(http.Flusher).Flush()
+			time.Sleep(500 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	stream := func(timeout time.Duration, idleTimeout int64) (string, error) {
+		provider := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, StreamIdleTimeout: idleTimeout})
+		client := server.Client()
+		client.Timeout = timeout
+		var output strings.Builder
+		err := provider.MakeStreamRequest(context.Background(), client, []types.Message{{Role: types.RoleUser, Content: "hi"}}, func(chunk *types.CompletionResponse) error {
+			output.WriteString(chunk.Content)
+			return nil
+		})
+		return output.String(), err
+	}
+
+	t.Run("wait for the first chunk isn't idle", func(t *testing.T) {
+		// The first chunk comes after the idle timeout, and the stream ends after the timeout
+		output, err := stream(2*time.Second, 1)
+		if err != nil {
+			t.Fatalf("MakeStreamRequest() unexpected error: %v", err)
+		}
+		if output != "feat: slow" {
+			t.Errorf("output = %q, want %q", output, "feat: slow")
+		}
+	})
+
+	t.Run("no chunk within the timeout", func(t *testing.T) {
+		_, err := stream(500*time.Millisecond, 30)
+		if !gptErrors.HasTitle(err, gptErrors.ErrTitleStreamTimeout) {
+			t.Fatalf("MakeStreamRequest() error = %v, want a stream timeout error", err)
+		}
+	})
+}
+
+func TestIdleReaderRestartsIdlePeriod(t *testing.T) {
+	ctx, watchdog := newIdleWatchdog(context.Background(), 100*time.Millisecond, 100*time.Millisecond)
+	defer watchdog.stop()
+
+	pr, pw := io.Pipe()
+	reader := watchdog.reader(pr)
+	go func() {
+		// Keep sending data for longer than the idle timeout
+		for i := 0; i < 5; i++ {
+			time.Sleep(50 * time.Millisecond)
+			pw.Write([]byte("x"))
+		}
+		pw.Close()
+	}()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(data) != "xxxxx" {
+		t.Errorf("ReadAll() = %q, want %q", data, "xxxxx")
+	}
+	if watchdog.expired() || ctx.Err() != nil {
+		t.Error("watchdog expired while data was arriving")
+	}
+}
@@ -1,7 +1,9 @@
 package llm
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
@@ -66,16 +68,16 @@ func (t *TongyiLLM) BuildHeaders() map[string]string {
 }
 
 // GetUsage returns usage information for the provider
-func (t *TongyiLLM) GetUsage(data []byte) (string, error) {
-	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", nil
-	}
+func (t *TongyiLLM) GetUsage(data []byte) (*types.Usage, error) {
+	return parseOpenAIUsage(gjson.GetBytes(data, "usage")), nil
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (t *TongyiLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return t.BaseLLM.MakeStreamRequest(ctx, client, t, messages, callback)
+}
 
-	return fmt.Sprintf(
-		"Token usage> input: %d, output: %d, total: %d",
-		usage.Get("prompt_tokens").Int(),
-		usage.Get("completion_tokens").Int(),
-		usage.Get("total_tokens").Int(),
-	), nil
+// ListModels lists the models available from the API
+func (t *TongyiLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return t.BaseLLM.ListModels(ctx, client, t)
 }
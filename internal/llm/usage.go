@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/belingud/gptcomet/internal/debug"
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// UsageRecorder receives the token usage of every response to a request
+// made with a context returned by WithUsageRecorder.
+type UsageRecorder func(usage types.Usage)
+
+type usageRecorderKey struct{}
+
+// WithUsageRecorder returns a copy of ctx that passes the token usage of
+// every response to recorder. The recorder may be called concurrently when
+// ctx is shared between requests.
+func WithUsageRecorder(ctx context.Context, recorder UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageRecorderKey{}, recorder)
+}
+
+// recordUsage passes usage to the recorder of ctx, if there is one
+func recordUsage(ctx context.Context, usage *types.Usage) {
+	if usage == nil {
+		return
+	}
+	debug.Printf("Token usage> %s", usage)
+	if recorder, ok := ctx.Value(usageRecorderKey{}).(UsageRecorder); ok {
+		recorder(*usage)
+	}
+}
+
+// parseOpenAIUsage converts an OpenAI-compatible usage object. Cached prompt
+// tokens are read from prompt_tokens_details, or from the DeepSeek style
+// prompt_cache_hit_tokens field.
+func parseOpenAIUsage(usage gjson.Result) *types.Usage {
+	if !usage.IsObject() {
+		return nil
+	}
+
+	result := &types.Usage{
+		PromptTokens:     int(usage.Get("prompt_tokens").Int()),
+		CompletionTokens: int(usage.Get("completion_tokens").Int()),
+		TotalTokens:      int(usage.Get("total_tokens").Int()),
+		CachedTokens:     int(usage.Get("prompt_tokens_details.cached_tokens").Int()),
+		ReasoningTokens:  int(usage.Get("completion_tokens_details.reasoning_tokens").Int()),
+	}
+	if result.CachedTokens == 0 {
+		result.CachedTokens = int(usage.Get("prompt_cache_hit_tokens").Int())
+	}
+	if result.TotalTokens == 0 {
+		result.TotalTokens = result.PromptTokens + result.CompletionTokens
+	}
+	return result
+}
@@ -0,0 +1,203 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+func TestParseOpenAIUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *types.Usage
+	}{
+		{
+			name: "cached and reasoning details",
+			data: `{"usage": {"prompt_tokens": 100, "completion_tokens": 50, "total_tokens": 150,
+				"prompt_tokens_details": {"cached_tokens": 80},
+				"completion_tokens_details": {"reasoning_tokens": 30}}}`,
+			want: &types.Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CachedTokens: 80, ReasoningTokens: 30},
+		},
+		{
+			name: "deepseek cache hit",
+			data: `{"usage": {"prompt_tokens": 100, "completion_tokens": 50, "prompt_cache_hit_tokens": 60}}`,
+			want: &types.Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CachedTokens: 60},
+		},
+		{
+			name: "null usage",
+			data: `{"usage": null}`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewOpenAILLM(&types.ClientConfig{}).GetUsage([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("GetUsage() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderGetUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider LLM
+		data     string
+		want     *types.Usage
+	}{
+		{
+			name:     "claude with cache",
+			provider: NewClaudeLLM(&types.ClientConfig{}),
+			data:     `{"usage": {"input_tokens": 10, "cache_read_input_tokens": 80, "cache_creation_input_tokens": 5, "output_tokens": 20}}`,
+			want:     &types.Usage{PromptTokens: 95, CompletionTokens: 20, TotalTokens: 115, CachedTokens: 80},
+		},
+		{
+			name:     "claude stream message_start",
+			provider: NewClaudeLLM(&types.ClientConfig{}),
+			data:     `{"type": "message_start", "message": {"usage": {"input_tokens": 10, "output_tokens": 1}}}`,
+			want:     &types.Usage{PromptTokens: 10, CompletionTokens: 1, TotalTokens: 11},
+		},
+		{
+			name:     "ollama done",
+			provider: NewOllamaLLM(&types.ClientConfig{}),
+			data:     `{"response": "", "done": true, "prompt_eval_count": 26, "eval_count": 290}`,
+			want:     &types.Usage{PromptTokens: 26, CompletionTokens: 290, TotalTokens: 316},
+		},
+		{
+			name:     "ollama not done",
+			provider: NewOllamaLLM(&types.ClientConfig{}),
+			data:     `{"response": "feat", "done": false}`,
+			want:     nil,
+		},
+		{
+			name:     "cohere v2",
+			provider: NewCohereLLM(&types.ClientConfig{}),
+			data:     `{"usage": {"billed_units": {"input_tokens": 9, "output_tokens": 4}, "tokens": {"input_tokens": 70, "output_tokens": 4}}}`,
+			want:     &types.Usage{PromptTokens: 70, CompletionTokens: 4, TotalTokens: 74},
+		},
+		{
+			name:     "cohere v1",
+			provider: NewCohereLLM(&types.ClientConfig{}),
+			data:     `{"meta": {"billed_units": {"input_tokens": 9, "output_tokens": 4}}}`,
+			want:     &types.Usage{PromptTokens: 9, CompletionTokens: 4, TotalTokens: 13},
+		},
+		{
+			name:     "vertex gemini",
+			provider: NewVertexLLM(&types.ClientConfig{}),
+			data:     `{"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 20, "totalTokenCount": 30}}`,
+			want:     &types.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.GetUsage([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("GetUsage() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeRequestRecordsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices": [{"message": {"content": "feat: usage"}}], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`)
+	}))
+	defer server.Close()
+
+	var recorded []types.Usage
+	ctx := WithUsageRecorder(context.Background(), func(usage types.Usage) {
+		recorded = append(recorded, usage)
+	})
+
+	provider := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, AnswerPath: "choices.0.message.content"})
+	if _, err := provider.MakeRequest(ctx, server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, false); err != nil {
+		t.Fatalf("MakeRequest() unexpected error: %v", err)
+	}
+
+	want := []types.Usage{{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}
+	if !reflect.DeepEqual(recorded, want) {
+		t.Errorf("recorded usage = %+v, want %+v", recorded, want)
+	}
+}
+
+func TestMakeStreamRequestRecordsUsage(t *testing.T) {
+	// Like OpenAI, the server only sends the usage chunk when the request asks for it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			StreamOptions struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"feat\"}}],\"usage\":null}\n\n")
+		if request.StreamOptions.IncludeUsage {
+			fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":2,\"total_tokens\":12}}\n\n")
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	var recorded []types.Usage
+	ctx := WithUsageRecorder(context.Background(), func(usage types.Usage) {
+		recorded = append(recorded, usage)
+	})
+
+	provider := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, StreamAnswerPath: "choices.0.delta.content"})
+	err := provider.MakeStreamRequest(ctx, server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, func(chunk *types.CompletionResponse) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("MakeStreamRequest() unexpected error: %v", err)
+	}
+
+	want := []types.Usage{{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}}
+	if !reflect.DeepEqual(recorded, want) {
+		t.Errorf("recorded usage = %+v, want %+v", recorded, want)
+	}
+}
+
+func TestMakeStreamRequestMergesClaudeUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":1000,\"cache_read_input_tokens\":200,\"output_tokens\":1}}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"feat\"}}\n\n")
+		fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":40}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	var recorded []types.Usage
+	ctx := WithUsageRecorder(context.Background(), func(usage types.Usage) {
+		recorded = append(recorded, usage)
+	})
+
+	provider := NewClaudeLLM(&types.ClientConfig{APIBase: server.URL, StreamAnswerPath: "delta.text"})
+	err := provider.MakeStreamRequest(ctx, server.Client(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, func(chunk *types.CompletionResponse) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("MakeStreamRequest() unexpected error: %v", err)
+	}
+
+	want := []types.Usage{{PromptTokens: 1200, CompletionTokens: 40, TotalTokens: 1240, CachedTokens: 200}}
+	if !reflect.DeepEqual(recorded, want) {
+		t.Errorf("recorded usage = %+v, want %+v", recorded, want)
+	}
+}
@@ -87,34 +87,50 @@ func (v *VertexLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 
 // BuildURL builds the API URL for Vertex AI
 func (v *VertexLLM) BuildURL() string {
-	return fmt.Sprintf("%s/%s", v.Config.APIBase, fmt.Sprintf(*v.Config.CompletionPath, v.Config.Model))
+	return fmt.Sprintf("%s/%s", v.Config.APIBase, v.completionPath())
+}
+
+// completionPath returns the completion path, filling in the model if the
+// path contains a %s placeholder
+func (v *VertexLLM) completionPath() string {
+	if strings.Contains(*v.Config.CompletionPath, "%s") {
+		return fmt.Sprintf(*v.Config.CompletionPath, v.Config.Model)
+	}
+	return *v.Config.CompletionPath
 }
 
 // BuildStreamURL builds the streaming API URL for Vertex AI
 func (v *VertexLLM) BuildStreamURL() string {
 	// Replace :generateContent with :streamGenerateContent for streaming
-	path := fmt.Sprintf(*v.Config.CompletionPath, v.Config.Model)
+	path := v.completionPath()
 	streamPath := strings.ReplaceAll(path, ":generateContent", ":streamGenerateContent")
 	return fmt.Sprintf("%s/%s?alt=sse", v.Config.APIBase, streamPath)
 }
 
+// PrepareStreamPayload leaves the payload unchanged, Vertex AI selects streaming by URL
+func (v *VertexLLM) PrepareStreamPayload(payload map[string]interface{}) {}
+
+// ParseStreamChunk extracts the text of a Vertex AI stream chunk
+func (v *VertexLLM) ParseStreamChunk(data []byte) (string, error) {
+	return parseGoogleStreamChunk(data, v.Config.StreamAnswerPath)
+}
+
+// IsStreamDone reports whether the chunk carries a finish reason
+func (v *VertexLLM) IsStreamDone(data []byte) bool {
+	return isGoogleStreamDone(data)
+}
+
 // FormatMessages formats messages for Vertex AI
-func (v *VertexLLM) FormatMessages(message string) (interface{}, error) {
-	contents := []map[string]interface{}{
-		{
-			"role": "user",
-			"parts": []map[string]string{
-				{
-					"text": message,
-				},
-			},
-		},
-	}
+func (v *VertexLLM) FormatMessages(messages []types.Message) (interface{}, error) {
+	contents, systemInstruction := formatGoogleContents(messages)
 
 	payload := map[string]interface{}{
 		"contents":          contents,
 		"generation_config": map[string]interface{}{},
 	}
+	if systemInstruction != nil {
+		payload["system_instruction"] = systemInstruction
+	}
 
 	if v.Config.MaxTokens != 0 {
 		payload["generation_config"].(map[string]interface{})["max_output_tokens"] = v.Config.MaxTokens
@@ -132,22 +148,31 @@ func (v *VertexLLM) FormatMessages(message string) (interface{}, error) {
 	return payload, nil
 }
 
-// GetUsage returns usage information for the provider
-func (v *VertexLLM) GetUsage(data []byte) (string, error) {
+// GetUsage returns usage information for the provider.
+// Gemini models report usageMetadata, older models report metadata.tokenMetadata.
+func (v *VertexLLM) GetUsage(data []byte) (*types.Usage, error) {
+	if usage := parseGoogleUsage(gjson.GetBytes(data, "usageMetadata")); usage != nil {
+		return usage, nil
+	}
+
 	usage := gjson.GetBytes(data, "metadata.tokenMetadata")
-	if !usage.Exists() {
-		return "", nil
+	if !usage.IsObject() {
+		return nil, nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> input: %d, output: %d, total: %d",
-		usage.Get("inputTokenCount").Int(),
-		usage.Get("outputTokenCount").Int(),
-		usage.Get("totalTokenCount").Int(),
-	), nil
+	return &types.Usage{
+		PromptTokens:     int(usage.Get("inputTokenCount").Int()),
+		CompletionTokens: int(usage.Get("outputTokenCount").Int()),
+		TotalTokens:      int(usage.Get("totalTokenCount").Int()),
+	}, nil
 }
 
 // MakeRequest makes a request to the API
-func (v *VertexLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return v.BaseLLM.MakeRequest(ctx, client, v, message, stream)
+func (v *VertexLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return v.BaseLLM.MakeRequest(ctx, client, v, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (v *VertexLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return v.BaseLLM.MakeStreamRequest(ctx, client, v, messages, callback)
 }
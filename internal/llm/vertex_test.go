@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/gptcomet/pkg/types"
@@ -138,7 +139,7 @@ func TestVertexLLM_FormatMessages(t *testing.T) {
 	})
 
 	message := "test message"
-	got, err := llm.FormatMessages(message)
+	got, err := llm.FormatMessages([]types.Message{{Role: types.RoleUser, Content: message}})
 	if err != nil {
 		t.Errorf("FormatMessages() error = %v", err)
 		return
@@ -181,7 +182,7 @@ func TestVertexLLM_GetUsage(t *testing.T) {
 	tests := []struct {
 		name    string
 		data    []byte
-		want    string
+		want    *types.Usage
 		wantErr bool
 	}{
 		{
@@ -195,13 +196,13 @@ func TestVertexLLM_GetUsage(t *testing.T) {
                     }
                 }
             }`),
-			want:    "Token usage> input: 10, output: 20, total: 30",
+			want:    &types.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
 			wantErr: false,
 		},
 		{
 			name:    "no usage info",
 			data:    []byte(`{}`),
-			want:    "",
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -213,8 +214,65 @@ func TestVertexLLM_GetUsage(t *testing.T) {
 				t.Errorf("GetUsage() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVertexLLM_BuildStreamURL(t *testing.T) {
+	llm := NewVertexLLM(&types.ClientConfig{
+		ProjectID: "my-project",
+		Location:  "us-central1",
+		Model:     "gemini-1.5-pro",
+	})
+
+	want := "https://us-central1-aiplatform.googleapis.com/v1/projects/my-project/locations/us-central1/publishers/google/models/gemini-1.5-pro:streamGenerateContent?alt=sse"
+	if got := llm.BuildStreamURL(); got != want {
+		t.Errorf("BuildStreamURL() = %s, want %s", got, want)
+	}
+}
+
+func TestVertexLLM_ParseStreamChunk(t *testing.T) {
+	llm := NewVertexLLM(&types.ClientConfig{})
+
+	tests := []struct {
+		name     string
+		data     string
+		want     string
+		wantErr  bool
+		wantDone bool
+	}{
+		{
+			name: "text chunk",
+			data: `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]}}]}`,
+			want: "Hello",
+		},
+		{
+			name:     "last chunk",
+			data:     `{"candidates":[{"content":{"role":"model","parts":[{"text":"!"}]},"finishReason":"STOP"}]}`,
+			want:     "!",
+			wantDone: true,
+		},
+		{
+			name:    "error",
+			data:    `{"error":{"code":429,"message":"Resource exhausted"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llm.ParseStreamChunk([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStreamChunk() error = %v, wantErr %v", err, tt.wantErr)
+			}
 			if got != tt.want {
-				t.Errorf("GetUsage() = %s, want %s", got, tt.want)
+				t.Errorf("ParseStreamChunk() = %q, want %q", got, tt.want)
+			}
+			if done := llm.IsStreamDone([]byte(tt.data)); done != tt.wantDone {
+				t.Errorf("IsStreamDone() = %v, want %v", done, tt.wantDone)
 			}
 		})
 	}
@@ -1,8 +1,10 @@
 package llm
 
 import (
+	"context"
 	"github.com/belingud/gptcomet/pkg/config"
 	"github.com/belingud/gptcomet/pkg/types"
+	"net/http"
 )
 
 const (
@@ -49,3 +51,13 @@ func (x *XAILLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 		},
 	}
 }
+
+// MakeStreamRequest makes a streaming request to the API
+func (x *XAILLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return x.BaseLLM.MakeStreamRequest(ctx, client, x, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (x *XAILLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return x.BaseLLM.ListModels(ctx, client, x)
+}
@@ -54,6 +54,16 @@ func (y *YiLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 }
 
 // MakeRequest makes a request to the Yi API, formats the response, and returns the result as a string.
-func (y *YiLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	return y.BaseLLM.MakeRequest(ctx, client, y, message, stream)
+func (y *YiLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	return y.BaseLLM.MakeRequest(ctx, client, y, messages, stream)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (y *YiLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback StreamCallback) error {
+	return y.BaseLLM.MakeStreamRequest(ctx, client, y, messages, callback)
+}
+
+// ListModels lists the models available from the API
+func (y *YiLLM) ListModels(ctx context.Context, client *http.Client) ([]string, error) {
+	return y.BaseLLM.ListModels(ctx, client, y)
 }
@@ -1,6 +1,8 @@
 package testutils
 
 import (
+	"time"
+
 	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/stretchr/testify/mock"
 )
@@ -46,7 +48,7 @@ func (m *MockConfigManager) GetPath() string {
 }
 
 func (m *MockConfigManager) GetClientConfig(initProvider string) (*types.ClientConfig, error) {
-	args := m.Called()
+	args := m.Called(initProvider)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -63,6 +65,22 @@ func (m *MockConfigManager) GetFileIgnore() []string {
 	return args.Get(0).([]string)
 }
 
+func (m *MockConfigManager) GetFallbackProviders() []string {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]string)
+}
+
+func (m *MockConfigManager) GetProtectedBranches() []string {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]string)
+}
+
 func (m *MockConfigManager) GetOutputTranslateTitle() bool {
 	args := m.Called()
 	return args.Bool(0)
@@ -83,6 +101,57 @@ func (m *MockConfigManager) GetTranslationPrompt() string {
 	return args.String(0)
 }
 
+func (m *MockConfigManager) GetFeedbackPrompt() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockConfigManager) GetChunkSummaryPrompt() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockConfigManager) GetSplitPrompt() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockConfigManager) GetChunkEnabled() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockConfigManager) GetChunkTokenBudget() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *MockConfigManager) GetCacheEnabled() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockConfigManager) GetCacheTTL() time.Duration {
+	args := m.Called()
+	return args.Get(0).(time.Duration)
+}
+
+func (m *MockConfigManager) GetCacheMaxSize() int64 {
+	args := m.Called()
+	return args.Get(0).(int64)
+}
+
+func (m *MockConfigManager) GetCustomProviders() (map[string]types.ProviderDefinition, error) {
+	args := m.Called()
+	definitions, _ := args.Get(0).(map[string]types.ProviderDefinition)
+	return definitions, args.Error(1)
+}
+
+func (m *MockConfigManager) GetModelPrice(model string) (types.ModelPrice, bool) {
+	args := m.Called(model)
+	return args.Get(0).(types.ModelPrice), args.Bool(1)
+}
+
 func (m *MockConfigManager) GetWithDefault(key string, defaultValue interface{}) interface{} {
 	args := m.Called(key, defaultValue)
 	return args.Get(0)
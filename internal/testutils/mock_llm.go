@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/belingud/gptcomet/internal/llm"
 	"github.com/belingud/gptcomet/pkg/config"
+	"github.com/belingud/gptcomet/pkg/types"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -28,8 +30,8 @@ func (m *MockLLM) BuildURL() string {
 	return args.String(0)
 }
 
-func (m *MockLLM) FormatMessages(message string) (interface{}, error) {
-	args := m.Called(message)
+func (m *MockLLM) FormatMessages(messages []types.Message) (interface{}, error) {
+	args := m.Called(messages)
 	return args.Get(0), args.Error(1)
 }
 
@@ -38,16 +40,24 @@ func (m *MockLLM) ParseResponse(response []byte) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockLLM) GetUsage(data []byte) (string, error) {
+func (m *MockLLM) GetUsage(data []byte) (*types.Usage, error) {
 	args := m.Called(data)
-	return args.String(0), args.Error(1)
+	if usage, ok := args.Get(0).(*types.Usage); ok {
+		return usage, args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
-func (m *MockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, stream bool) (string, error) {
-	args := m.Called(ctx, client, message, stream)
+func (m *MockLLM) MakeRequest(ctx context.Context, client *http.Client, messages []types.Message, stream bool) (string, error) {
+	args := m.Called(ctx, client, messages, stream)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockLLM) MakeStreamRequest(ctx context.Context, client *http.Client, messages []types.Message, callback llm.StreamCallback) error {
+	args := m.Called(ctx, client, messages, callback)
+	return args.Error(0)
+}
+
 func (m *MockLLM) Complete(message string) (string, error) {
 	args := m.Called(message)
 	return args.String(0), args.Error(1)
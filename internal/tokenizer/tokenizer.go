@@ -0,0 +1,200 @@
+package tokenizer
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+// Family is a group of models that share a tokenizer
+type Family string
+
+const (
+	FamilyDefault  Family = "default"
+	FamilyOpenAI   Family = "openai"
+	FamilyClaude   Family = "claude"
+	FamilyGemini   Family = "gemini"
+	FamilyLlama    Family = "llama"
+	FamilyMistral  Family = "mistral"
+	FamilyQwen     Family = "qwen"
+	FamilyDeepSeek Family = "deepseek"
+)
+
+// messageOverhead is the number of tokens added for the role and separators of every message
+const messageOverhead = 4
+
+// ratio describes how a tokenizer family splits text
+type ratio struct {
+	// charsPerToken is the average number of non-CJK characters per token
+	charsPerToken float64
+	// tokensPerCJK is the average number of tokens per CJK character
+	tokensPerCJK float64
+}
+
+var ratios = map[Family]ratio{
+	FamilyDefault:  {charsPerToken: 4, tokensPerCJK: 1},
+	FamilyOpenAI:   {charsPerToken: 4, tokensPerCJK: 1},
+	FamilyClaude:   {charsPerToken: 3.5, tokensPerCJK: 1.2},
+	FamilyGemini:   {charsPerToken: 4, tokensPerCJK: 0.8},
+	FamilyLlama:    {charsPerToken: 3.8, tokensPerCJK: 1},
+	FamilyMistral:  {charsPerToken: 3.5, tokensPerCJK: 1.2},
+	FamilyQwen:     {charsPerToken: 3.8, tokensPerCJK: 0.7},
+	FamilyDeepSeek: {charsPerToken: 3.8, tokensPerCJK: 0.7},
+}
+
+// familyPrefixes maps model name prefixes to their tokenizer family
+var familyPrefixes = []struct {
+	prefix string
+	family Family
+}{
+	{"gpt-", FamilyOpenAI},
+	{"o1", FamilyOpenAI},
+	{"o3", FamilyOpenAI},
+	{"o4", FamilyOpenAI},
+	{"chatgpt", FamilyOpenAI},
+	{"claude", FamilyClaude},
+	{"gemini", FamilyGemini},
+	{"gemma", FamilyGemini},
+	{"llama", FamilyLlama},
+	{"meta-llama", FamilyLlama},
+	{"codellama", FamilyLlama},
+	{"mistral", FamilyMistral},
+	{"mixtral", FamilyMistral},
+	{"codestral", FamilyMistral},
+	{"ministral", FamilyMistral},
+	{"pixtral", FamilyMistral},
+	{"qwen", FamilyQwen},
+	{"qwq", FamilyQwen},
+	{"deepseek", FamilyDeepSeek},
+}
+
+// exactContextWindows holds the context windows of models that are matched by
+// their full name, because later models extend their names
+var exactContextWindows = map[string]int{
+	"gpt-4":      8192,
+	"gpt-4-0613": 8192,
+	"gpt-4-0314": 8192,
+}
+
+// contextWindows holds the context windows of well-known models, in tokens.
+// More specific prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-5", 400000},
+	{"gpt-4.5", 128000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-1106", 128000},
+	{"gpt-4-0125", 128000},
+	{"gpt-4-vision", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-3.5-turbo", 16385},
+	{"o1-mini", 128000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4-mini", 200000},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini-1.0-pro", 32760},
+	{"gemini-pro", 32760},
+	{"gemini", 1048576},
+	{"deepseek", 128000},
+	{"mistral-large", 131072},
+	{"mistral-small", 131072},
+	{"mistral", 32768},
+	{"codestral", 262144},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3.3", 131072},
+	{"llama-3.1", 131072},
+	{"llama-3.2", 131072},
+	{"llama-3.3", 131072},
+	{"llama3", 8192},
+	{"llama-3", 8192},
+	{"qwen-turbo", 1000000},
+	{"qwen-plus", 131072},
+	{"qwen-max", 32768},
+	{"grok", 131072},
+	{"moonshot-v1-8k", 8192},
+	{"moonshot-v1-32k", 32768},
+	{"moonshot-v1-128k", 131072},
+	{"command-r", 128000},
+	{"glm-4", 128000},
+}
+
+// normalizeModel lowercases the model name and strips prefixes such as
+// "models/" or "openai/" used by some providers and routers.
+func normalizeModel(model string) string {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	return model
+}
+
+// FamilyOf returns the tokenizer family of model, or FamilyDefault if it is unknown
+func FamilyOf(model string) Family {
+	model = normalizeModel(model)
+	for _, p := range familyPrefixes {
+		if strings.HasPrefix(model, p.prefix) {
+			return p.family
+		}
+	}
+	return FamilyDefault
+}
+
+// Estimate returns an approximation of the number of tokens model uses for text.
+// CJK characters are counted separately, as most tokenizers encode them with
+// about one token per character instead of several characters per token.
+func Estimate(model string, text string) int {
+	if text == "" {
+		return 0
+	}
+	r := ratios[FamilyOf(model)]
+
+	var cjk, other int
+	for _, c := range text {
+		if isCJK(c) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+
+	return int(math.Ceil(float64(other)/r.charsPerToken + float64(cjk)*r.tokensPerCJK))
+}
+
+// EstimateMessages returns an approximation of the number of prompt tokens
+// model uses for messages, including the overhead of every message.
+func EstimateMessages(model string, messages []types.Message) int {
+	total := 0
+	for _, m := range messages {
+		total += Estimate(model, m.Content) + messageOverhead
+	}
+	return total
+}
+
+// ContextWindow returns the known context window of model in tokens,
+// or 0 if the model is unknown.
+func ContextWindow(model string) int {
+	model = normalizeModel(model)
+	if tokens, ok := exactContextWindows[model]; ok {
+		return tokens
+	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(model, w.prefix) {
+			return w.tokens
+		}
+	}
+	return 0
+}
+
+// isCJK reports whether c is a Chinese, Japanese or Korean character
+func isCJK(c rune) bool {
+	return unicode.In(c, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,96 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+func TestFamilyOf(t *testing.T) {
+	tests := []struct {
+		model string
+		want  Family
+	}{
+		{"gpt-4o", FamilyOpenAI},
+		{"o3-mini", FamilyOpenAI},
+		{"claude-3-5-sonnet-latest", FamilyClaude},
+		{"models/gemini-1.5-flash", FamilyGemini},
+		{"meta-llama/Llama-3.1-70B-Instruct", FamilyLlama},
+		{"codestral-latest", FamilyMistral},
+		{"Qwen/Qwen2.5-Coder-32B-Instruct", FamilyQwen},
+		{"deepseek-chat", FamilyDeepSeek},
+		{"my-local-model", FamilyDefault},
+		{"", FamilyDefault},
+	}
+	for _, tt := range tests {
+		if got := FamilyOf(tt.model); got != tt.want {
+			t.Errorf("FamilyOf(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		text  string
+		want  int
+	}{
+		{"empty", "gpt-4o", "", 0},
+		{"openai ascii", "gpt-4o", strings.Repeat("a", 400), 100},
+		{"default rounds up", "", "abcde", 2},
+		{"claude ascii", "claude-3-opus", strings.Repeat("a", 350), 100},
+		{"openai cjk", "gpt-4o", "提交信息", 4},
+		{"qwen cjk", "qwen-max", strings.Repeat("中", 10), 7},
+		{"mixed", "gpt-4o", "fix: 修复", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Estimate(tt.model, tt.text); got != tt.want {
+				t.Errorf("Estimate(%q, %q) = %d, want %d", tt.model, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateMessages(t *testing.T) {
+	messages := []types.Message{
+		{Role: types.RoleSystem, Content: strings.Repeat("a", 40)},
+		{Role: types.RoleUser, Content: strings.Repeat("b", 80)},
+	}
+	want := 10 + 20 + 2*messageOverhead
+	if got := EstimateMessages("gpt-4o", messages); got != want {
+		t.Errorf("EstimateMessages() = %d, want %d", got, want)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4o-mini", 128000},
+		{"gpt-4", 8192},
+		{"gpt-4-0613", 8192},
+		{"gpt-4-32k-0613", 32768},
+		{"gpt-4-turbo-preview", 128000},
+		{"gpt-4.5-preview", 128000},
+		{"gpt-4-1106-preview", 128000},
+		{"gpt-4-0125-preview", 128000},
+		{"gpt-4-vision-preview", 128000},
+		{"GPT-4.1", 1047576},
+		{"claude-3-5-haiku-latest", 200000},
+		{"gemini-1.5-pro-002", 2097152},
+		{"gemini-2.0-flash", 1048576},
+		{"openrouter/deepseek-chat", 128000},
+		{"llama3.1:8b", 131072},
+		{"llama3:8b", 8192},
+		{"my-local-model", 0},
+	}
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
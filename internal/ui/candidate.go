@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CandidateAction is the action chosen for a candidate in the CandidateSelector
+type CandidateAction int
+
+const (
+	// CandidateActionNone means the selector was quit without choosing a candidate
+	CandidateActionNone CandidateAction = iota
+	// CandidateActionSelect means the candidate was picked as it is
+	CandidateActionSelect
+	// CandidateActionEdit means the candidate should be edited before use
+	CandidateActionEdit
+	// CandidateActionRegenerate means the candidate should be generated again
+	CandidateActionRegenerate
+)
+
+var previewStyle = lipgloss.NewStyle().
+	MarginLeft(4).
+	Padding(0, 1).
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("240"))
+
+// CandidateSelector lists alternative commit messages and lets the user pick,
+// edit or regenerate one of them. Each item shows the subject line, and the
+// full message of the highlighted candidate is previewed below the list.
+type CandidateSelector struct {
+	list       list.Model
+	candidates []string
+	action     CandidateAction
+	index      int
+	quitting   bool
+}
+
+// NewCandidateSelector creates a CandidateSelector for the given candidates
+func NewCandidateSelector(candidates []string) *CandidateSelector {
+	items := make([]list.Item, len(candidates))
+	for i, c := range candidates {
+		subject, _, _ := strings.Cut(c, "\n")
+		items[i] = item{title: subject, description: c}
+	}
+
+	const defaultWidth = 80
+
+	// Calculate list height based on number of items
+	// Add 5 for title, help text and footer
+	listHeight := len(items) + helpTextHeight
+
+	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
+	l.Title = "Select Commit Message (enter: pick, e: edit, r: regenerate, q: quit)"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+	l.Styles.Title = titleStyle
+	l.Styles.PaginationStyle = paginationStyle
+	l.Styles.HelpStyle = helpStyle
+
+	l.DisableQuitKeybindings()
+	l.SetShowPagination(false)
+
+	return &CandidateSelector{
+		list:       l,
+		candidates: candidates,
+	}
+}
+
+func (m *CandidateSelector) Init() tea.Cmd {
+	return nil
+}
+
+func (m *CandidateSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetWidth(msg.Width)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			m.quitting = true
+			return m, tea.Quit
+		case "enter":
+			return m.choose(CandidateActionSelect)
+		case "e":
+			return m.choose(CandidateActionEdit)
+		case "r":
+			return m.choose(CandidateActionRegenerate)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// choose records the action for the highlighted candidate and quits
+func (m *CandidateSelector) choose(action CandidateAction) (tea.Model, tea.Cmd) {
+	if len(m.candidates) == 0 {
+		return m, nil
+	}
+	m.action = action
+	m.index = m.list.Index()
+	return m, tea.Quit
+}
+
+func (m *CandidateSelector) View() string {
+	switch m.action {
+	case CandidateActionSelect:
+		return quitTextStyle.Render(fmt.Sprintf("Selected candidate %d", m.index+1))
+	case CandidateActionEdit:
+		return quitTextStyle.Render(fmt.Sprintf("Editing candidate %d", m.index+1))
+	case CandidateActionRegenerate:
+		return quitTextStyle.Render(fmt.Sprintf("Regenerating candidate %d", m.index+1))
+	}
+	if m.quitting {
+		return quitTextStyle.Render("Selection cancelled.")
+	}
+
+	view := "\n" + m.list.View()
+	if i, ok := m.list.SelectedItem().(item); ok {
+		view += "\n" + previewStyle.Render(i.Description()) + "\n"
+	}
+	return view
+}
+
+// Action returns the action chosen by the user
+func (m *CandidateSelector) Action() CandidateAction {
+	return m.action
+}
+
+// Index returns the index of the candidate the action applies to
+func (m *CandidateSelector) Index() int {
+	return m.index
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCandidateSelector(t *testing.T) {
+	candidates := []string{
+		"feat: add login\n\n- add login form",
+		"feat: support user login",
+	}
+
+	selector := NewCandidateSelector(candidates)
+	require.NotNil(t, selector)
+
+	items := selector.list.Items()
+	require.Len(t, items, 2)
+	assert.Equal(t, "feat: add login", items[0].(item).Title())
+	assert.Equal(t, candidates[0], items[0].(item).Description())
+	assert.Equal(t, CandidateActionNone, selector.Action())
+}
+
+func TestCandidateSelector_Update(t *testing.T) {
+	candidates := []string{"feat: first", "feat: second"}
+
+	tests := []struct {
+		name       string
+		keys       []tea.KeyMsg
+		wantAction CandidateAction
+		wantIndex  int
+		wantQuit   bool
+	}{
+		{
+			name:       "Enter picks highlighted candidate",
+			keys:       []tea.KeyMsg{{Type: tea.KeyEnter}},
+			wantAction: CandidateActionSelect,
+			wantIndex:  0,
+		},
+		{
+			name:       "Edit second candidate",
+			keys:       []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeyRunes, Runes: []rune("e")}},
+			wantAction: CandidateActionEdit,
+			wantIndex:  1,
+		},
+		{
+			name:       "Regenerate second candidate",
+			keys:       []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeyRunes, Runes: []rune("r")}},
+			wantAction: CandidateActionRegenerate,
+			wantIndex:  1,
+		},
+		{
+			name:       "Quit without choosing",
+			keys:       []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("q")}},
+			wantAction: CandidateActionNone,
+			wantQuit:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := NewCandidateSelector(candidates)
+			for _, key := range tt.keys {
+				_, _ = selector.Update(key)
+			}
+
+			assert.Equal(t, tt.wantAction, selector.Action())
+			assert.Equal(t, tt.wantIndex, selector.Index())
+			assert.Equal(t, tt.wantQuit, selector.quitting)
+		})
+	}
+}
+
+func TestCandidateSelector_View(t *testing.T) {
+	selector := NewCandidateSelector([]string{"feat: first\n\n- detail line", "feat: second"})
+
+	view := selector.View()
+	assert.Contains(t, view, "feat: first")
+	assert.Contains(t, view, "- detail line")
+
+	_, _ = selector.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, selector.View(), "Selected candidate 1")
+}
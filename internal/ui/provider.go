@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"io"
+	"slices"
 	"sort"
 	"strings"
 
@@ -210,6 +211,18 @@ func (m *ProviderSelector) Selected() string {
 	return m.choice
 }
 
+// maxModelMatches is the number of listed models shown below the model input
+const maxModelMatches = 8
+
+// ModelsFunc lists the models of the provider with the config values entered so far
+type ModelsFunc func(configs map[string]string) ([]string, error)
+
+// modelsMsg carries the result of a ModelsFunc
+type modelsMsg struct {
+	models []string
+	err    error
+}
+
 type ConfigInput struct {
 	// provider   string
 	inputs     []textinput.Model
@@ -218,6 +231,14 @@ type ConfigInput struct {
 	currentKey int
 	done       bool
 	quitting   bool
+
+	// listModels lists the models offered when the model is asked, if set
+	listModels    ModelsFunc
+	models        []string
+	modelsErr     error
+	loadingModels bool
+	// modelCursor is the index of the highlighted model in modelMatches, or -1
+	modelCursor int
 }
 
 func NewConfigInput(configs map[string]config.ConfigRequirement) *ConfigInput {
@@ -260,26 +281,103 @@ func NewConfigInput(configs map[string]config.ConfigRequirement) *ConfigInput {
 	}
 
 	return &ConfigInput{
-		inputs:     inputs,
-		configs:    configs,
-		configKeys: configKeys,
-		currentKey: 0,
+		inputs:      inputs,
+		configs:     configs,
+		configKeys:  configKeys,
+		currentKey:  0,
+		modelCursor: -1,
 	}
 }
 
+// WithModels makes the input offer the models listed by fn when it asks for the
+// model. The model is asked after the API base and key then, so fn can use them.
+// Typing filters the listed models, and any other model name can still be entered.
+func (m *ConfigInput) WithModels(fn ModelsFunc) *ConfigInput {
+	m.listModels = fn
+
+	model := slices.Index(m.configKeys, "model")
+	apiKey := slices.Index(m.configKeys, "api_key")
+	if model >= 0 && apiKey > model {
+		input := m.inputs[model]
+		m.configKeys = slices.Insert(slices.Delete(m.configKeys, model, model+1), apiKey, "model")
+		m.inputs = slices.Insert(slices.Delete(m.inputs, model, model+1), apiKey, input)
+		for i := range m.inputs {
+			if i == m.currentKey {
+				m.inputs[i].Focus()
+			} else {
+				m.inputs[i].Blur()
+			}
+		}
+	}
+	return m
+}
+
 func (m *ConfigInput) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, m.loadModels())
+}
+
+// loadModels starts listing the models when the model is asked and they are
+// not listed yet
+func (m *ConfigInput) loadModels() tea.Cmd {
+	if m.listModels == nil || len(m.configKeys) == 0 || m.configKeys[m.currentKey] != "model" ||
+		m.loadingModels || m.models != nil || m.modelsErr != nil {
+		return nil
+	}
+	m.loadingModels = true
+	fn, configs := m.listModels, m.GetConfigs()
+	return func() tea.Msg {
+		models, err := fn(configs)
+		return modelsMsg{models: models, err: err}
+	}
+}
+
+// modelMatches returns the listed models containing the entered text
+func (m *ConfigInput) modelMatches() []string {
+	if m.configKeys[m.currentKey] != "model" {
+		return nil
+	}
+	filter := strings.ToLower(m.inputs[m.currentKey].Value())
+	var matches []string
+	for _, model := range m.models {
+		if strings.Contains(strings.ToLower(model), filter) {
+			matches = append(matches, model)
+		}
+	}
+	return matches
 }
 
 func (m *ConfigInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case modelsMsg:
+		m.loadingModels = false
+		m.models, m.modelsErr = msg.models, msg.err
+		if m.models == nil && m.modelsErr == nil {
+			m.models = []string{}
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			m.quitting = true
 			return m, tea.Quit
 
+		case tea.KeyUp, tea.KeyDown:
+			if matches := m.modelMatches(); len(matches) > 0 {
+				if msg.Type == tea.KeyUp {
+					m.modelCursor = max(m.modelCursor-1, 0)
+				} else {
+					m.modelCursor = min(m.modelCursor+1, min(len(matches), maxModelMatches)-1)
+				}
+				return m, nil
+			}
+
 		case tea.KeyEnter:
+			// use the highlighted model, if any
+			if matches := m.modelMatches(); m.modelCursor >= 0 && m.modelCursor < len(matches) {
+				m.inputs[m.currentKey].SetValue(matches[m.modelCursor])
+			}
+
 			// if current input is empty and has default value
 			if m.inputs[m.currentKey].Value() == "" {
 				if def := m.configs[m.configKeys[m.currentKey]].DefaultValue; def != "" {
@@ -295,7 +393,7 @@ func (m *ConfigInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.inputs[m.currentKey].Blur()
 				m.currentKey++
 				m.inputs[m.currentKey].Focus()
-				return m, nil
+				return m, m.loadModels()
 			}
 
 			// if last input, done
@@ -306,7 +404,15 @@ func (m *ConfigInput) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// only update current input
 	var cmd tea.Cmd
+	value := m.inputs[m.currentKey].Value()
 	m.inputs[m.currentKey], cmd = m.inputs[m.currentKey].Update(msg)
+	if m.inputs[m.currentKey].Value() != value {
+		// highlight the first model matching the new text
+		m.modelCursor = -1
+		if len(m.modelMatches()) > 0 && m.inputs[m.currentKey].Value() != "" {
+			m.modelCursor = 0
+		}
+	}
 	return m, cmd
 }
 
@@ -351,7 +457,9 @@ func (m *ConfigInput) View() string {
 	}
 	s.WriteString(":\n")
 	s.WriteString(m.inputs[m.currentKey].View())
-	s.WriteString("\n\n")
+	s.WriteString("\n")
+	s.WriteString(m.modelsView())
+	s.WriteString("\n")
 
 	// show progress
 	s.WriteString(fmt.Sprintf("(%d/%d) Press Enter to continue, Esc to quit", m.currentKey+1, len(m.inputs)))
@@ -359,6 +467,38 @@ func (m *ConfigInput) View() string {
 	return s.String()
 }
 
+// modelsView shows the listed models matching the entered text, when the
+// model is asked
+func (m *ConfigInput) modelsView() string {
+	if m.listModels == nil || m.configKeys[m.currentKey] != "model" {
+		return ""
+	}
+	switch {
+	case m.loadingModels:
+		return "  Loading models...\n"
+	case m.modelsErr != nil:
+		return fmt.Sprintf("  Can't list models, enter the name: %v\n", m.modelsErr)
+	}
+
+	matches := m.modelMatches()
+	if len(matches) == 0 {
+		return ""
+	}
+	var s strings.Builder
+	for i, model := range matches[:min(len(matches), maxModelMatches)] {
+		if i == m.modelCursor {
+			s.WriteString(selectedItemStyle.Render("> "+model) + "\n")
+		} else {
+			s.WriteString(itemStyle.Render(model) + "\n")
+		}
+	}
+	if len(matches) > maxModelMatches {
+		s.WriteString(fmt.Sprintf("    ... %d more, type to filter\n", len(matches)-maxModelMatches))
+	}
+	s.WriteString("  (↑/↓ to pick a listed model)\n")
+	return s.String()
+}
+
 func (m *ConfigInput) Done() bool {
 	return m.done
 }
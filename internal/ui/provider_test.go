@@ -344,3 +344,61 @@ func TestItemDelegate_Interface(t *testing.T) {
 		t.Errorf("Expected spacing 0, got %d", d.Spacing())
 	}
 }
+
+func TestConfigInputWithModels(t *testing.T) {
+	configs := map[string]config.ConfigRequirement{
+		"api_base":   {DefaultValue: "https://api.openai.com/v1"},
+		"model":      {DefaultValue: "gpt-4o"},
+		"api_key":    {DefaultValue: ""},
+		"max_tokens": {DefaultValue: "1024"},
+	}
+
+	var listedWith map[string]string
+	ci := NewConfigInput(configs).WithModels(func(values map[string]string) ([]string, error) {
+		listedWith = values
+		return []string{"gpt-4o", "gpt-4o-mini", "o3"}, nil
+	})
+
+	// The model is asked after the API key
+	assert.Equal(t, []string{"api_base", "api_key", "model", "max_tokens"}, ci.configKeys)
+	assert.True(t, ci.inputs[0].Focused())
+	assert.Equal(t, "gpt-4o", ci.inputs[2].Placeholder)
+
+	_, _ = ci.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	ci.inputs[1].SetValue("sk-test")
+	_, cmd := ci.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd, "asking for the model should list the models")
+	assert.True(t, ci.loadingModels)
+
+	_, _ = ci.Update(cmd())
+	assert.False(t, ci.loadingModels)
+	assert.Equal(t, "sk-test", listedWith["api_key"])
+	assert.Contains(t, ci.View(), "gpt-4o-mini")
+
+	// Typing filters the models and highlights the first match
+	_, _ = ci.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("4o")})
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, ci.modelMatches())
+	_, _ = ci.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, _ = ci.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "gpt-4o-mini", ci.GetConfigs()["model"])
+	assert.Equal(t, 3, ci.currentKey)
+}
+
+func TestConfigInputWithModelsError(t *testing.T) {
+	configs := map[string]config.ConfigRequirement{
+		"model": {DefaultValue: "llama2"},
+	}
+
+	ci := NewConfigInput(configs).WithModels(func(map[string]string) ([]string, error) {
+		return nil, assert.AnError
+	})
+	cmd := ci.loadModels()
+	require.NotNil(t, cmd)
+	_, _ = ci.Update(cmd())
+	assert.Contains(t, ci.View(), "Can't list models")
+
+	// The model can still be entered
+	_, _ = ci.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, ci.Done())
+	assert.Equal(t, "llama2", ci.GetConfigs()["model"])
+}
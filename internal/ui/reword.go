@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/belingud/gptcomet/internal/git"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RewordAction is the action chosen in the RewordSelector
+type RewordAction int
+
+const (
+	// RewordActionNone means the selector was quit without rewording
+	RewordActionNone RewordAction = iota
+	// RewordActionApply means the selected commits should be reworded
+	RewordActionApply
+	// RewordActionEdit means the new message of a commit should be edited
+	RewordActionEdit
+	// RewordActionRegenerate means the new message of a commit should be generated again
+	RewordActionRegenerate
+)
+
+// RewordEntry is a commit with its current and generated message
+type RewordEntry struct {
+	Hash       string
+	OldMessage string
+	// NewMessage is empty if no message could be generated
+	NewMessage string
+	// Selected reports whether the commit gets the new message
+	Selected bool
+}
+
+var (
+	messageBoxStyle = lipgloss.NewStyle().
+			Padding(0, 1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240"))
+
+	messageLabelStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("240"))
+)
+
+// RewordSelector lists the commits of a range with their generated messages.
+// The user selects the commits to reword, and the current and new message of
+// the highlighted commit are shown side by side below the list.
+type RewordSelector struct {
+	entries  []RewordEntry
+	cursor   int
+	width    int
+	action   RewordAction
+	quitting bool
+}
+
+// NewRewordSelector creates a RewordSelector for entries, with the commit at
+// cursor highlighted
+func NewRewordSelector(entries []RewordEntry, cursor int) *RewordSelector {
+	if cursor < 0 || cursor >= len(entries) {
+		cursor = 0
+	}
+	return &RewordSelector{
+		entries: entries,
+		cursor:  cursor,
+		width:   100,
+	}
+}
+
+func (m *RewordSelector) Init() tea.Cmd {
+	return nil
+}
+
+func (m *RewordSelector) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			m.quitting = true
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.entries)-1 {
+				m.cursor++
+			}
+		case " ", "x":
+			m.toggle(m.cursor)
+		case "a":
+			m.toggleAll()
+		case "enter":
+			return m.choose(RewordActionApply)
+		case "e":
+			return m.choose(RewordActionEdit)
+		case "r":
+			return m.choose(RewordActionRegenerate)
+		}
+	}
+	return m, nil
+}
+
+// toggle selects or deselects the commit at i, if it has a new message
+func (m *RewordSelector) toggle(i int) {
+	if i < len(m.entries) && m.entries[i].NewMessage != "" {
+		m.entries[i].Selected = !m.entries[i].Selected
+	}
+}
+
+// toggleAll selects all commits with a new message, or deselects all if they
+// are all selected already
+func (m *RewordSelector) toggleAll() {
+	selectAll := false
+	for _, entry := range m.entries {
+		if entry.NewMessage != "" && !entry.Selected {
+			selectAll = true
+		}
+	}
+	for i := range m.entries {
+		m.entries[i].Selected = selectAll && m.entries[i].NewMessage != ""
+	}
+}
+
+// choose records the action and quits
+func (m *RewordSelector) choose(action RewordAction) (tea.Model, tea.Cmd) {
+	if len(m.entries) == 0 {
+		return m, nil
+	}
+	m.action = action
+	return m, tea.Quit
+}
+
+func (m *RewordSelector) View() string {
+	switch m.action {
+	case RewordActionApply:
+		return quitTextStyle.Render(fmt.Sprintf("Rewording %d commits", m.selectedCount()))
+	case RewordActionEdit:
+		return quitTextStyle.Render(fmt.Sprintf("Editing the message of %s", git.ShortHash(m.entries[m.cursor].Hash)))
+	case RewordActionRegenerate:
+		return quitTextStyle.Render(fmt.Sprintf("Regenerating the message of %s", git.ShortHash(m.entries[m.cursor].Hash)))
+	}
+	if m.quitting {
+		return quitTextStyle.Render("Reword cancelled.")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n")
+	sb.WriteString(titleStyle.Render("Reword Commits (space: select, a: all, enter: reword, e: edit, r: regenerate, q: quit)"))
+	sb.WriteString("\n\n")
+	for i, entry := range m.entries {
+		check := "[ ]"
+		if entry.Selected {
+			check = "[x]"
+		}
+		subject := git.FirstLine(entry.NewMessage)
+		if subject == "" {
+			subject = git.FirstLine(entry.OldMessage) + " (no new message)"
+		}
+		line := fmt.Sprintf("%s %s %s", check, git.ShortHash(entry.Hash), subject)
+		if i == m.cursor {
+			sb.WriteString(selectedItemStyle.Render("> " + line))
+		} else {
+			sb.WriteString(itemStyle.Render(line))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(m.entries) > 0 {
+		entry := m.entries[m.cursor]
+		// Two boxes with borders and padding, next to each other
+		boxWidth := max((m.width-8)/2-4, 20)
+		oldBox := lipgloss.JoinVertical(lipgloss.Left,
+			messageLabelStyle.Render("Current message"),
+			messageBoxStyle.Width(boxWidth).Render(strings.TrimSpace(entry.OldMessage)))
+		newMessage := strings.TrimSpace(entry.NewMessage)
+		if newMessage == "" {
+			newMessage = "(no message generated)"
+		}
+		newBox := lipgloss.JoinVertical(lipgloss.Left,
+			messageLabelStyle.Render("New message"),
+			messageBoxStyle.Width(boxWidth).Render(newMessage))
+		sb.WriteString("\n")
+		sb.WriteString(lipgloss.NewStyle().MarginLeft(4).Render(lipgloss.JoinHorizontal(lipgloss.Top, oldBox, "  ", newBox)))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// selectedCount returns the number of selected commits
+func (m *RewordSelector) selectedCount() int {
+	count := 0
+	for _, entry := range m.entries {
+		if entry.Selected {
+			count++
+		}
+	}
+	return count
+}
+
+// Action returns the action chosen by the user
+func (m *RewordSelector) Action() RewordAction {
+	return m.action
+}
+
+// Cursor returns the index of the highlighted commit, which the edit and
+// regenerate actions apply to
+func (m *RewordSelector) Cursor() int {
+	return m.cursor
+}
+
+// Entries returns the commits with the selection of the user
+func (m *RewordSelector) Entries() []RewordEntry {
+	return m.entries
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRewordEntries() []RewordEntry {
+	return []RewordEntry{
+		{Hash: "1111111aaaa", OldMessage: "wip", NewMessage: "feat: add login form", Selected: true},
+		{Hash: "2222222bbbb", OldMessage: "fix", NewMessage: "fix: validate password", Selected: true},
+		{Hash: "3333333cccc", OldMessage: "empty", NewMessage: ""},
+	}
+}
+
+func TestRewordSelector_Update(t *testing.T) {
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	key := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	tests := []struct {
+		name         string
+		keys         []tea.KeyMsg
+		wantAction   RewordAction
+		wantCursor   int
+		wantSelected []bool
+		wantQuit     bool
+	}{
+		{
+			name:         "Enter applies the selection",
+			keys:         []tea.KeyMsg{{Type: tea.KeyEnter}},
+			wantAction:   RewordActionApply,
+			wantSelected: []bool{true, true, false},
+		},
+		{
+			name:         "Space deselects a commit",
+			keys:         []tea.KeyMsg{down, space, {Type: tea.KeyEnter}},
+			wantAction:   RewordActionApply,
+			wantCursor:   1,
+			wantSelected: []bool{true, false, false},
+		},
+		{
+			name:         "Commits without a new message can't be selected",
+			keys:         []tea.KeyMsg{down, down, space},
+			wantCursor:   2,
+			wantSelected: []bool{true, true, false},
+		},
+		{
+			name:         "Toggle all",
+			keys:         []tea.KeyMsg{key("a")},
+			wantSelected: []bool{false, false, false},
+		},
+		{
+			name:         "Toggle all selects the deselected commits",
+			keys:         []tea.KeyMsg{space, key("a")},
+			wantSelected: []bool{true, true, false},
+		},
+		{
+			name:         "Edit the second commit",
+			keys:         []tea.KeyMsg{down, key("e")},
+			wantAction:   RewordActionEdit,
+			wantCursor:   1,
+			wantSelected: []bool{true, true, false},
+		},
+		{
+			name:         "Regenerate the first commit",
+			keys:         []tea.KeyMsg{key("r")},
+			wantAction:   RewordActionRegenerate,
+			wantSelected: []bool{true, true, false},
+		},
+		{
+			name:         "Quit",
+			keys:         []tea.KeyMsg{key("q")},
+			wantAction:   RewordActionNone,
+			wantSelected: []bool{true, true, false},
+			wantQuit:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := NewRewordSelector(newTestRewordEntries(), 0)
+			for _, key := range tt.keys {
+				_, _ = selector.Update(key)
+			}
+
+			assert.Equal(t, tt.wantAction, selector.Action())
+			assert.Equal(t, tt.wantCursor, selector.Cursor())
+			assert.Equal(t, tt.wantQuit, selector.quitting)
+			for i, entry := range selector.Entries() {
+				assert.Equal(t, tt.wantSelected[i], entry.Selected, "entry %d", i)
+			}
+		})
+	}
+}
+
+func TestRewordSelector_View(t *testing.T) {
+	selector := NewRewordSelector(newTestRewordEntries(), 1)
+
+	view := selector.View()
+	assert.Contains(t, view, "[x] 1111111 feat: add login form")
+	assert.Contains(t, view, "[ ] 3333333 empty (no new message)")
+	// The highlighted commit shows both messages
+	assert.Contains(t, view, "Current message")
+	assert.Contains(t, view, "fix: validate password")
+
+	_, _ = selector.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, selector.View(), "Rewording 2 commits")
+}
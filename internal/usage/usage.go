@@ -0,0 +1,196 @@
+// Package usage records the token usage of every request in a local ledger and aggregates it.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/belingud/gptcomet/pkg/types"
+)
+
+// LedgerFileName is the name of the usage ledger file in the config directory
+const LedgerFileName = "usage.jsonl"
+
+// GroupBy is the field records are aggregated by
+type GroupBy string
+
+const (
+	GroupByDay      GroupBy = "day"
+	GroupByProvider GroupBy = "provider"
+	GroupByModel    GroupBy = "model"
+)
+
+// Record is a single request in the usage ledger
+type Record struct {
+	Time      time.Time   `json:"time"`
+	Command   string      `json:"command"`
+	Provider  string      `json:"provider"`
+	Model     string      `json:"model"`
+	Usage     types.Usage `json:"usage"`
+	LatencyMS int64       `json:"latency_ms"`
+	Success   bool        `json:"success"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// Ledger appends usage records to a JSONL file
+type Ledger struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLedger returns a ledger that writes to the file at path
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Path returns the path of the ledger file
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Append writes record as a new line at the end of the ledger file,
+// creating the file and its directory if they don't exist.
+func (l *Ledger) Append(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage record: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create usage ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	// A single write keeps lines from concurrent processes intact
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// Load reads all records of the ledger file at path. A missing file has no
+// records, and lines that can't be parsed, such as a line cut off by a
+// crash, are skipped.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	return records, nil
+}
+
+// Summary is the aggregated usage of a group of records
+type Summary struct {
+	Key          string      `json:"key"`
+	Requests     int         `json:"requests"`
+	Failures     int         `json:"failures"`
+	Usage        types.Usage `json:"usage"`
+	AvgLatencyMS int64       `json:"avg_latency_ms"`
+	// Cost is the estimated cost in USD of the records whose model has a price
+	Cost float64 `json:"cost"`
+
+	totalLatencyMS int64
+}
+
+// PriceFunc returns the price of model, and false if it is unknown
+type PriceFunc func(model string) (types.ModelPrice, bool)
+
+// Aggregate groups records by groupBy and adds up their usage, sorted by key.
+// Days are formatted as YYYY-MM-DD in local time. price may be nil, in which
+// case no cost is estimated.
+func Aggregate(records []Record, groupBy GroupBy, price PriceFunc) ([]Summary, error) {
+	switch groupBy {
+	case GroupByDay, GroupByProvider, GroupByModel:
+	default:
+		return nil, fmt.Errorf("unsupported group %q, use one of: %s, %s, %s", groupBy, GroupByDay, GroupByProvider, GroupByModel)
+	}
+
+	summaries := make(map[string]*Summary)
+	for _, record := range records {
+		key := record.keyOf(groupBy)
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &Summary{Key: key}
+			summaries[key] = summary
+		}
+		summary.add(record, price)
+	}
+
+	result := make([]Summary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// keyOf returns the group of the record
+func (r Record) keyOf(groupBy GroupBy) string {
+	switch groupBy {
+	case GroupByProvider:
+		return r.Provider
+	case GroupByModel:
+		return r.Model
+	default:
+		return r.Time.Local().Format("2006-01-02")
+	}
+}
+
+// Total adds up all records into a single summary with the given key
+func Total(records []Record, key string, price PriceFunc) Summary {
+	summary := Summary{Key: key}
+	for _, record := range records {
+		summary.add(record, price)
+	}
+	return summary
+}
+
+// add adds record to the summary
+func (s *Summary) add(record Record, price PriceFunc) {
+	s.Requests++
+	if !record.Success {
+		s.Failures++
+	}
+	s.Usage.Add(record.Usage)
+	s.totalLatencyMS += record.LatencyMS
+	s.AvgLatencyMS = s.totalLatencyMS / int64(s.Requests)
+	if price != nil {
+		if p, ok := price(record.Model); ok {
+			s.Cost += p.Cost(record.Usage)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/belingud/gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger_AppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", LedgerFileName)
+	ledger := NewLedger(path)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, ledger.Append(Record{
+				Time:     time.Now(),
+				Command:  "commit",
+				Provider: "openai",
+				Model:    "gpt-4o",
+				Usage:    types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+				Success:  true,
+			}))
+		}()
+	}
+	wg.Wait()
+
+	records, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, records, 10)
+	assert.Equal(t, "gpt-4o", records[0].Model)
+	assert.Equal(t, 15, records[0].Usage.TotalTokens)
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		records, err := Load(filepath.Join(t.TempDir(), LedgerFileName))
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("skips broken lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), LedgerFileName)
+		data := `{"command":"commit","provider":"openai","model":"gpt-4o","success":true}
+{"command":"rev
+{"command":"review","provider":"openai","model":"gpt-4o","success":false}
+`
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+		records, err := Load(path)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "review", records[1].Command)
+	})
+}
+
+func TestAggregate(t *testing.T) {
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	day2 := time.Date(2026, 10, 2, 12, 0, 0, 0, time.Local)
+	records := []Record{
+		{Time: day1, Provider: "openai", Model: "gpt-4o", Usage: types.Usage{PromptTokens: 1000000, TotalTokens: 1000000}, LatencyMS: 100, Success: true},
+		{Time: day1, Provider: "deepseek", Model: "deepseek-chat", Usage: types.Usage{PromptTokens: 100, TotalTokens: 100}, LatencyMS: 300, Success: false},
+		{Time: day2, Provider: "openai", Model: "gpt-4o", Usage: types.Usage{CompletionTokens: 1000000, TotalTokens: 1000000}, LatencyMS: 200, Success: true},
+	}
+	price := func(model string) (types.ModelPrice, bool) {
+		if model == "gpt-4o" {
+			return types.ModelPrice{Input: 2.5, Output: 10}, true
+		}
+		return types.ModelPrice{}, false
+	}
+
+	tests := []struct {
+		name    string
+		groupBy GroupBy
+		want    []Summary
+		wantErr bool
+	}{
+		{
+			name:    "by day",
+			groupBy: GroupByDay,
+			want: []Summary{
+				{Key: "2026-10-01", Requests: 2, Failures: 1, Usage: types.Usage{PromptTokens: 1000100, TotalTokens: 1000100}, AvgLatencyMS: 200, Cost: 2.5},
+				{Key: "2026-10-02", Requests: 1, Usage: types.Usage{CompletionTokens: 1000000, TotalTokens: 1000000}, AvgLatencyMS: 200, Cost: 10},
+			},
+		},
+		{
+			name:    "by provider",
+			groupBy: GroupByProvider,
+			want: []Summary{
+				{Key: "deepseek", Requests: 1, Failures: 1, Usage: types.Usage{PromptTokens: 100, TotalTokens: 100}, AvgLatencyMS: 300},
+				{Key: "openai", Requests: 2, Usage: types.Usage{PromptTokens: 1000000, CompletionTokens: 1000000, TotalTokens: 2000000}, AvgLatencyMS: 150, Cost: 12.5},
+			},
+		},
+		{
+			name:    "unsupported group",
+			groupBy: "week",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Aggregate(records, tt.groupBy, price)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Key, got[i].Key)
+				assert.Equal(t, tt.want[i].Requests, got[i].Requests)
+				assert.Equal(t, tt.want[i].Failures, got[i].Failures)
+				assert.Equal(t, tt.want[i].Usage, got[i].Usage)
+				assert.Equal(t, tt.want[i].AvgLatencyMS, got[i].AvgLatencyMS)
+				assert.InDelta(t, tt.want[i].Cost, got[i].Cost, 1e-9)
+			}
+		})
+	}
+
+	total := Total(records, "total", nil)
+	assert.Equal(t, 3, total.Requests)
+	assert.Equal(t, 2000100, total.Usage.TotalTokens)
+	assert.Zero(t, total.Cost)
+}
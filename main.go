@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	"github.com/belingud/gptcomet/cmd"
@@ -19,13 +21,22 @@ var version = "2.5.1"
 // - config: Manage application configuration
 // - update: Check and update to latest version
 // - review: Review git changes and commit messages
+// - usage: Show token usage and estimated cost of past requests
+// - cache: Manage the cache of generated responses
+// - models: List the models available from the provider
+// - provider: Check the configured providers
+// - hook: Generate commit messages in a git prepare-commit-msg hook
+// - reword: Regenerate the messages of existing commits
+// - split: Split the staged changes into several commits
 //
 // The root command supports the following persistent flags:
 //
 //	--debug, -d: Enable debug mode for verbose logging
 //	--config, -c: Specify a custom config file path
 //
-// If command execution fails, the program exits with status code 1.
+// Ctrl-C cancels the context of the running command, which stops its requests
+// and prompts, prints a cancelled message and exits with status code 130.
+// If command execution fails otherwise, the program exits with status code 1.
 func main() {
 	var (
 		debugEnabled bool
@@ -33,11 +44,12 @@ func main() {
 	)
 
 	var rootCmd = &cobra.Command{
-		Use:          "gmsg",
-		Aliases:      []string{"gptcomet"},
-		Short:        "GPTComet - AI-powered Git commit message generator and reviewer",
-		Version:      version,
-		SilenceUsage: true,
+		Use:           "gmsg",
+		Aliases:       []string{"gptcomet"},
+		Short:         "GPTComet - AI-powered Git commit message generator and reviewer",
+		Version:       version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			if debugEnabled {
 				logger.SetLevel(logger.DebugLevel)
@@ -60,9 +72,23 @@ func main() {
 	rootCmd.AddCommand(cmd.NewConfigCmd())        // config
 	rootCmd.AddCommand(cmd.NewUpdateCmd(version)) // update
 	rootCmd.AddCommand(cmd.NewReviewCmd())        // review
+	rootCmd.AddCommand(cmd.NewUsageCmd())         // usage
+	rootCmd.AddCommand(cmd.NewCacheCmd())         // cache
+	rootCmd.AddCommand(cmd.NewModelsCmd())        // models
+	rootCmd.AddCommand(cmd.NewProviderGroupCmd()) // provider
+	rootCmd.AddCommand(cmd.NewHookCmd())          // hook
+	rootCmd.AddCommand(cmd.NewRewordCmd())        // reword
+	rootCmd.AddCommand(cmd.NewSplitCmd())         // split
 
-	if err := rootCmd.Execute(); err != nil {
-		// fmt.Fprintln(os.Stderr, err)
+	ctx, stop := cmd.NotifyContext(context.Background())
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if cmd.IsCancelled(ctx, err) {
+			cmd.PrintCancelled(os.Stderr)
+			os.Exit(cmd.ExitCodeCancelled)
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
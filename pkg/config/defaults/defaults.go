@@ -12,8 +12,14 @@ const (
 	DefaultTemperature      = 0.3
 	DefaultTopP             = 1.0
 	DefaultFrequencyPenalty = 0.0
+	DefaultChunkTokenBudget = 8000
+	DefaultCacheTTL         = 86400
+	DefaultCacheMaxSizeMB   = 50
 )
 
+// DefaultProtectedBranches are the branches reword refuses to rewrite without --force
+var DefaultProtectedBranches = []string{"main", "master"}
+
 // defaultConfig returns a default configuration map for gptcomet.
 //
 // The configuration map contains the default values for the provider, file
@@ -32,6 +38,18 @@ const (
 //   - markdown_theme: the default markdown theme for the output
 //   - console:
 //   - verbose: true
+//   - chunk:
+//   - enabled: false
+//   - token_budget: 8000
+//   - cache:
+//   - enabled: true
+//   - ttl: 86400 (seconds)
+//   - max_size_mb: 50
+//   - pricing: an empty price table, mapping model names to their price in USD
+//     per million input, output and cached input tokens
+//   - fallback_providers: an empty list of providers to try when the provider fails
+//   - custom_providers: an empty map of OpenAI-compatible providers declared in the config
+//   - protected_branches: ["main", "master"], the branches reword doesn't rewrite
 //   - openai:
 //   - api_base: the default API base for the OpenAI provider
 //   - api_key: an empty string (must be set by the user)
@@ -87,6 +105,19 @@ func defaultConfig() map[string]interface{} {
 		"console": map[string]interface{}{
 			"verbose": true,
 		},
+		"chunk": map[string]interface{}{
+			"enabled":      false,
+			"token_budget": DefaultChunkTokenBudget,
+		},
+		"cache": map[string]interface{}{
+			"enabled":     true,
+			"ttl":         DefaultCacheTTL,
+			"max_size_mb": DefaultCacheMaxSizeMB,
+		},
+		"pricing":            map[string]interface{}{},
+		"fallback_providers": []string{},
+		"custom_providers":   map[string]interface{}{},
+		"protected_branches": DefaultProtectedBranches,
 		"openai": map[string]interface{}{
 			"api_base":          DefaultAPIBase,
 			"api_key":           "",
@@ -243,6 +274,39 @@ Requirements:
 Clearly separate necessary improvements from optional suggestions. Only include points relevant to the provided diff.  
 
 THE CODE PATCH TO BE REVIEWED:
+{{ placeholder }}`,
+	"feedback": `Revise the commit message you just wrote according to the feedback below.
+Keep following the guidelines and format of the original request, and base the message on the same git diff.
+
+FEEDBACK:
+{{ placeholder }}
+
+Reply with the revised commit message only.
+Revised Commit Message:`,
+	"chunk_summary": `You are an expert software engineer. The git diff below is one part of a larger change that is too big to review at once.
+Summarize what this part changes, file by file, in a few concise bullet points.
+Mention added, removed or renamed files, and the purpose of the changes when it is clear from the code.
+Do not write a commit message and do not speculate about parts of the change you can't see.
+
+GIT DIFF PART:
+{{ placeholder }}
+
+Summary:`,
+	"split": `You are an expert software engineer. The staged changes below are too many for a single commit.
+Group them into a few coherent commits, each with a single purpose, such as one feature, one fix or one refactoring.
+Every change is numbered. A change is a hunk of a modified file, or a whole file for added, deleted, renamed and binary files.
+Changes marked as ignored only show their file, group them with the changes they belong to.
+
+Rules:
+1. Every change belongs to exactly one commit. Keep changes that depend on each other in the same commit.
+2. Order the commits so that every commit builds on the previous ones.
+3. Write a commit message for every commit in the Conventional Commits format, "<type>(<scope>): <subject>", with a subject of less than 72 characters.
+4. Use as few commits as needed, a single commit is fine when all changes belong together.
+
+Reply with JSON only, in this format:
+{"commits": [{"message": "feat(auth): add login endpoint", "changes": [1, 3]}, {"message": "docs: describe login", "changes": [2]}]}
+
+STAGED CHANGES:
 {{ placeholder }}`,
 }
 
@@ -47,6 +47,24 @@ func TestPromptDefaults(t *testing.T) {
 				"{{ placeholder }}",
 			},
 		},
+		{
+			name: "feedback prompt",
+			key:  "feedback",
+			contains: []string{
+				"commit message",
+				"FEEDBACK",
+				"{{ placeholder }}",
+			},
+		},
+		{
+			name: "chunk summary prompt",
+			key:  "chunk_summary",
+			contains: []string{
+				"git diff",
+				"Summarize",
+				"{{ placeholder }}",
+			},
+		},
 	}
 
 	for _, tt := range tests {
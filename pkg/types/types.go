@@ -1,5 +1,28 @@
 package types
 
+import "fmt"
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Context guard modes, used when a prompt doesn't fit in the context window of the model
+const (
+	ContextGuardFail = "fail"
+	ContextGuardWarn = "warn"
+	ContextGuardOff  = "off"
+)
+
+// Auth styles of declarative providers, how the API key is sent
+const (
+	AuthStyleBearer = "bearer" // "Authorization: Bearer <key>", or the bearer token in auth_header
+	AuthStyleHeader = "header" // The plain key in auth_header, such as "x-api-key: <key>"
+	AuthStyleNone   = "none"   // No API key is sent or required
+)
+
 // Message represents a chat message
 type Message struct {
 	Role    string `json:"role"`
@@ -28,11 +51,88 @@ type Choice struct {
 	Message Message `json:"message"`
 }
 
-// Usage represents token usage information
+// Usage represents token usage information.
+// PromptTokens include CachedTokens, and CompletionTokens include ReasoningTokens.
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	CachedTokens     int `json:"cached_tokens,omitempty"`    // Prompt tokens read from the provider's cache
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"` // Completion tokens spent on reasoning
+}
+
+// Add adds the token counts of other to u
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.CachedTokens += other.CachedTokens
+	u.ReasoningTokens += other.ReasoningTokens
+}
+
+// String formats the token counts, cached and reasoning tokens are only shown when present
+func (u Usage) String() string {
+	prompt := fmt.Sprintf("prompt: %d", u.PromptTokens)
+	if u.CachedTokens > 0 {
+		prompt += fmt.Sprintf(" (cached: %d)", u.CachedTokens)
+	}
+	completion := fmt.Sprintf("completion: %d", u.CompletionTokens)
+	if u.ReasoningTokens > 0 {
+		completion += fmt.Sprintf(" (reasoning: %d)", u.ReasoningTokens)
+	}
+	return fmt.Sprintf("%s, %s, total: %d", prompt, completion, u.TotalTokens)
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input       float64 `json:"input" yaml:"input"`
+	Output      float64 `json:"output" yaml:"output"`
+	CachedInput float64 `json:"cached_input,omitempty" yaml:"cached_input,omitempty"` // Falls back to Input when zero
+}
+
+// Cost returns the estimated cost of usage in USD
+func (p ModelPrice) Cost(usage Usage) float64 {
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	uncached := usage.PromptTokens - usage.CachedTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*p.Input +
+		float64(usage.CachedTokens)*cachedPrice +
+		float64(usage.CompletionTokens)*p.Output) / 1_000_000
+}
+
+// UsagePaths are the gjson paths of the token counts in a response. Empty
+// paths are not read, and the total defaults to prompt plus completion tokens.
+type UsagePaths struct {
+	PromptTokens     string `json:"prompt_tokens,omitempty" yaml:"prompt_tokens,omitempty"`
+	CompletionTokens string `json:"completion_tokens,omitempty" yaml:"completion_tokens,omitempty"`
+	TotalTokens      string `json:"total_tokens,omitempty" yaml:"total_tokens,omitempty"`
+	CachedTokens     string `json:"cached_tokens,omitempty" yaml:"cached_tokens,omitempty"`
+	ReasoningTokens  string `json:"reasoning_tokens,omitempty" yaml:"reasoning_tokens,omitempty"`
+}
+
+// IsEmpty reports whether no usage path is set
+func (p UsagePaths) IsEmpty() bool {
+	return p == UsagePaths{}
+}
+
+// ProviderDefinition declares an OpenAI-compatible provider in the
+// custom_providers section of the config file, so it can be used without
+// a provider implementation in code.
+type ProviderDefinition struct {
+	Name             string     `json:"name" yaml:"-"`
+	APIBase          string     `json:"api_base" yaml:"api_base"`
+	DefaultModel     string     `json:"default_model,omitempty" yaml:"default_model,omitempty"`
+	AuthStyle        string     `json:"auth_style,omitempty" yaml:"auth_style,omitempty"`   // bearer (default), header or none
+	AuthHeader       string     `json:"auth_header,omitempty" yaml:"auth_header,omitempty"` // Header of the API key, defaults to Authorization
+	CompletionPath   string     `json:"completion_path,omitempty" yaml:"completion_path,omitempty"`
+	AnswerPath       string     `json:"answer_path,omitempty" yaml:"answer_path,omitempty"`
+	StreamAnswerPath string     `json:"stream_answer_path,omitempty" yaml:"stream_answer_path,omitempty"`
+	UsagePaths       UsagePaths `json:"usage_paths,omitempty" yaml:"usage_paths,omitempty"` // OpenAI-compatible usage when empty
 }
 
 // ClientConfig represents the configuration for an LLM client
@@ -61,8 +161,25 @@ type ClientConfig struct {
 	ExtraBody         map[string]interface{} `json:"extra_body,omitempty"`
 	Proxy             string                 `json:"proxy,omitempty"`
 	Retries           int                    `json:"retries"`
-	Timeout           int64                  `json:"timeout"`
+	Timeout           int64                  `json:"timeout"` // Seconds a request may take in total, 0 means no limit
 	Provider          string                 `json:"provider"`
-	ProjectID         string                 `json:"project_id,omitempty"` // Vertex AI project ID
-	Location          string                 `json:"location,omitempty"`   // Vertex AI location
+	Type              string                 `json:"type,omitempty"`           // Built-in provider to use, e.g. exec, when it isn't the provider name
+	ProjectID         string                 `json:"project_id,omitempty"`     // Vertex AI project ID
+	Location          string                 `json:"location,omitempty"`       // Vertex AI location
+	ContextWindow     int                    `json:"context_window,omitempty"` // Overrides the known context window of the model
+	ContextGuard      string                 `json:"context_guard,omitempty"`  // fail, warn or off when the prompt doesn't fit
+	Command           string                 `json:"command,omitempty"`        // Executable of the exec provider
+	CommandArgs       []string               `json:"args,omitempty"`           // Arguments of the exec provider command
+
+	MaxIdleConns        int   `json:"max_idle_conns,omitempty"`          // Maximum idle connections across all hosts
+	MaxIdleConnsPerHost int   `json:"max_idle_conns_per_host,omitempty"` // Maximum idle connections per host
+	IdleConnTimeout     int64 `json:"idle_conn_timeout,omitempty"`       // Seconds an idle connection is kept open
+	DisableCompression  *bool `json:"disable_compression,omitempty"`     // Don't request gzip responses, nil uses the default
+
+	ConnectTimeout    int64 `json:"connect_timeout,omitempty"`     // Seconds to wait for a connection, 0 uses the default
+	StreamIdleTimeout int64 `json:"stream_idle_timeout,omitempty"` // Seconds a stream may send no data before it's aborted, 0 means no limit
+	StreamUsage       *bool `json:"stream_usage,omitempty"`        // Ask OpenAI-compatible streams for their usage, nil uses the default
+
+	// Definition of the provider when it is declared in the config file
+	Definition *ProviderDefinition `json:"-"`
 }